@@ -55,3 +55,18 @@ type SASLServer interface {
 
 	VerifySASL(mechanism SASLMechanism) (SASLVerifier, error)
 }
+
+type GSSVerifier interface {
+	// Write handles a GSSAPI token from the client and returns the token to send back. It returns io.EOF once the client
+	// is authenticated
+	Write(token []byte) ([]byte, error)
+}
+
+type GSSServer interface {
+	Credentials
+
+	VerifyGSS() (GSSVerifier, error)
+
+	// VerifyGSSPrincipal checks a principal which was already authenticated while negotiating GSSAPI encryption
+	VerifyGSSPrincipal(principal string) error
+}
@@ -0,0 +1,66 @@
+package credentials
+
+import (
+	"io"
+	"strings"
+
+	"gfx.cafe/gfx/pggat/lib/auth"
+	"gfx.cafe/gfx/pggat/lib/auth/gss"
+)
+
+// GSS authenticates clients with kerberos. The client principal must match Username.
+type GSS struct {
+	Acceptor *gss.Acceptor
+	Username string
+
+	// IncludeRealm compares the whole principal (name@REALM) to Username instead of only the name
+	IncludeRealm bool
+	// Realm is the realm principals must be from. Optional
+	Realm string
+}
+
+func (GSS) Credentials() {}
+
+func (T GSS) VerifyGSS() (auth.GSSVerifier, error) {
+	return &gssVerifier{
+		creds: T,
+	}, nil
+}
+
+func (T GSS) VerifyGSSPrincipal(principal string) error {
+	name, realm, _ := strings.Cut(principal, "@")
+
+	if T.Realm != "" && T.Realm != realm {
+		return auth.ErrFailed
+	}
+
+	if T.IncludeRealm {
+		name = principal
+	}
+	if name != T.Username {
+		return auth.ErrFailed
+	}
+
+	return nil
+}
+
+type gssVerifier struct {
+	creds GSS
+}
+
+func (T *gssVerifier) Write(token []byte) ([]byte, error) {
+	context, resp, err := T.creds.Acceptor.Accept(token)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = T.creds.VerifyGSSPrincipal(context.Principal); err != nil {
+		return nil, err
+	}
+
+	// kerberos contexts are established in a single round trip
+	return resp, io.EOF
+}
+
+var _ auth.Credentials = GSS{}
+var _ auth.GSSServer = GSS{}
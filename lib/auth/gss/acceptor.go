@@ -0,0 +1,183 @@
+package gss
+
+import (
+	"crypto/rand"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+
+	"github.com/jcmturner/gofork/encoding/asn1"
+	"github.com/jcmturner/gokrb5/v8/asn1tools"
+	"github.com/jcmturner/gokrb5/v8/crypto"
+	"github.com/jcmturner/gokrb5/v8/gssapi"
+	"github.com/jcmturner/gokrb5/v8/iana"
+	"github.com/jcmturner/gokrb5/v8/iana/asnAppTag"
+	"github.com/jcmturner/gokrb5/v8/iana/chksumtype"
+	"github.com/jcmturner/gokrb5/v8/iana/keyusage"
+	"github.com/jcmturner/gokrb5/v8/iana/msgtype"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/service"
+)
+
+var (
+	ErrUnsupportedMechanism = errors.New("only the kerberos 5 GSSAPI mechanism is supported")
+	ErrInvalidToken         = errors.New("invalid GSSAPI token")
+)
+
+// token ids of RFC 4121 context tokens
+var (
+	tokIDAPReq = [2]byte{0x01, 0x00}
+	tokIDAPRep = [2]byte{0x02, 0x00}
+)
+
+// flags of the RFC 4121 authenticator checksum
+const (
+	flagMutual = 0x02
+)
+
+// Acceptor accepts kerberos 5 GSSAPI security contexts with the keys of a keytab
+type Acceptor struct {
+	settings *service.Settings
+}
+
+// NewAcceptor creates an Acceptor. If principal is empty, tickets for any service principal in the keytab are accepted.
+func NewAcceptor(kt *keytab.Keytab, principal string) *Acceptor {
+	options := []func(*service.Settings){
+		service.DecodePAC(false),
+	}
+	if principal != "" {
+		options = append(options, service.KeytabPrincipal(principal))
+	}
+
+	return &Acceptor{
+		settings: service.NewSettings(kt, options...),
+	}
+}
+
+// LoadAcceptor loads a keytab file and creates an Acceptor with it
+func LoadAcceptor(keytabPath string, principal string) (*Acceptor, error) {
+	kt, err := keytab.Load(keytabPath)
+	if err != nil {
+		return nil, fmt.Errorf("loading keytab: %w", err)
+	}
+
+	return NewAcceptor(kt, principal), nil
+}
+
+func unmarshalAPReq(token []byte) (messages.APReq, error) {
+	var apReq messages.APReq
+
+	var oid asn1.ObjectIdentifier
+	rest, err := asn1.UnmarshalWithParams(token, &oid, "application,explicit,tag:0")
+	if err != nil {
+		return apReq, ErrInvalidToken
+	}
+	if !oid.Equal(gssapi.OIDKRB5.OID()) {
+		return apReq, ErrUnsupportedMechanism
+	}
+	if len(rest) < 2 || rest[0] != tokIDAPReq[0] || rest[1] != tokIDAPReq[1] {
+		return apReq, ErrInvalidToken
+	}
+
+	if err = apReq.Unmarshal(rest[2:]); err != nil {
+		return apReq, err
+	}
+
+	return apReq, nil
+}
+
+func marshalAPRep(apReq *messages.APReq, seqNumber int64) ([]byte, error) {
+	encPart := messages.EncAPRepPart{
+		CTime:          apReq.Authenticator.CTime,
+		Cusec:          apReq.Authenticator.Cusec,
+		SequenceNumber: seqNumber,
+	}
+	b, err := asn1.Marshal(encPart)
+	if err != nil {
+		return nil, err
+	}
+	b = asn1tools.AddASNAppTag(b, asnAppTag.EncAPRepPart)
+
+	ed, err := crypto.GetEncryptedData(b, apReq.Ticket.DecryptedEncPart.Key, keyusage.AP_REP_ENCPART, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	apRep := messages.APRep{
+		PVNO:    iana.PVNO,
+		MsgType: msgtype.KRB_AP_REP,
+		EncPart: ed,
+	}
+	b, err = asn1.Marshal(apRep)
+	if err != nil {
+		return nil, err
+	}
+	b = asn1tools.AddASNAppTag(b, asnAppTag.APREP)
+
+	token, err := asn1.Marshal(gssapi.OIDKRB5.OID())
+	if err != nil {
+		return nil, err
+	}
+	token = append(token, tokIDAPRep[:]...)
+	token = append(token, b...)
+	return asn1tools.AddASNAppTag(token, 0), nil
+}
+
+// Accept verifies the initial context token of a client. It returns the established context and the token to send
+// back to the client, if any.
+func (T *Acceptor) Accept(token []byte) (*Context, []byte, error) {
+	apReq, err := unmarshalAPReq(token)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	ok, creds, err := service.VerifyAPREQ(&apReq, T.settings)
+	if err != nil {
+		return nil, nil, err
+	}
+	if !ok {
+		return nil, nil, ErrInvalidToken
+	}
+
+	authenticator := &apReq.Authenticator
+
+	var mutual bool
+	if authenticator.Cksum.CksumType == chksumtype.GSSAPI {
+		if len(authenticator.Cksum.Checksum) < 24 {
+			return nil, nil, ErrInvalidToken
+		}
+		mutual = binary.LittleEndian.Uint32(authenticator.Cksum.Checksum[20:24])&flagMutual != 0
+	}
+
+	ctx := &Context{
+		Principal: creds.CName().PrincipalNameString() + "@" + creds.Realm(),
+		key:       apReq.Ticket.DecryptedEncPart.Key,
+		acceptor:  true,
+		recvSeq:   uint64(authenticator.SeqNumber),
+		sendSeq:   uint64(authenticator.SeqNumber),
+	}
+	if authenticator.SubKey.KeyType != 0 {
+		ctx.key = authenticator.SubKey
+	}
+
+	if !mutual {
+		return ctx, nil, nil
+	}
+
+	seq, err := rand.Int(rand.Reader, big.NewInt(math.MaxUint32))
+	if err != nil {
+		return nil, nil, err
+	}
+	seqNumber := seq.Int64()&0x3fffffff + 1
+	ctx.sendSeq = uint64(seqNumber)
+
+	resp, err := marshalAPRep(&apReq, seqNumber)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return ctx, resp, nil
+}
@@ -0,0 +1,173 @@
+package gss
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// MaxPacketSize is the max size of a length prefixed token, including the length, in postgres GSSAPI encryption
+const MaxPacketSize = 16384
+
+var ErrPacketTooLarge = errors.New("GSSAPI packet too large")
+
+func readToken(r io.Reader) ([]byte, error) {
+	var length [4]byte
+	if _, err := io.ReadFull(r, length[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(length[:])
+	if n > MaxPacketSize-4 {
+		return nil, ErrPacketTooLarge
+	}
+
+	token := make([]byte, n)
+	if _, err := io.ReadFull(r, token); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func writeToken(w io.Writer, token []byte) error {
+	if len(token) > MaxPacketSize-4 {
+		return ErrPacketTooLarge
+	}
+
+	packet := make([]byte, 4+len(token))
+	binary.BigEndian.PutUint32(packet, uint32(len(token)))
+	copy(packet[4:], token)
+	_, err := w.Write(packet)
+	return err
+}
+
+// Conn is a net.Conn encrypted with GSSAPI the way postgres does it: each wrap token is sent prefixed with its length
+type Conn struct {
+	conn    net.Conn
+	context *Context
+
+	maxMessage int
+
+	rmu    sync.Mutex
+	unread []byte
+
+	wmu sync.Mutex
+}
+
+// Server establishes a security context with the client on conn and returns a Conn encrypted with it. conn must be
+// positioned right after the server accepted the GSSENCRequest.
+func Server(conn net.Conn, acceptor *Acceptor) (*Conn, error) {
+	token, err := readToken(conn)
+	if err != nil {
+		return nil, err
+	}
+
+	context, resp, err := acceptor.Accept(token)
+	if err != nil {
+		return nil, err
+	}
+	if !context.CanWrap() {
+		return nil, ErrUnsupportedEncryptionType
+	}
+
+	if len(resp) > 0 {
+		if err = writeToken(conn, resp); err != nil {
+			return nil, err
+		}
+	}
+
+	return NewConn(conn, context)
+}
+
+// NewConn creates a Conn from an established security context
+func NewConn(conn net.Conn, context *Context) (*Conn, error) {
+	overhead, err := context.Overhead()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Conn{
+		conn:       conn,
+		context:    context,
+		maxMessage: MaxPacketSize - 4 - overhead,
+	}, nil
+}
+
+// Context returns the security context of the conn
+func (T *Conn) Context() *Context {
+	return T.context
+}
+
+func (T *Conn) Read(b []byte) (int, error) {
+	T.rmu.Lock()
+	defer T.rmu.Unlock()
+
+	for len(T.unread) == 0 {
+		token, err := readToken(T.conn)
+		if err != nil {
+			return 0, err
+		}
+
+		T.unread, err = T.context.Unwrap(token)
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n := copy(b, T.unread)
+	T.unread = T.unread[n:]
+	return n, nil
+}
+
+func (T *Conn) Write(b []byte) (int, error) {
+	T.wmu.Lock()
+	defer T.wmu.Unlock()
+
+	var n int
+	for n < len(b) {
+		message := b[n:]
+		if len(message) > T.maxMessage {
+			message = message[:T.maxMessage]
+		}
+
+		token, err := T.context.Wrap(message)
+		if err != nil {
+			return n, err
+		}
+		if err = writeToken(T.conn, token); err != nil {
+			return n, err
+		}
+
+		n += len(message)
+	}
+
+	return n, nil
+}
+
+func (T *Conn) Close() error {
+	return T.conn.Close()
+}
+
+func (T *Conn) LocalAddr() net.Addr {
+	return T.conn.LocalAddr()
+}
+
+func (T *Conn) RemoteAddr() net.Addr {
+	return T.conn.RemoteAddr()
+}
+
+func (T *Conn) SetDeadline(t time.Time) error {
+	return T.conn.SetDeadline(t)
+}
+
+func (T *Conn) SetReadDeadline(t time.Time) error {
+	return T.conn.SetReadDeadline(t)
+}
+
+func (T *Conn) SetWriteDeadline(t time.Time) error {
+	return T.conn.SetWriteDeadline(t)
+}
+
+var _ net.Conn = (*Conn)(nil)
@@ -0,0 +1,174 @@
+package gss
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/jcmturner/gokrb5/v8/crypto"
+	"github.com/jcmturner/gokrb5/v8/gssapi"
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/iana/keyusage"
+	"github.com/jcmturner/gokrb5/v8/types"
+)
+
+var (
+	ErrUnsupportedEncryptionType = errors.New("GSSAPI encryption requires an AES kerberos key")
+	ErrBadSequence               = errors.New("GSSAPI token out of sequence")
+)
+
+// flags of RFC 4121 wrap tokens
+const (
+	flagSentByAcceptor = 0x01
+	flagSealed         = 0x02
+	flagAcceptorSubkey = 0x04
+)
+
+// Context is an established security context. It can seal messages with RFC 4121 wrap tokens.
+type Context struct {
+	// Principal is the authenticated client principal, in name@REALM form
+	Principal string
+
+	key      types.EncryptionKey
+	acceptor bool
+
+	sendSeq uint64
+	recvSeq uint64
+}
+
+// CanWrap returns whether the key of the context can be used to seal messages. Only RFC 4121 enctypes are supported.
+func (T *Context) CanWrap() bool {
+	switch T.key.KeyType {
+	case etypeID.AES128_CTS_HMAC_SHA1_96,
+		etypeID.AES256_CTS_HMAC_SHA1_96,
+		etypeID.AES128_CTS_HMAC_SHA256_128,
+		etypeID.AES256_CTS_HMAC_SHA384_192:
+		return true
+	default:
+		return false
+	}
+}
+
+// Overhead returns the number of bytes Wrap adds to a message
+func (T *Context) Overhead() (int, error) {
+	et, err := crypto.GetEtype(T.key.KeyType)
+	if err != nil {
+		return 0, err
+	}
+
+	// header | encrypt(confounder | message | header) | hmac
+	return gssapi.HdrLen*2 + et.GetConfounderByteSize() + et.GetHMACBitLength()/8, nil
+}
+
+func (T *Context) usages() (send, recv uint32) {
+	if T.acceptor {
+		return keyusage.GSSAPI_ACCEPTOR_SEAL, keyusage.GSSAPI_INITIATOR_SEAL
+	}
+	return keyusage.GSSAPI_INITIATOR_SEAL, keyusage.GSSAPI_ACCEPTOR_SEAL
+}
+
+func makeHeader(flags byte, ec, rrc uint16, seq uint64) []byte {
+	header := make([]byte, gssapi.HdrLen)
+	header[0] = 0x05
+	header[1] = 0x04
+	header[2] = flags
+	header[3] = gssapi.FillerByte
+	binary.BigEndian.PutUint16(header[4:6], ec)
+	binary.BigEndian.PutUint16(header[6:8], rrc)
+	binary.BigEndian.PutUint64(header[8:16], seq)
+	return header
+}
+
+// Wrap seals message into a wrap token
+func (T *Context) Wrap(message []byte) ([]byte, error) {
+	if !T.CanWrap() {
+		return nil, ErrUnsupportedEncryptionType
+	}
+
+	var flags byte = flagSealed
+	if T.acceptor {
+		flags |= flagSentByAcceptor
+	}
+	header := makeHeader(flags, 0, 0, T.sendSeq)
+
+	usage, _ := T.usages()
+	et, err := crypto.GetEtype(T.key.KeyType)
+	if err != nil {
+		return nil, err
+	}
+
+	plaintext := make([]byte, 0, len(message)+len(header))
+	plaintext = append(plaintext, message...)
+	plaintext = append(plaintext, header...)
+	_, ciphertext, err := et.EncryptMessage(T.key.KeyValue, plaintext, usage)
+	if err != nil {
+		return nil, err
+	}
+
+	T.sendSeq++
+
+	return append(header, ciphertext...), nil
+}
+
+// Unwrap opens a wrap token sealed by the peer
+func (T *Context) Unwrap(token []byte) ([]byte, error) {
+	if len(token) < gssapi.HdrLen {
+		return nil, ErrInvalidToken
+	}
+	if token[0] != 0x05 || token[1] != 0x04 || token[3] != gssapi.FillerByte {
+		return nil, ErrInvalidToken
+	}
+
+	flags := token[2]
+	if flags&flagSealed == 0 {
+		// only sealed tokens are accepted, the messages must be confidential
+		return nil, ErrInvalidToken
+	}
+	if (flags&flagSentByAcceptor != 0) == T.acceptor {
+		return nil, ErrInvalidToken
+	}
+	if flags&flagAcceptorSubkey != 0 {
+		// we never assert an acceptor subkey
+		return nil, ErrInvalidToken
+	}
+
+	ec := binary.BigEndian.Uint16(token[4:6])
+	rrc := binary.BigEndian.Uint16(token[6:8])
+	seq := binary.BigEndian.Uint64(token[8:16])
+	if seq != T.recvSeq {
+		return nil, ErrBadSequence
+	}
+
+	// undo the right rotation
+	ciphertext := make([]byte, len(token)-gssapi.HdrLen)
+	if len(ciphertext) > 0 {
+		n := int(rrc) % len(ciphertext)
+		copy(ciphertext, token[gssapi.HdrLen+n:])
+		copy(ciphertext[len(ciphertext)-n:], token[gssapi.HdrLen:gssapi.HdrLen+n])
+	}
+
+	_, usage := T.usages()
+	plaintext, err := crypto.DecryptMessage(ciphertext, T.key, usage)
+	if err != nil {
+		return nil, err
+	}
+
+	// message | filler | header
+	if len(plaintext) < int(ec)+gssapi.HdrLen {
+		return nil, ErrInvalidToken
+	}
+	header := plaintext[len(plaintext)-gssapi.HdrLen:]
+	expected := makeHeader(flags, ec, 0, seq)
+	for i := range header {
+		// the rrc only applies to the outer header
+		if i == 6 || i == 7 {
+			continue
+		}
+		if header[i] != expected[i] {
+			return nil, ErrInvalidToken
+		}
+	}
+
+	T.recvSeq++
+
+	return plaintext[:len(plaintext)-gssapi.HdrLen-int(ec)], nil
+}
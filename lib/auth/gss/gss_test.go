@@ -0,0 +1,256 @@
+package gss
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/jcmturner/gofork/encoding/asn1"
+	"github.com/jcmturner/gokrb5/v8/asn1tools"
+	"github.com/jcmturner/gokrb5/v8/crypto"
+	"github.com/jcmturner/gokrb5/v8/gssapi"
+	"github.com/jcmturner/gokrb5/v8/iana/chksumtype"
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/iana/keyusage"
+	"github.com/jcmturner/gokrb5/v8/iana/nametype"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/types"
+)
+
+const (
+	testRealm   = "EXAMPLE.COM"
+	testService = "postgres/db.example.com"
+	testClient  = "alice"
+)
+
+// kdc stands in for a KDC. It issues service tickets for a keytab the same way a real KDC would.
+type kdc struct {
+	keytab *keytab.Keytab
+}
+
+func newKDC(t *testing.T) *kdc {
+	kt := keytab.New()
+	if err := kt.AddEntry(testService, testRealm, "service password", time.Now(), 1, etypeID.AES256_CTS_HMAC_SHA1_96); err != nil {
+		t.Fatal(err)
+	}
+	return &kdc{
+		keytab: kt,
+	}
+}
+
+// initiate creates the initial context token of a client and returns the initiator's half of the context
+func (T *kdc) initiate(t *testing.T, mutual bool) ([]byte, *Context, types.EncryptionKey) {
+	now := time.Now().UTC()
+	cname := types.NewPrincipalName(nametype.KRB_NT_PRINCIPAL, testClient)
+	sname := types.NewPrincipalName(nametype.KRB_NT_SRV_INST, testService)
+
+	ticket, sessionKey, err := messages.NewTicket(
+		cname, testRealm,
+		sname, testRealm,
+		types.NewKrbFlags(),
+		T.keytab,
+		etypeID.AES256_CTS_HMAC_SHA1_96,
+		1,
+		now, now, now.Add(time.Hour), now.Add(time.Hour),
+	)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	authenticator, err := types.NewAuthenticator(testRealm, cname)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err = authenticator.GenerateSeqNumberAndSubKey(etypeID.AES256_CTS_HMAC_SHA1_96, 32); err != nil {
+		t.Fatal(err)
+	}
+
+	checksum := make([]byte, 24)
+	binary.LittleEndian.PutUint32(checksum[0:4], 16)
+	if mutual {
+		binary.LittleEndian.PutUint32(checksum[20:24], flagMutual)
+	}
+	authenticator.Cksum = types.Checksum{
+		CksumType: chksumtype.GSSAPI,
+		Checksum:  checksum,
+	}
+
+	apReq, err := messages.NewAPReq(ticket, sessionKey, authenticator)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := apReq.Marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	token, err := asn1.Marshal(gssapi.OIDKRB5.OID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	token = append(token, tokIDAPReq[:]...)
+	token = append(token, b...)
+
+	initiator := &Context{
+		key:     authenticator.SubKey,
+		sendSeq: uint64(authenticator.SeqNumber),
+		recvSeq: uint64(authenticator.SeqNumber),
+	}
+
+	return asn1tools.AddASNAppTag(token, 0), initiator, sessionKey
+}
+
+// complete verifies the AP-REP of the acceptor and finishes the initiator's half of the context
+func complete(t *testing.T, initiator *Context, sessionKey types.EncryptionKey, token []byte) {
+	var oid asn1.ObjectIdentifier
+	rest, err := asn1.UnmarshalWithParams(token, &oid, "application,explicit,tag:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !oid.Equal(gssapi.OIDKRB5.OID()) || len(rest) < 2 || rest[0] != tokIDAPRep[0] || rest[1] != tokIDAPRep[1] {
+		t.Fatal("expected AP-REP")
+	}
+
+	var apRep messages.APRep
+	if err = apRep.Unmarshal(rest[2:]); err != nil {
+		t.Fatal(err)
+	}
+	b, err := crypto.DecryptEncPart(apRep.EncPart, sessionKey, keyusage.AP_REP_ENCPART)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var encPart messages.EncAPRepPart
+	if err = encPart.Unmarshal(b); err != nil {
+		t.Fatal(err)
+	}
+
+	initiator.recvSeq = uint64(encPart.SequenceNumber)
+}
+
+func TestServer(t *testing.T) {
+	k := newKDC(t)
+	acceptor := NewAcceptor(k.keytab, testService)
+
+	clientConn, serverConn := net.Pipe()
+	defer func() {
+		_ = clientConn.Close()
+		_ = serverConn.Close()
+	}()
+
+	type result struct {
+		conn *Conn
+		err  error
+	}
+	accepted := make(chan result, 1)
+	go func() {
+		conn, err := Server(serverConn, acceptor)
+		accepted <- result{conn, err}
+	}()
+
+	token, initiator, sessionKey := k.initiate(t, true)
+	if err := writeToken(clientConn, token); err != nil {
+		t.Fatal(err)
+	}
+	resp, err := readToken(clientConn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	complete(t, initiator, sessionKey, resp)
+
+	res := <-accepted
+	if res.err != nil {
+		t.Fatal(res.err)
+	}
+	server := res.conn
+	if server.Context().Principal != testClient+"@"+testRealm {
+		t.Errorf("unexpected principal %q", server.Context().Principal)
+	}
+
+	client, err := NewConn(clientConn, initiator)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// larger than a single packet
+	message := make([]byte, MaxPacketSize*3)
+	if _, err = rand.Read(message); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, pair := range [][2]*Conn{{client, server}, {server, client}} {
+		from, to := pair[0], pair[1]
+
+		written := make(chan error, 1)
+		go func() {
+			_, err := from.Write(message)
+			written <- err
+		}()
+
+		received := make([]byte, len(message))
+		if _, err = io.ReadFull(to, received); err != nil {
+			t.Fatal(err)
+		}
+		if err = <-written; err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(received, message) {
+			t.Error("message was corrupted")
+		}
+	}
+}
+
+func TestAcceptWithoutMutual(t *testing.T) {
+	k := newKDC(t)
+	acceptor := NewAcceptor(k.keytab, "")
+
+	token, initiator, _ := k.initiate(t, false)
+	context, resp, err := acceptor.Accept(token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(resp) != 0 {
+		t.Error("expected no AP-REP without mutual authentication")
+	}
+
+	wrapped, err := initiator.Wrap([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	unwrapped, err := context.Unwrap(wrapped)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(unwrapped) != "hello" {
+		t.Errorf("unexpected message %q", unwrapped)
+	}
+
+	// tampered tokens are rejected
+	wrapped, err = initiator.Wrap([]byte("hello"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	wrapped[len(wrapped)-1] ^= 0xFF
+	if _, err = context.Unwrap(wrapped); err == nil {
+		t.Error("expected tampered token to be rejected")
+	}
+}
+
+func TestAcceptWrongKey(t *testing.T) {
+	k := newKDC(t)
+
+	other := keytab.New()
+	if err := other.AddEntry(testService, testRealm, "another password", time.Now(), 1, etypeID.AES256_CTS_HMAC_SHA1_96); err != nil {
+		t.Fatal(err)
+	}
+	acceptor := NewAcceptor(other, "")
+
+	token, _, _ := k.initiate(t, true)
+	if _, _, err := acceptor.Accept(token); err == nil {
+		t.Error("expected ticket for another key to be rejected")
+	}
+}
@@ -188,9 +188,22 @@ func startup0(ctx context.Context, params *acceptParams) (done bool, err error)
 		}
 		return authentication(ctx, params, &p)
 	case packets.TypeNegotiateProtocolVersion:
-		// we only support protocol 3.0 for now
-		err = errors.New("server wanted to negotiate protocol version")
-		return
+		var p packets.NegotiateProtocolVersion
+		err = fed.ToConcrete(&p, packet)
+		if err != nil {
+			return
+		}
+		if len(p.UnrecognizedProtocolOptions) > 0 {
+			err = errors.New("server did not recognize protocol options")
+			return
+		}
+		if p.MinorProtocolVersion < 0 || p.MinorProtocolVersion > int32(params.Conn.MinorVersion) {
+			err = errors.New("server wanted to negotiate an unsupported protocol version")
+			return
+		}
+		// older servers fall back to an earlier minor version
+		params.Conn.MinorVersion = int16(p.MinorProtocolVersion)
+		return false, nil
 	default:
 		err = ErrUnexpectedPacket(packet.Type())
 		return
@@ -211,7 +224,7 @@ func startup1(ctx context.Context, params *acceptParams) (done bool, err error)
 		if err != nil {
 			return
 		}
-		params.Conn.BackendKey.SecretKey = p.SecretKey
+		params.Conn.BackendKey.SecretKey = string(p.SecretKey)
 		params.Conn.BackendKey.ProcessID = p.ProcessID
 
 		return false, nil
@@ -293,7 +306,7 @@ func accept(ctx context.Context, params *acceptParams) error {
 	}
 
 	m := packets.StartupPayloadVersion3{
-		MinorVersion: 0,
+		MinorVersion: fed.MinorVersionLatest,
 		Parameters: []packets.StartupPayloadVersion3PayloadParameter{
 			{
 				Key:   "user",
@@ -321,6 +334,7 @@ func accept(ctx context.Context, params *acceptParams) error {
 	if err != nil {
 		return err
 	}
+	params.Conn.MinorVersion = m.MinorVersion
 
 	for {
 		var done bool
@@ -2,6 +2,7 @@ package backends
 
 import (
 	"context"
+
 	"gfx.cafe/gfx/pggat/lib/fed"
 	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
 )
@@ -11,7 +12,7 @@ func Cancel(ctx context.Context, server *fed.Conn, key fed.BackendKey) error {
 		Mode: &packets.StartupPayloadControl{
 			Mode: &packets.StartupPayloadControlPayloadCancel{
 				ProcessID: key.ProcessID,
-				SecretKey: key.SecretKey,
+				SecretKey: []byte(key.SecretKey),
 			},
 		},
 	}
@@ -2,6 +2,10 @@ package backends
 
 import (
 	"context"
+	"errors"
+	"net"
+	"time"
+
 	"gfx.cafe/gfx/pggat/lib/fed"
 )
 
@@ -11,6 +15,9 @@ type serverToPeerBinding struct {
 	Packet    fed.Packet
 	PeerError error
 	TxState   byte
+
+	// IdleTimeout is how long the peer may be idle in a transaction before it is failed. 0 = no timeout
+	IdleTimeout time.Duration
 }
 
 func (T *serverToPeerBinding) ErrUnexpectedPacket() error {
@@ -58,6 +65,51 @@ func (T *serverToPeerBinding) PeerRead(ctx context.Context) bool {
 	return true
 }
 
+// PeerBuffered returns whether the peer has already sent its next packet
+func (T *serverToPeerBinding) PeerBuffered() bool {
+	if T == nil {
+		return false
+	}
+	if !T.PeerOK() {
+		return false
+	}
+	return T.Peer.Buffered(true)
+}
+
+// PeerReadIdle is like PeerRead, but the peer will be failed with ErrIdleInTransactionTimeout if it does not send a
+// packet within IdleTimeout.
+func (T *serverToPeerBinding) PeerReadIdle(ctx context.Context) bool {
+	if T == nil {
+		return false
+	}
+	if T.IdleTimeout == 0 {
+		return T.PeerRead(ctx)
+	}
+
+	if T.PeerRead(fed.WithReadDeadline(ctx, time.Now().Add(T.IdleTimeout))) {
+		return true
+	}
+
+	var netErr net.Error
+	if errors.As(T.PeerError, &netErr) && netErr.Timeout() {
+		T.PeerError = ErrIdleInTransactionTimeout
+	}
+	return false
+}
+
+func (T *serverToPeerBinding) PeerFlush(ctx context.Context) {
+	if T == nil {
+		return
+	}
+	if !T.PeerOK() {
+		return
+	}
+	err := T.Peer.Flush(ctx)
+	if err != nil {
+		T.PeerFail(err)
+	}
+}
+
 func (T *serverToPeerBinding) PeerWrite(ctx context.Context) {
 	if T == nil {
 		return
@@ -5,6 +5,7 @@ import (
 	"fmt"
 
 	"gfx.cafe/gfx/pggat/lib/fed"
+	"gfx.cafe/gfx/pggat/lib/perror"
 )
 
 func ErrUnexpectedPacket(typ fed.Type) error {
@@ -14,4 +15,11 @@ func ErrUnexpectedPacket(typ fed.Type) error {
 var (
 	ErrExpectedIdle                     = errors.New("expected server to return ReadyForQuery(IDLE)")
 	ErrUnexpectedAuthenticationResponse = errors.New("unexpected authentication response")
+	ErrCopyBothPeerFailed               = errors.New("peer failed during CopyBoth, server is still copying")
+	ErrCopyBothAborted                  = errors.New("server aborted CopyBoth")
+	ErrIdleInTransactionTimeout         = perror.New(
+		perror.FATAL,
+		perror.IdleInTransactionSessionTimeout,
+		"terminating connection due to idle-in-transaction timeout",
+	)
 )
@@ -3,12 +3,17 @@ package backends
 import (
 	"context"
 	"strings"
+	"time"
 
 	"gfx.cafe/gfx/pggat/lib/fed"
 	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
 	"gfx.cafe/gfx/pggat/lib/util/strutil"
 )
 
+// maxPipelineSyncs is how many Syncs may be forwarded before their responses are read. The server stops reading while
+// its output is full, so forwarding without a limit could deadlock.
+const maxPipelineSyncs = 64
+
 func copyIn(ctx context.Context, binding *serverToPeerBinding) error {
 	binding.PeerWrite(ctx)
 
@@ -56,6 +61,82 @@ func copyOut(ctx context.Context, binding *serverToPeerBinding) error {
 	}
 }
 
+// copyBoth streams CopyData in both directions at once until both sides are done. The peer is read in another
+// goroutine, so the server and peer conns must support being read and written concurrently.
+func copyBoth(ctx context.Context, binding *serverToPeerBinding) error {
+	binding.PeerWrite(ctx)
+	if !binding.PeerOK() {
+		return ErrCopyBothPeerFailed
+	}
+
+	peer := binding.Peer
+	server := binding.Server
+
+	peerDone := make(chan error, 1)
+	go func() {
+		peerDone <- func() error {
+			for {
+				packet, err := peer.ReadPacket(ctx, true)
+				if err != nil {
+					return err
+				}
+
+				switch packet.Type() {
+				case packets.TypeCopyData, packets.TypeCopyDone, packets.TypeCopyFail:
+					if err = server.WritePacket(ctx, packet); err != nil {
+						return err
+					}
+					if err = server.Flush(ctx); err != nil {
+						return err
+					}
+					if packet.Type() != packets.TypeCopyData {
+						return nil
+					}
+				default:
+					return ErrUnexpectedPacket(packet.Type())
+				}
+			}
+		}()
+	}()
+
+	for {
+		err := binding.ServerRead(ctx)
+		if err != nil {
+			return err
+		}
+
+		switch binding.Packet.Type() {
+		case packets.TypeCopyData,
+			packets.TypeNoticeResponse,
+			packets.TypeParameterStatus,
+			packets.TypeNotificationResponse:
+			if err = peer.WritePacket(ctx, binding.Packet); err != nil {
+				return err
+			}
+			if err = peer.Flush(ctx); err != nil {
+				return err
+			}
+		case packets.TypeCopyDone:
+			if err = peer.WritePacket(ctx, binding.Packet); err != nil {
+				return err
+			}
+
+			// wait for the peer to finish too
+			if err = <-peerDone; err != nil {
+				binding.PeerFail(err)
+				return ErrCopyBothPeerFailed
+			}
+			return nil
+		case packets.TypeMarkiplierResponse:
+			// the peer can't be told to stop sending, so the session is over
+			binding.PeerWrite(ctx)
+			return ErrCopyBothAborted
+		default:
+			return binding.ErrUnexpectedPacket()
+		}
+	}
+}
+
 func query(ctx context.Context, binding *serverToPeerBinding) error {
 	if err := binding.ServerWrite(ctx); err != nil {
 		return err
@@ -85,6 +166,10 @@ func query(ctx context.Context, binding *serverToPeerBinding) error {
 			if err = copyOut(ctx, binding); err != nil {
 				return err
 			}
+		case packets.TypeCopyBothResponse:
+			if err = copyBoth(ctx, binding); err != nil {
+				return err
+			}
 		case packets.TypeReadyForQuery:
 			var p packets.ReadyForQuery
 			err = fed.ToConcrete(&p, binding.Packet)
@@ -117,16 +202,35 @@ func QueryString(ctx context.Context, server, peer *fed.Conn, query string) (err
 	return
 }
 
+// SetParameter sets a session-level parameter with set_config, which parses the value like the config file does. Unlike
+// SET, list values such as search_path round trip in the form SHOW and ParameterStatus report them.
 func SetParameter(ctx context.Context, server, peer *fed.Conn, name strutil.CIString, value string) (err, peerError error) {
 	var q strings.Builder
-	escapedName := strutil.Escape(name.String(), '"')
+	escapedName := strutil.Escape(name.String(), '\'')
 	escapedValue := strutil.Escape(value, '\'')
-	q.Grow(len(`SET "" = ''`) + len(escapedName) + len(escapedValue))
-	q.WriteString(`SET "`)
+	q.Grow(len(`SELECT pg_catalog.set_config('', '', false)`) + len(escapedName) + len(escapedValue))
+	q.WriteString(`SELECT pg_catalog.set_config('`)
 	q.WriteString(escapedName)
-	q.WriteString(`" = '`)
+	q.WriteString(`', '`)
 	q.WriteString(escapedValue)
-	q.WriteString(`'`)
+	q.WriteString(`', false)`)
+
+	return QueryString(
+		ctx,
+		server,
+		peer,
+		q.String(),
+	)
+}
+
+// ResetParameter resets a session-level parameter to its default
+func ResetParameter(ctx context.Context, server, peer *fed.Conn, name strutil.CIString) (err, peerError error) {
+	var q strings.Builder
+	escapedName := strutil.Escape(name.String(), '"')
+	q.Grow(len(`RESET ""`) + len(escapedName))
+	q.WriteString(`RESET "`)
+	q.WriteString(escapedName)
+	q.WriteString(`"`)
 
 	return QueryString(
 		ctx,
@@ -170,57 +274,71 @@ func functionCall(ctx context.Context, binding *serverToPeerBinding) error {
 	}
 }
 
+// syncResponse reads and forwards one packet of the response to a Sync. done is true once the response is over, and
+// synced is true if it ended with ReadyForQuery. A COPY FROM STDIN swallows the Sync, so a new one is needed after it.
+func syncResponse(ctx context.Context, binding *serverToPeerBinding) (done, synced bool, err error) {
+	err = binding.ServerRead(ctx)
+	if err != nil {
+		return false, false, err
+	}
+
+	switch binding.Packet.Type() {
+	case packets.TypeParseComplete,
+		packets.TypeBindComplete,
+		packets.TypeCloseComplete,
+		packets.TypeMarkiplierResponse,
+		packets.TypeRowDescription,
+		packets.TypeNoData,
+		packets.TypeParameterDescription,
+
+		packets.TypeCommandComplete,
+		packets.TypeDataRow,
+		packets.TypeEmptyQueryResponse,
+		packets.TypePortalSuspended,
+
+		packets.TypeNoticeResponse,
+		packets.TypeParameterStatus,
+		packets.TypeNotificationResponse:
+		binding.PeerWrite(ctx)
+		return false, false, nil
+	case packets.TypeCopyInResponse:
+		if err = copyIn(ctx, binding); err != nil {
+			return false, false, err
+		}
+		// why
+		return true, false, nil
+	case packets.TypeCopyOutResponse:
+		if err = copyOut(ctx, binding); err != nil {
+			return false, false, err
+		}
+		return false, false, nil
+	case packets.TypeReadyForQuery:
+		var p packets.ReadyForQuery
+		err = fed.ToConcrete(&p, binding.Packet)
+		if err != nil {
+			return false, false, err
+		}
+		binding.Packet = &p
+		binding.TxState = byte(p)
+		binding.PeerWrite(ctx)
+		return true, true, nil
+	default:
+		return false, false, binding.ErrUnexpectedPacket()
+	}
+}
+
 func sync(ctx context.Context, binding *serverToPeerBinding) (bool, error) {
 	if err := binding.ServerWrite(ctx); err != nil {
 		return false, err
 	}
 
 	for {
-		err := binding.ServerRead(ctx)
+		done, synced, err := syncResponse(ctx, binding)
 		if err != nil {
 			return false, err
 		}
-
-		switch binding.Packet.Type() {
-		case packets.TypeParseComplete,
-			packets.TypeBindComplete,
-			packets.TypeCloseComplete,
-			packets.TypeMarkiplierResponse,
-			packets.TypeRowDescription,
-			packets.TypeNoData,
-			packets.TypeParameterDescription,
-
-			packets.TypeCommandComplete,
-			packets.TypeDataRow,
-			packets.TypeEmptyQueryResponse,
-			packets.TypePortalSuspended,
-
-			packets.TypeNoticeResponse,
-			packets.TypeParameterStatus,
-			packets.TypeNotificationResponse:
-			binding.PeerWrite(ctx)
-		case packets.TypeCopyInResponse:
-			if err = copyIn(ctx, binding); err != nil {
-				return false, err
-			}
-			// why
-			return false, nil
-		case packets.TypeCopyOutResponse:
-			if err = copyOut(ctx, binding); err != nil {
-				return false, err
-			}
-		case packets.TypeReadyForQuery:
-			var p packets.ReadyForQuery
-			err = fed.ToConcrete(&p, binding.Packet)
-			if err != nil {
-				return false, err
-			}
-			binding.Packet = &p
-			binding.TxState = byte(p)
-			binding.PeerWrite(ctx)
-			return true, nil
-		default:
-			return false, binding.ErrUnexpectedPacket()
+		if done {
+			return synced, nil
 		}
 	}
 }
@@ -236,41 +354,100 @@ func Sync(ctx context.Context, server, peer *fed.Conn) (err, peerErr error) {
 	return
 }
 
-func eqp(ctx context.Context, binding *serverToPeerBinding) error {
+// finishSyncs reads the responses of the Syncs in flight. If the last batch is open, or COPY swallows a Sync, a Sync
+// is sent in its place.
+func finishSyncs(ctx context.Context, binding *serverToPeerBinding, syncs int, open bool) error {
+	packet := binding.Packet
+
+	for syncs > 0 || open {
+		if open {
+			binding.Packet = &packets.Sync{}
+			if err := binding.ServerWrite(ctx); err != nil {
+				return err
+			}
+			syncs++
+			open = false
+		}
+
+		done, synced, err := syncResponse(ctx, binding)
+		if err != nil {
+			return err
+		}
+		if done {
+			syncs--
+			open = !synced
+		}
+	}
+
+	binding.Packet = packet
+	return nil
+}
+
+// eqp forwards extended query messages until every Sync has been answered. Messages the peer has already sent are
+// forwarded while the server works on earlier Syncs, so pipelines aren't serialized. If the peer sends something
+// that isn't part of the pipeline, eqp finishes the pipeline and returns true with the packet left in binding.Packet.
+func eqp(ctx context.Context, binding *serverToPeerBinding) (bool, error) {
 	if err := binding.ServerWrite(ctx); err != nil {
-		return err
+		return false, err
 	}
 
+	// syncs is the number of Syncs waiting for a response
+	var syncs int
+	// open is whether messages were sent since the last Sync
+	open := true
 	for {
-		if !binding.PeerRead(ctx) {
-			for {
-				binding.Packet = &packets.Sync{}
-				ok, err := sync(ctx, binding)
-				if err != nil {
-					return err
+		if !binding.PeerOK() {
+			return false, finishSyncs(ctx, binding, syncs, open)
+		}
+
+		if syncs == 0 || (syncs < maxPipelineSyncs && binding.PeerBuffered()) {
+			if !binding.PeerRead(ctx) {
+				continue
+			}
+
+			switch binding.Packet.Type() {
+			case packets.TypeSync:
+				if err := binding.ServerWrite(ctx); err != nil {
+					return false, err
 				}
-				if ok {
-					return nil
+				syncs++
+				open = false
+			case packets.TypeParse, packets.TypeBind, packets.TypeClose, packets.TypeDescribe, packets.TypeExecute, packets.TypeFlush:
+				if err := binding.ServerWrite(ctx); err != nil {
+					return false, err
 				}
+				open = true
+			default:
+				if syncs == 0 || open {
+					binding.PeerFail(binding.ErrUnexpectedPacket())
+					continue
+				}
+
+				return true, finishSyncs(ctx, binding, syncs, false)
 			}
+			continue
 		}
 
-		switch binding.Packet.Type() {
-		case packets.TypeSync:
-			ok, err := sync(ctx, binding)
-			if err != nil {
-				return err
-			}
-			if ok {
-				return nil
-			}
-		case packets.TypeParse, packets.TypeBind, packets.TypeClose, packets.TypeDescribe, packets.TypeExecute, packets.TypeFlush:
-			if err := binding.ServerWrite(ctx); err != nil {
-				return err
-			}
-		default:
-			binding.PeerFail(binding.ErrUnexpectedPacket())
+		done, synced, err := syncResponse(ctx, binding)
+		if err != nil {
+			return false, err
+		}
+		if !done {
+			continue
+		}
+
+		syncs--
+		if !synced {
+			// COPY swallowed the Sync, the peer will send another
+			open = true
+			continue
+		}
+		if syncs == 0 && !open {
+			return false, nil
 		}
+
+		// let the peer see the results so far
+		binding.PeerFlush(ctx)
 	}
 }
 
@@ -291,9 +468,13 @@ func transaction(ctx context.Context, binding *serverToPeerBinding) error {
 			binding.Packet = &rfq
 			binding.PeerWrite(ctx)
 		case packets.TypeParse, packets.TypeBind, packets.TypeClose, packets.TypeDescribe, packets.TypeExecute, packets.TypeFlush:
-			if err := eqp(ctx, binding); err != nil {
+			next, err := eqp(ctx, binding)
+			if err != nil {
 				return err
 			}
+			if next {
+				continue
+			}
 		default:
 			binding.PeerFail(binding.ErrUnexpectedPacket())
 		}
@@ -302,7 +483,7 @@ func transaction(ctx context.Context, binding *serverToPeerBinding) error {
 			return nil
 		}
 
-		if !binding.PeerRead(ctx) {
+		if !binding.PeerReadIdle(ctx) {
 			// abort tx
 			err := queryString(ctx, binding, "ABORT;")
 			if err != nil {
@@ -318,10 +499,17 @@ func transaction(ctx context.Context, binding *serverToPeerBinding) error {
 }
 
 func Transaction(ctx context.Context, server, peer *fed.Conn, initialPacket fed.Packet) (err, peerError error) {
+	return TransactionIdleTimeout(ctx, server, peer, initialPacket, 0)
+}
+
+// TransactionIdleTimeout is like Transaction, but the peer is disconnected with ErrIdleInTransactionTimeout if it is
+// idle inside the transaction for longer than idleTimeout. The transaction is aborted on the server. 0 = no timeout
+func TransactionIdleTimeout(ctx context.Context, server, peer *fed.Conn, initialPacket fed.Packet, idleTimeout time.Duration) (err, peerError error) {
 	pgState := serverToPeerBinding{
-		Server: server,
-		Peer:   peer,
-		Packet: initialPacket,
+		Server:      server,
+		Peer:        peer,
+		Packet:      initialPacket,
+		IdleTimeout: idleTimeout,
 	}
 	err = transaction(ctx, &pgState)
 	peerError = pgState.PeerError
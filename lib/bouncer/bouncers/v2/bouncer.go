@@ -2,6 +2,8 @@ package bouncers
 
 import (
 	"context"
+	"time"
+
 	"gfx.cafe/gfx/pggat/lib/bouncer/backends/v0"
 	"gfx.cafe/gfx/pggat/lib/fed"
 )
@@ -10,3 +12,10 @@ func Bounce(ctx context.Context, client, server *fed.Conn, initialPacket fed.Pac
 	serverError, clientError = backends.Transaction(ctx, server, client, initialPacket)
 	return
 }
+
+// BounceIdleTimeout is like Bounce, but the client is disconnected if it is idle in a transaction for longer than
+// idleTimeout. 0 = no timeout
+func BounceIdleTimeout(ctx context.Context, client, server *fed.Conn, initialPacket fed.Packet, idleTimeout time.Duration) (clientError error, serverError error) {
+	serverError, clientError = backends.TransactionIdleTimeout(ctx, server, client, initialPacket, idleTimeout)
+	return
+}
@@ -3,8 +3,10 @@ package frontends
 import (
 	"context"
 	"crypto/tls"
+	"net"
 	"strings"
 
+	"gfx.cafe/gfx/pggat/lib/auth/gss"
 	"gfx.cafe/gfx/pggat/lib/fed"
 	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
 	"gfx.cafe/gfx/pggat/lib/perror"
@@ -43,8 +45,16 @@ func startup0(
 		switch control := mode.Mode.(type) {
 		case *packets.StartupPayloadControlPayloadCancel:
 			// Cancel
+			if len(control.SecretKey) == 0 || len(control.SecretKey) > fed.MaxSecretKeyLength {
+				err = perror.New(
+					perror.FATAL,
+					perror.ProtocolViolation,
+					"Invalid cancel secret key length",
+				)
+				return
+			}
 			result.CancelKey.ProcessID = control.ProcessID
-			result.CancelKey.SecretKey = control.SecretKey
+			result.CancelKey.SecretKey = string(control.SecretKey)
 			cancelling = true
 			done = true
 			return
@@ -64,8 +74,25 @@ func startup0(
 			}
 			return
 		case *packets.StartupPayloadControlPayloadGSSAPI:
-			// GSSAPI is not supported yet
-			err = params.Conn.WriteByte(ctx, 'N')
+			// gss encryption is not enabled
+			if params.Options.GSSAcceptor == nil {
+				err = params.Conn.WriteByte(ctx, 'N')
+				return
+			}
+
+			// do gss encryption
+			if err = params.Conn.WriteByte(ctx, 'G'); err != nil {
+				return
+			}
+			var gssConn *gss.Conn
+			if err = params.Conn.EnableGSS(ctx, func(conn net.Conn) (net.Conn, error) {
+				var err error
+				gssConn, err = gss.Server(conn, params.Options.GSSAcceptor)
+				return gssConn, err
+			}); err != nil {
+				return
+			}
+			params.Conn.GSSPrincipal = gssConn.Context().Principal
 			return
 		default:
 			err = perror.New(
@@ -117,12 +144,21 @@ func startup0(
 					}
 				}
 			case "replication":
-				err = perror.New(
-					perror.FATAL,
-					perror.FeatureNotSupported,
-					"Replication mode is not supported yet",
-				)
-				return
+				switch strings.ToLower(parameter.Value) {
+				case "true", "on", "yes", "1":
+					params.Conn.Replication = "true"
+				case "database":
+					params.Conn.Replication = "database"
+				case "false", "off", "no", "0":
+					params.Conn.Replication = ""
+				default:
+					err = perror.New(
+						perror.FATAL,
+						perror.InvalidParameterValue,
+						`invalid value for parameter "replication": "`+parameter.Value+`"`,
+					)
+					return
+				}
 			default:
 				if strings.HasPrefix(parameter.Key, "_pq_.") {
 					// we don't support protocol extensions at the moment
@@ -138,10 +174,15 @@ func startup0(
 			}
 		}
 
-		if mode.MinorVersion != 0 || len(unsupportedOptions) > 0 {
+		params.Conn.MinorVersion = mode.MinorVersion
+		if params.Conn.MinorVersion > fed.MinorVersionLatest {
+			params.Conn.MinorVersion = fed.MinorVersionLatest
+		}
+
+		if mode.MinorVersion != params.Conn.MinorVersion || len(unsupportedOptions) > 0 {
 			// negotiate protocol
 			uopts := packets.NegotiateProtocolVersion{
-				MinorProtocolVersion:        0,
+				MinorProtocolVersion:        int32(params.Conn.MinorVersion),
 				UnrecognizedProtocolOptions: unsupportedOptions,
 			}
 			err = params.Conn.WritePacket(ctx, &uopts)
@@ -206,7 +247,7 @@ func accept(ctx context.Context, params *acceptParams) (acceptResult, error) {
 	return result, nil
 }
 
-func Accept(conn *fed.Conn, tlsConfig *tls.Config) (
+func Accept(conn *fed.Conn, tlsConfig *tls.Config, gssAcceptor *gss.Acceptor) (
 	cancelKey fed.BackendKey,
 	isCanceling bool,
 	err error,
@@ -214,7 +255,8 @@ func Accept(conn *fed.Conn, tlsConfig *tls.Config) (
 	params := acceptParams{
 		Conn: conn,
 		Options: acceptOptions{
-			SSLConfig: tlsConfig,
+			SSLConfig:   tlsConfig,
+			GSSAcceptor: gssAcceptor,
 		},
 	}
 	var result acceptResult
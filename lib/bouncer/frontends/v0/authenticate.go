@@ -145,6 +145,67 @@ func (T *DBAuthenticator) authenticationSASL(ctx context.Context, params *authPa
 	return nil
 }
 
+func (T *DBAuthenticator) authenticationGSS(ctx context.Context, params *authParams, creds auth.GSSServer) error {
+	ctx, span := T.tracer.Start(ctx, "authenticationGSS", trace.WithSpanKind(trace.SpanKindInternal))
+	defer span.End()
+
+	if params.Conn.GSSPrincipal != "" {
+		// the client was already authenticated when GSSAPI encryption was negotiated
+		return creds.VerifyGSSPrincipal(params.Conn.GSSPrincipal)
+	}
+
+	gssInitial := packets.Authentication{
+		Mode: &packets.AuthenticationPayloadGSS{},
+	}
+	err := params.Conn.WritePacket(ctx, &gssInitial)
+	if err != nil {
+		return err
+	}
+
+	tool, err := creds.VerifyGSS()
+	if err != nil {
+		return err
+	}
+
+	for {
+		var packet fed.Packet
+		packet, err = params.Conn.ReadPacket(ctx, true)
+		if err != nil {
+			return err
+		}
+		var p packets.GSSResponse
+		err = fed.ToConcrete(&p, packet)
+		if err != nil {
+			return err
+		}
+
+		var resp []byte
+		var done bool
+		resp, err = tool.Write(p)
+		if err != nil {
+			if !errors.Is(err, io.EOF) {
+				return err
+			}
+			done = true
+		}
+
+		if len(resp) > 0 {
+			m := packets.AuthenticationPayloadGSSContinue(resp)
+			cont := packets.Authentication{
+				Mode: &m,
+			}
+			err = params.Conn.WritePacket(ctx, &cont)
+			if err != nil {
+				return err
+			}
+		}
+
+		if done {
+			return nil
+		}
+	}
+}
+
 func (T *DBAuthenticator) authenticationMD5(ctx context.Context, params *authParams, creds auth.MD5Server) error {
 	ctx, span := T.tracer.Start(ctx, "authenticationMD5", trace.WithSpanKind(trace.SpanKindInternal))
 	defer span.End()
@@ -185,7 +246,9 @@ func (T *DBAuthenticator) authenticationMD5(ctx context.Context, params *authPar
 
 func (T *DBAuthenticator) authenticate(ctx context.Context, params *authParams) (err error) {
 	if params.Options.Credentials != nil {
-		if credsSASL, ok := params.Options.Credentials.(auth.SASLServer); ok {
+		if credsGSS, ok := params.Options.Credentials.(auth.GSSServer); ok {
+			err = T.authenticationGSS(ctx, params, credsGSS)
+		} else if credsSASL, ok := params.Options.Credentials.(auth.SASLServer); ok {
 			err = T.authenticationSASL(ctx, params, credsSASL)
 		} else if credsMD5, ok := params.Options.Credentials.(auth.MD5Server); ok {
 			err = T.authenticationMD5(ctx, params, credsMD5)
@@ -215,18 +278,22 @@ func (T *DBAuthenticator) authenticate(ctx context.Context, params *authParams)
 	if _, err = rand.Reader.Read(processID[:]); err != nil {
 		return
 	}
-	var backendKey [4]byte
-	if _, err = rand.Reader.Read(backendKey[:]); err != nil {
+	// protocol 3.2 allows longer keys so they are harder to guess
+	secretKey := make([]byte, 4)
+	if params.Conn.MinorVersion >= 2 {
+		secretKey = make([]byte, 32)
+	}
+	if _, err = rand.Reader.Read(secretKey); err != nil {
 		return
 	}
 	params.Conn.BackendKey = fed.BackendKey{
 		ProcessID: int32(binary.BigEndian.Uint32(processID[:])),
-		SecretKey: int32(binary.BigEndian.Uint32(backendKey[:])),
+		SecretKey: string(secretKey),
 	}
 
 	keyData := packets.BackendKeyData{
 		ProcessID: params.Conn.BackendKey.ProcessID,
-		SecretKey: params.Conn.BackendKey.SecretKey,
+		SecretKey: secretKey,
 	}
 	if err = params.Conn.WritePacket(ctx, &keyData); err != nil {
 		return
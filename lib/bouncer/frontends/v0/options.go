@@ -4,10 +4,12 @@ import (
 	"crypto/tls"
 
 	"gfx.cafe/gfx/pggat/lib/auth"
+	"gfx.cafe/gfx/pggat/lib/auth/gss"
 )
 
 type acceptOptions struct {
-	SSLConfig *tls.Config
+	SSLConfig   *tls.Config
+	GSSAcceptor *gss.Acceptor
 }
 
 type authOptions struct {
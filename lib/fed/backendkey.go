@@ -1,6 +1,16 @@
 package fed
 
+const (
+	// MinorVersionLatest is the latest supported minor version of protocol 3
+	MinorVersionLatest = 2
+
+	// MaxSecretKeyLength is the max length of a cancel secret key in protocol 3.2
+	MaxSecretKeyLength = 256
+)
+
 type BackendKey struct {
 	ProcessID int32
-	SecretKey int32
+	// SecretKey is the raw cancel secret. It is 4 bytes in protocol 3.0 and up to MaxSecretKeyLength bytes in protocol
+	// 3.2. It is a string so BackendKey can be used as a map key
+	SecretKey string
 }
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"net"
 	"sync"
+	"time"
 
 	"gfx.cafe/gfx/pggat/lib/fed"
 	"gfx.cafe/gfx/pggat/lib/util/decorator"
@@ -17,11 +18,15 @@ type Codec struct {
 
 	conn net.Conn
 	ssl  bool
+	gss  bool
 
 	encoder fed.Encoder
 	decoder fed.Decoder
 
 	mu sync.RWMutex
+	// wmu guards the encoder so the conn can be read and written from different goroutines at once, such as when
+	// streaming CopyBoth
+	wmu sync.Mutex
 }
 
 func NewCodec(rw net.Conn) fed.PacketCodec {
@@ -34,6 +39,16 @@ func NewCodec(rw net.Conn) fed.PacketCodec {
 }
 
 func (c *Codec) ReadPacket(ctx context.Context, typed bool) (fed.Packet, error) {
+	// only the packet header is read here, so the deadline does not apply to the body
+	if deadline, ok := fed.ReadDeadline(ctx); ok {
+		if err := c.conn.SetReadDeadline(deadline); err != nil {
+			return nil, err
+		}
+		defer func() {
+			_ = c.conn.SetReadDeadline(time.Time{})
+		}()
+	}
+
 	if err := c.decoder.Next(typed); err != nil {
 		return nil, err
 	}
@@ -43,6 +58,9 @@ func (c *Codec) ReadPacket(ctx context.Context, typed bool) (fed.Packet, error)
 }
 
 func (c *Codec) WritePacket(ctx context.Context, packet fed.Packet) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
 	err := c.encoder.Next(packet.Type(), packet.Length())
 	if err != nil {
 		return err
@@ -51,6 +69,9 @@ func (c *Codec) WritePacket(ctx context.Context, packet fed.Packet) error {
 	return packet.WriteTo(&c.encoder)
 }
 func (c *Codec) WriteByte(ctx context.Context, b byte) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
 	return c.encoder.WriteByte(b)
 }
 
@@ -62,12 +83,19 @@ func (c *Codec) ReadByte(ctx context.Context) (byte, error) {
 	return c.decoder.ReadByte()
 }
 
+func (c *Codec) Buffered(typed bool) bool {
+	return c.decoder.NextBuffered(typed)
+}
+
 func (c *Codec) Flush(ctx context.Context) error {
+	c.wmu.Lock()
+	defer c.wmu.Unlock()
+
 	return c.encoder.Flush()
 }
 
 func (c *Codec) Close(ctx context.Context) error {
-	if err := c.encoder.Flush(); err != nil {
+	if err := c.Flush(ctx); err != nil {
 		return err
 	}
 	return c.conn.Close()
@@ -77,6 +105,10 @@ func (c *Codec) LocalAddr() net.Addr {
 	return c.conn.LocalAddr()
 }
 
+func (c *Codec) RemoteAddr() net.Addr {
+	return c.conn.RemoteAddr()
+}
+
 func (c *Codec) SSL() bool {
 	return c.ssl
 }
@@ -87,6 +119,9 @@ func (c *Codec) EnableSSL(ctx context.Context, config *tls.Config, isClient bool
 	if c.ssl {
 		return errors.New("SSL is already enabled")
 	}
+	if c.gss {
+		return errors.New("GSSAPI encryption is already enabled")
+	}
 	c.ssl = true
 
 	// Flush buffers
@@ -112,3 +147,36 @@ func (c *Codec) EnableSSL(ctx context.Context, config *tls.Config, isClient bool
 	}
 	return nil
 }
+
+func (c *Codec) GSS() bool {
+	return c.gss
+}
+
+func (c *Codec) EnableGSS(ctx context.Context, handshake func(conn net.Conn) (net.Conn, error)) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ssl {
+		return errors.New("SSL is already enabled")
+	}
+	if c.gss {
+		return errors.New("GSSAPI encryption is already enabled")
+	}
+	c.gss = true
+
+	// Flush buffers
+	if err := c.Flush(ctx); err != nil {
+		return err
+	}
+	if c.decoder.Buffered() > 0 {
+		return errors.New("expected empty read buffer")
+	}
+
+	gssConn, err := handshake(c.conn)
+	if err != nil {
+		return fmt.Errorf("gss handshake fail: %w", err)
+	}
+	c.encoder.Reset(gssConn)
+	c.decoder.Reset(gssConn)
+	c.conn = gssConn
+	return nil
+}
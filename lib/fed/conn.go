@@ -28,6 +28,15 @@ type Conn struct {
 	Database          string
 	InitialParameters map[strutil.CIString]string
 	BackendKey        BackendKey
+	// MinorVersion is the negotiated minor version of protocol 3
+	MinorVersion int16
+	// GSSPrincipal is the client principal authenticated while negotiating GSSAPI encryption. Empty if the conn is not
+	// GSSAPI encrypted
+	GSSPrincipal string
+
+	// Replication is the value of the replication startup parameter ("true" or "database"). Empty if this is not a
+	// replication connection
+	Replication string
 
 	Authenticated bool
 	Ready         bool
@@ -44,6 +53,12 @@ func (T *Conn) Flush(ctx context.Context) error {
 	return T.codec.Flush(ctx)
 }
 
+// Buffered returns whether the peer has already sent the next packet, so ReadPacket won't wait on it. Packets held by
+// middleware are not counted.
+func (T *Conn) Buffered(typed bool) bool {
+	return T.codec.Buffered(typed)
+}
+
 func (T *Conn) readPacket(ctx context.Context, typed bool) (Packet, error) {
 	return T.codec.ReadPacket(ctx, typed)
 }
@@ -161,6 +176,16 @@ func (T *Conn) WritePacket(ctx context.Context, packet Packet) error {
 	return nil
 }
 
+// WriteAsync writes and flushes packet straight to the codec, skipping middleware. Unlike WritePacket, it may be called
+// while another goroutine is reading from the conn, so it is meant for asynchronous messages that middleware does not
+// track, like NotificationResponse.
+func (T *Conn) WriteAsync(ctx context.Context, packet Packet) error {
+	if err := T.writePacket(ctx, packet); err != nil {
+		return err
+	}
+	return T.Flush(ctx)
+}
+
 func (T *Conn) WriteByte(ctx context.Context, b byte) error {
 	return T.codec.WriteByte(ctx, b)
 }
@@ -170,6 +195,12 @@ func (T *Conn) LocalAddr() net.Addr {
 
 }
 
+// RemoteAddr returns the address of the peer. For conns accepted with the PROXY protocol, this is the address of the
+// real client rather than the proxy.
+func (T *Conn) RemoteAddr() net.Addr {
+	return T.codec.RemoteAddr()
+}
+
 func (T *Conn) ReadByte(ctx context.Context) (byte, error) {
 	return T.codec.ReadByte(ctx)
 }
@@ -178,6 +209,10 @@ func (T *Conn) EnableSSL(ctx context.Context, config *tls.Config, isClient bool)
 	return T.codec.EnableSSL(ctx, config, isClient)
 }
 
+func (T *Conn) EnableGSS(ctx context.Context, handshake func(conn net.Conn) (net.Conn, error)) error {
+	return T.codec.EnableGSS(ctx, handshake)
+}
+
 func (T *Conn) Close(ctx context.Context) error {
 	return T.codec.Close(ctx)
 }
@@ -0,0 +1,21 @@
+package fed
+
+import (
+	"context"
+	"time"
+)
+
+type readDeadlineKey struct{}
+
+// WithReadDeadline returns a ctx which fails reads that are still waiting on the peer at deadline with a timeout
+// error. Codecs set it as the read deadline of the underlying conn for the read only, so it must not be used while
+// something else relies on a deadline of that conn. Plain ctx deadlines never touch the conn.
+func WithReadDeadline(ctx context.Context, deadline time.Time) context.Context {
+	return context.WithValue(ctx, readDeadlineKey{}, deadline)
+}
+
+// ReadDeadline returns the deadline set with WithReadDeadline
+func ReadDeadline(ctx context.Context) (time.Time, bool) {
+	deadline, ok := ctx.Value(readDeadlineKey{}).(time.Time)
+	return deadline, ok
+}
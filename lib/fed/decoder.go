@@ -157,6 +157,16 @@ func (T *Decoder) Buffered() int {
 	return T.bufferWrite - T.bufferRead
 }
 
+// NextBuffered returns whether the header of the next packet has already been read from the reader, so Next will not
+// block waiting for it
+func (T *Decoder) NextBuffered(typed bool) bool {
+	header := 4
+	if typed {
+		header = 5
+	}
+	return T.Buffered()-(T.packetLength-T.packetPos) >= header
+}
+
 var ErrOverranReadBuffer = errors.New("overran read buffer")
 
 func (T *Decoder) ReadByte() (byte, error) {
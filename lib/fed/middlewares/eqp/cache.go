@@ -0,0 +1,139 @@
+package eqp
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+)
+
+const (
+	// cachePrefix is the prefix of named prepared statements shared between clients
+	cachePrefix = "pggat_"
+	// placeholderName is closed in place of a Parse for a statement the server already has. It never exists, so the
+	// server always responds with a CloseComplete which is turned into the ParseComplete the client expects.
+	placeholderName = cachePrefix + "placeholder"
+)
+
+// CacheConfig configures sharing of named prepared statements between clients of a server
+type CacheConfig struct {
+	// MaxPreparedStatements is the max number of shared prepared statements kept on the server. The least recently
+	// used statements are closed past this.
+	MaxPreparedStatements int
+
+	// Hit is called when a client's prepared statement was already on the server. Optional
+	Hit func()
+	// Miss is called when a client's prepared statement had to be parsed on the server. Optional
+	Miss func()
+}
+
+type cache struct {
+	config CacheConfig
+
+	used map[string]uint64
+	tick uint64
+}
+
+// cachedName is the name of the shared prepared statement for p. Statements with the same query and parameter types
+// share a name.
+func cachedName(p *packets.Parse) string {
+	h := sha256.New()
+	h.Write([]byte(p.Query))
+	var buf [4]byte
+	for _, dataType := range p.ParameterDataTypes {
+		binary.BigEndian.PutUint32(buf[:], uint32(dataType))
+		h.Write(buf[:])
+	}
+	sum := h.Sum(nil)
+	return cachePrefix + hex.EncodeToString(sum[:16])
+}
+
+func isCachedName(name string) bool {
+	return strings.HasPrefix(name, cachePrefix)
+}
+
+func (T *cache) hit() {
+	if T.config.Hit != nil {
+		T.config.Hit()
+	}
+}
+
+func (T *cache) miss() {
+	if T.config.Miss != nil {
+		T.config.Miss()
+	}
+}
+
+// touch marks a shared prepared statement as used
+func (T *cache) touch(name string) {
+	if T.used == nil {
+		T.used = make(map[string]uint64)
+	}
+	T.tick++
+	T.used[name] = T.tick
+}
+
+// sharedStatements returns the names of the shared prepared statements on the server, counting pending parses and
+// leaving out statements in closing
+func sharedStatements(state *State, closing map[string]struct{}) map[string]struct{} {
+	names := make(map[string]struct{}, len(state.preparedStatements))
+	for name := range state.preparedStatements {
+		if isCachedName(name) {
+			names[name] = struct{}{}
+		}
+	}
+	for i := 0; i < state.pendingPreparedStatements.Length(); i++ {
+		if name := state.pendingPreparedStatements.Get(i).Destination; isCachedName(name) {
+			names[name] = struct{}{}
+		}
+	}
+	for name := range closing {
+		delete(names, name)
+	}
+	return names
+}
+
+// evict returns the least recently used shared prepared statements on the server which need to be closed to make room
+// for count more. Statements in closing are already being evicted, and statements in keep are never evicted.
+func (T *cache) evict(state *State, closing, keep map[string]struct{}, count int) []string {
+	if T.config.MaxPreparedStatements == 0 {
+		return nil
+	}
+
+	names := sharedStatements(state, closing)
+
+	// forget statements that are no longer on the server
+	for name := range T.used {
+		if _, ok := names[name]; !ok {
+			delete(T.used, name)
+		}
+	}
+
+	var candidates []string
+	total := 0
+	for name := range names {
+		total++
+
+		if _, ok := keep[name]; ok {
+			continue
+		}
+		candidates = append(candidates, name)
+	}
+
+	excess := total + count - T.config.MaxPreparedStatements
+	if excess <= 0 {
+		return nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return T.used[candidates[i]] < T.used[candidates[j]]
+	})
+
+	if excess > len(candidates) {
+		excess = len(candidates)
+	}
+	return candidates[:excess]
+}
@@ -3,30 +3,277 @@ package eqp
 import (
 	"context"
 	"gfx.cafe/gfx/pggat/lib/fed"
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
 )
 
 type Server struct {
 	state State
+
+	// cache is nil unless named prepared statements are shared between clients
+	cache *cache
+	// client is the client this server was last synced to
+	client *Client
+
+	// queue are packets to write after the current one. They are already tracked and renamed
+	queue []fed.Packet
+	// queued is the packet from queue being written, which is passed through as is
+	queued fed.Packet
+	// hidden are parses of evicted statements sent for the client, their ParseComplete is not forwarded
+	hidden map[*packets.Parse]bool
+	// closing are shared statements being evicted which haven't been parsed again since
+	closing map[string]struct{}
 }
 
 func NewServer() *Server {
 	return new(Server)
 }
 
+// NewCachingServer creates a Server which shares named prepared statements between clients. Client statement names
+// are rewritten to a name derived from the query, so clients preparing the same query reuse one statement on the
+// server.
+func NewCachingServer(config CacheConfig) *Server {
+	return &Server{
+		cache: &cache{
+			config: config,
+		},
+	}
+}
+
 func (T *Server) PreRead(ctx context.Context, _ bool) (fed.Packet, error) {
 	return nil, nil
 }
 
 func (T *Server) ReadPacket(ctx context.Context, packet fed.Packet) (fed.Packet, error) {
+	switch packet.Type() {
+	case packets.TypeCloseComplete:
+		if T.state.pendingCloses.Length() == 0 {
+			break
+		}
+		switch T.state.pendingCloses.Get(0).Variant {
+		case CloseVariantPlaceholder:
+			T.state.popClose()
+			return &packets.ParseComplete{}, nil
+		case CloseVariantEvict:
+			T.state.CloseComplete()
+			return nil, nil
+		}
+	case packets.TypeParseComplete:
+		if T.state.pendingPreparedStatements.Length() == 0 {
+			break
+		}
+		if front := T.state.pendingPreparedStatements.Get(0); T.hidden[front] {
+			delete(T.hidden, front)
+			T.state.ParseComplete()
+			return nil, nil
+		}
+	case packets.TypeReadyForQuery:
+		defer T.forgetFailed()
+	}
+
 	return T.state.S2C(packet)
 }
 
 func (T *Server) WritePacket(ctx context.Context, packet fed.Packet) (fed.Packet, error) {
-	return T.state.C2S(packet)
+	if T.queued != nil && packet == T.queued {
+		T.queued = nil
+		return packet, nil
+	}
+
+	if T.cache == nil {
+		return T.state.C2S(packet)
+	}
+
+	switch packet.Type() {
+	case packets.TypeParse:
+		return T.parse(packet)
+	case packets.TypeBind:
+		var p packets.Bind
+		if err := fed.ToConcrete(&p, packet); err != nil {
+			return nil, err
+		}
+		var err error
+		p.Source, err = T.rename(p.Source)
+		if err != nil {
+			return nil, err
+		}
+		bind, err := T.state.C2S(&p)
+		if err != nil {
+			return nil, err
+		}
+		return T.send(bind), nil
+	case packets.TypeDescribe:
+		var p packets.Describe
+		if err := fed.ToConcrete(&p, packet); err != nil {
+			return nil, err
+		}
+		if p.Which == 'S' {
+			var err error
+			p.Name, err = T.rename(p.Name)
+			if err != nil {
+				return nil, err
+			}
+		}
+		describe, err := T.state.C2S(&p)
+		if err != nil {
+			return nil, err
+		}
+		return T.send(describe), nil
+	default:
+		return T.state.C2S(packet)
+	}
 }
 
 func (T *Server) PostWrite(ctx context.Context) (fed.Packet, error) {
-	return nil, nil
+	T.queued = nil
+	if len(T.queue) == 0 {
+		return nil, nil
+	}
+
+	T.queued = T.queue[0]
+	T.queue = T.queue[1:]
+	return T.queued, nil
+}
+
+// forgetFailed forgets hidden parses and evictions which will never complete, and evictions which already did
+func (T *Server) forgetFailed() {
+	if T.state.pendingPreparedStatements.Length() == 0 {
+		clear(T.hidden)
+	}
+
+	for name := range T.closing {
+		pending := false
+		for i := 0; i < T.state.pendingCloses.Length(); i++ {
+			c := T.state.pendingCloses.Get(i)
+			if c.Variant == CloseVariantEvict && c.Target == name {
+				pending = true
+				break
+			}
+		}
+		if !pending {
+			delete(T.closing, name)
+		}
+	}
+}
+
+// send returns the first queued packet to write now and queues packet after the rest
+func (T *Server) send(packet fed.Packet) fed.Packet {
+	if len(T.queue) == 0 {
+		return packet
+	}
+
+	T.queue = append(T.queue, packet)
+	first := T.queue[0]
+	T.queue = T.queue[1:]
+	return first
+}
+
+// hasPreparedStatement returns whether the server has or is about to have the prepared statement
+func (T *Server) hasPreparedStatement(name string) bool {
+	if _, ok := T.closing[name]; ok {
+		return false
+	}
+	_, ok := T.state.PreparedStatement(name)
+	return ok
+}
+
+// parsing marks a shared statement as parsed on the server again, after any closes already sent
+func (T *Server) parsing(name string) {
+	delete(T.closing, name)
+}
+
+// makeRoom queues closes of the least recently used shared prepared statements so one more fits on the server
+func (T *Server) makeRoom(keep string) {
+	for _, name := range T.cache.evict(&T.state, T.closing, map[string]struct{}{keep: {}}, 1) {
+		if T.closing == nil {
+			T.closing = make(map[string]struct{})
+		}
+		T.closing[name] = struct{}{}
+		T.state.pushClose(Close{
+			Variant: CloseVariantEvict,
+			Target:  name,
+		})
+		T.queue = append(T.queue, &packets.Close{
+			Which: 'S',
+			Name:  name,
+		})
+	}
+}
+
+// rename returns the name of the shared prepared statement for the client's prepared statement. If the statement was
+// evicted while the client was paired, it is parsed again first without the client seeing it.
+func (T *Server) rename(name string) (string, error) {
+	if name == "" || T.client == nil {
+		return name, nil
+	}
+
+	preparedStatement, ok := T.client.state.PreparedStatement(name)
+	if !ok {
+		// let the server report that it doesn't exist
+		return name, nil
+	}
+
+	cached := cachedName(preparedStatement)
+	T.cache.touch(cached)
+
+	if !T.hasPreparedStatement(cached) {
+		T.makeRoom(cached)
+
+		T.parsing(cached)
+		p := *preparedStatement
+		p.Destination = cached
+		parse, err := T.state.Parse(&p)
+		if err != nil {
+			return "", err
+		}
+
+		if T.hidden == nil {
+			T.hidden = make(map[*packets.Parse]bool)
+		}
+		T.hidden[parse.(*packets.Parse)] = true
+		T.queue = append(T.queue, parse)
+	}
+
+	return cached, nil
+}
+
+func (T *Server) parse(packet fed.Packet) (fed.Packet, error) {
+	var p packets.Parse
+	if err := fed.ToConcrete(&p, packet); err != nil {
+		return nil, err
+	}
+
+	if p.Destination == "" {
+		// unnamed statements are overwritten all the time, don't bother sharing them
+		return T.state.C2S(&p)
+	}
+
+	p.Destination = cachedName(&p)
+	T.cache.touch(p.Destination)
+
+	if T.hasPreparedStatement(p.Destination) {
+		T.cache.hit()
+
+		T.state.pushClose(Close{
+			Variant: CloseVariantPlaceholder,
+			Target:  placeholderName,
+		})
+		return &packets.Close{
+			Which: 'S',
+			Name:  placeholderName,
+		}, nil
+	}
+
+	T.cache.miss()
+
+	// make room first, the closes are written before the parse
+	T.makeRoom(p.Destination)
+	T.parsing(p.Destination)
+
+	parse, err := T.state.C2S(&p)
+	if err != nil {
+		return nil, err
+	}
+	return T.send(parse), nil
 }
 
 var _ fed.Middleware = (*Server)(nil)
@@ -0,0 +1,137 @@
+package eqp
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+)
+
+// testWrite writes packet through the middleware like fed.Conn and returns what was sent to the server
+func testWrite(t *testing.T, s *Server, packet fed.Packet) []fed.Packet {
+	ctx := context.Background()
+
+	var sent []fed.Packet
+	packet, err := s.WritePacket(ctx, packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if packet != nil {
+		sent = append(sent, packet)
+	}
+
+	for {
+		packet, err = s.PostWrite(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if packet == nil {
+			return sent
+		}
+		packet, err = s.WritePacket(ctx, packet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if packet != nil {
+			sent = append(sent, packet)
+		}
+	}
+}
+
+// testRespond answers the packets sent to the server and returns what the client receives
+func testRespond(t *testing.T, s *Server, sent []fed.Packet) []fed.Packet {
+	var received []fed.Packet
+	for _, packet := range sent {
+		var response fed.Packet
+		switch packet.Type() {
+		case packets.TypeParse:
+			response = &packets.ParseComplete{}
+		case packets.TypeClose:
+			response = &packets.CloseComplete{}
+		case packets.TypeBind:
+			response = &packets.BindComplete{}
+		case packets.TypeSync:
+			rfq := packets.ReadyForQuery('I')
+			response = &rfq
+		default:
+			continue
+		}
+
+		response, err := s.ReadPacket(context.Background(), response)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if response != nil {
+			received = append(received, response)
+		}
+	}
+	return received
+}
+
+func TestServerEvictWhilePaired(t *testing.T) {
+	const max = 2
+
+	s := NewCachingServer(CacheConfig{
+		MaxPreparedStatements: max,
+	})
+	c := NewClient()
+	s.client = c
+
+	// one pairing prepares more statements than fit on the server
+	for i := 0; i < 5; i++ {
+		parse := &packets.Parse{
+			Destination: fmt.Sprintf("s%d", i),
+			Query:       fmt.Sprintf("select %d", i),
+		}
+		if _, err := c.state.C2S(parse); err != nil {
+			t.Fatal(err)
+		}
+
+		sent := testWrite(t, s, parse)
+		if sent[len(sent)-1].Type() != packets.TypeParse {
+			t.Fatalf("expected closes to be sent before the parse")
+		}
+		received := testRespond(t, s, sent)
+		if len(received) != 1 || received[0].Type() != packets.TypeParseComplete {
+			t.Fatalf("expected the client to only receive a ParseComplete but got %v", received)
+		}
+		c.state.ParseComplete()
+
+		if n := len(s.state.preparedStatements); n > max {
+			t.Fatalf("expected at most %d prepared statements on the server but got %d", max, n)
+		}
+	}
+
+	// the first statement was evicted, binding it parses it again without the client noticing
+	bind := &packets.Bind{
+		Source: "s0",
+	}
+	sent := testWrite(t, s, bind)
+	if len(sent) != 3 ||
+		sent[0].Type() != packets.TypeClose ||
+		sent[1].Type() != packets.TypeParse ||
+		sent[2].Type() != packets.TypeBind {
+		t.Fatalf("expected a close, parse, and bind but got %v", sent)
+	}
+	received := testRespond(t, s, sent)
+	if len(received) != 1 || received[0].Type() != packets.TypeBindComplete {
+		t.Fatalf("expected the client to only receive a BindComplete but got %v", received)
+	}
+
+	if n := len(s.state.preparedStatements); n > max {
+		t.Fatalf("expected at most %d prepared statements on the server but got %d", max, n)
+	}
+	if _, ok := s.state.preparedStatements[cachedName(&packets.Parse{Query: "select 0"})]; !ok {
+		t.Fatal("expected the evicted statement to be parsed again")
+	}
+
+	received = testRespond(t, s, testWrite(t, s, &packets.Sync{}))
+	if len(received) != 1 || received[0].Type() != packets.TypeReadyForQuery {
+		t.Fatalf("expected ReadyForQuery but got %v", received)
+	}
+	if len(s.hidden) != 0 || len(s.closing) != 0 {
+		t.Fatal("expected nothing to be pending after ReadyForQuery")
+	}
+}
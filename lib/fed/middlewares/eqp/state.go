@@ -12,6 +12,11 @@ type CloseVariant int
 const (
 	CloseVariantPreparedStatement CloseVariant = iota
 	CloseVariantPortal
+	// CloseVariantPlaceholder is a close sent in place of a Parse for a prepared statement the server already has
+	CloseVariantPlaceholder
+	// CloseVariantEvict is a close of a shared prepared statement sent to make room for another. The client didn't ask
+	// for it, so its CloseComplete is not forwarded
+	CloseVariantEvict
 )
 
 type Close struct {
@@ -19,6 +24,13 @@ type Close struct {
 	Target  string
 }
 
+// segment counts the pending parses, binds, and closes sent before a Sync
+type segment struct {
+	preparedStatements int
+	portals            int
+	closes             int
+}
+
 type State struct {
 	preparedStatements map[string]*packets.Parse
 	portals            map[string]*packets.Bind
@@ -26,6 +38,12 @@ type State struct {
 	pendingPreparedStatements ring.Ring[*packets.Parse]
 	pendingPortals            ring.Ring[*packets.Bind]
 	pendingCloses             ring.Ring[Close]
+
+	// segments are the pending counts of each Sync which hasn't received ReadyForQuery yet, oldest first. Pipelined
+	// clients can have many Syncs in flight, and an error only fails the pending of its own segment.
+	segments ring.Ring[segment]
+	// open is the pending counts of messages sent since the last Sync
+	open segment
 }
 
 // C2S is client to server packets
@@ -39,6 +57,10 @@ func (T *State) C2S(packet fed.Packet) (fed.Packet, error) {
 		return T.Bind(packet)
 	case packets.TypeQuery:
 		T.Query()
+		T.Sync()
+		return packet, nil
+	case packets.TypeSync, packets.TypeFunctionCall:
+		T.Sync()
 		return packet, nil
 	default:
 		return packet, nil
@@ -84,7 +106,7 @@ func (T *State) Close(packet fed.Packet) (fed.Packet, error) {
 		return nil, packets.ErrInvalidFormat
 	}
 
-	T.pendingCloses.PushBack(Close{
+	T.pushClose(Close{
 		Variant: variant,
 		Target:  p.Name,
 	})
@@ -92,9 +114,34 @@ func (T *State) Close(packet fed.Packet) (fed.Packet, error) {
 	return &p, nil
 }
 
+func (T *State) pushClose(c Close) {
+	T.pendingCloses.PushBack(c)
+	T.open.closes++
+}
+
+func (T *State) popClose() (Close, bool) {
+	c, ok := T.pendingCloses.PopFront()
+	if ok {
+		T.complete(func(s *segment) { s.closes-- })
+	}
+	return c, ok
+}
+
+// complete updates the counts of the segment the next response belongs to. Responses arrive in order, so that is the
+// oldest segment, or the open one if no Syncs are in flight.
+func (T *State) complete(fn func(s *segment)) {
+	s, ok := T.segments.PopFront()
+	if !ok {
+		fn(&T.open)
+		return
+	}
+	fn(&s)
+	T.segments.PushFront(s)
+}
+
 // CloseComplete notifies that a close was successful. Execute on CloseComplete S->C
 func (T *State) CloseComplete() {
-	c, ok := T.pendingCloses.PopFront()
+	c, ok := T.popClose()
 	if !ok {
 		return
 	}
@@ -102,7 +149,7 @@ func (T *State) CloseComplete() {
 	switch c.Variant {
 	case CloseVariantPortal:
 		delete(T.portals, c.Target)
-	case CloseVariantPreparedStatement:
+	case CloseVariantPreparedStatement, CloseVariantEvict:
 		delete(T.preparedStatements, c.Target)
 	default:
 		return
@@ -117,6 +164,7 @@ func (T *State) Parse(packet fed.Packet) (fed.Packet, error) {
 		return nil, err
 	}
 	T.pendingPreparedStatements.PushBack(&p)
+	T.open.preparedStatements++
 	return &p, nil
 }
 
@@ -126,6 +174,7 @@ func (T *State) ParseComplete() {
 	if !ok {
 		return
 	}
+	T.complete(func(s *segment) { s.preparedStatements-- })
 
 	if T.preparedStatements == nil {
 		T.preparedStatements = make(map[string]*packets.Parse)
@@ -141,6 +190,7 @@ func (T *State) Bind(packet fed.Packet) (fed.Packet, error) {
 		return nil, err
 	}
 	T.pendingPortals.PushBack(&p)
+	T.open.portals++
 	return &p, nil
 }
 
@@ -150,6 +200,7 @@ func (T *State) BindComplete() {
 	if !ok {
 		return
 	}
+	T.complete(func(s *segment) { s.portals-- })
 
 	if T.portals == nil {
 		T.portals = make(map[string]*packets.Bind)
@@ -157,6 +208,12 @@ func (T *State) BindComplete() {
 	T.portals[portal.Destination] = portal
 }
 
+// Sync ends the open segment. Execute on Sync, Query, and FunctionCall C->S
+func (T *State) Sync() {
+	T.segments.PushBack(T.open)
+	T.open = segment{}
+}
+
 // Query clobbers the unnamed portal and unnamed prepared statement. Execute on Query C->S
 func (T *State) Query() {
 	delete(T.portals, "")
@@ -177,6 +234,12 @@ func (T *State) CommandComplete(packet fed.Packet) (fed.Packet, error) {
 		T.pendingPreparedStatements.Clear()
 		T.pendingPortals.Clear()
 		T.pendingCloses.Clear()
+		n := T.segments.Length()
+		T.segments.Clear()
+		for i := 0; i < n; i++ {
+			T.segments.PushBack(segment{})
+		}
+		T.open = segment{}
 	}
 
 	return &p, nil
@@ -197,14 +260,38 @@ func (T *State) ReadyForQuery(packet fed.Packet) (fed.Packet, error) {
 		}
 	}
 
-	// all pending has failed
-	T.pendingPreparedStatements.Clear()
-	T.pendingPortals.Clear()
-	T.pendingCloses.Clear()
+	// all pending of this segment has failed
+	s, ok := T.segments.PopFront()
+	if !ok {
+		s = T.open
+		T.open = segment{}
+	}
+	for i := 0; i < s.preparedStatements; i++ {
+		T.pendingPreparedStatements.PopFront()
+	}
+	for i := 0; i < s.portals; i++ {
+		T.pendingPortals.PopFront()
+	}
+	for i := 0; i < s.closes; i++ {
+		T.pendingCloses.PopFront()
+	}
 
 	return &p, nil
 }
 
+// PreparedStatement returns the prepared statement with name, including ones which haven't been completed yet
+func (T *State) PreparedStatement(name string) (*packets.Parse, bool) {
+	for i := T.pendingPreparedStatements.Length() - 1; i >= 0; i-- {
+		preparedStatement := T.pendingPreparedStatements.Get(i)
+		if preparedStatement.Destination == name {
+			return preparedStatement, true
+		}
+	}
+
+	preparedStatement, ok := T.preparedStatements[name]
+	return preparedStatement, ok
+}
+
 func (T *State) Set(other *State) {
 	maps.Clear(T.preparedStatements)
 	maps.Clear(T.portals)
@@ -235,4 +322,10 @@ func (T *State) Set(other *State) {
 	for i := 0; i < other.pendingCloses.Length(); i++ {
 		T.pendingCloses.PushBack(other.pendingCloses.Get(i))
 	}
+
+	T.segments.Clear()
+	for i := 0; i < other.segments.Length(); i++ {
+		T.segments.PushBack(other.segments.Get(i))
+	}
+	T.open = other.open
 }
@@ -0,0 +1,52 @@
+package eqp
+
+import (
+	"testing"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+	"gfx.cafe/gfx/pggat/lib/perror"
+)
+
+func TestStatePipeline(t *testing.T) {
+	var s State
+
+	readyForQuery := packets.ReadyForQuery('I')
+
+	// two Syncs are in flight when the first fails
+	for _, packet := range []fed.Packet{
+		&packets.Parse{Destination: "a", Query: "this is a bad query"},
+		&packets.Parse{Destination: "b", Query: "select 1"},
+		&packets.Sync{},
+		&packets.Parse{Destination: "c", Query: "select 2"},
+		&packets.Sync{},
+	} {
+		if _, err := s.C2S(packet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, packet := range []fed.Packet{
+		perror.ToPacket(perror.New(perror.ERROR, perror.SyntaxError, "syntax error")),
+		&readyForQuery,
+		&packets.ParseComplete{},
+		&readyForQuery,
+	} {
+		if _, err := s.S2C(packet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for name, expected := range map[string]bool{
+		"a": false,
+		"b": false,
+		"c": true,
+	} {
+		if _, ok := s.PreparedStatement(name); ok != expected {
+			t.Errorf("expected prepared statement %q to exist: %v", name, expected)
+		}
+	}
+	if s.pendingPreparedStatements.Length() != 0 {
+		t.Errorf("expected nothing to be pending")
+	}
+}
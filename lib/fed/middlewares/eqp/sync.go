@@ -26,6 +26,12 @@ func SyncMiddleware(ctx context.Context, c *Client, server *fed.Conn) error {
 		panic("middleware not found")
 	}
 
+	s.client = c
+
+	if s.cache != nil {
+		return syncCached(ctx, c, s, server)
+	}
+
 	var needsBackendSync bool
 
 	// close all portals on server
@@ -100,6 +106,111 @@ func SyncMiddleware(ctx context.Context, c *Client, server *fed.Conn) error {
 	return nil
 }
 
+// syncCached syncs a client to a server which shares named prepared statements. Shared statements are left on the
+// server for the next client, unless there are too many.
+func syncCached(ctx context.Context, c *Client, s *Server, server *fed.Conn) error {
+	var needsBackendSync bool
+
+	// close all portals on server
+	for name := range s.state.portals {
+		p := packets.Close{
+			Which: 'P',
+			Name:  name,
+		}
+		if err := server.WritePacket(ctx, &p); err != nil {
+			return err
+		}
+
+		needsBackendSync = true
+	}
+
+	// sync the unnamed prepared statement
+	unnamed, ok := c.state.preparedStatements[""]
+	serverUnnamed, serverOk := s.state.preparedStatements[""]
+	if ok {
+		if !serverOk || !preparedStatementsEqual(unnamed, serverUnnamed) {
+			if err := server.WritePacket(ctx, unnamed); err != nil {
+				return err
+			}
+
+			needsBackendSync = true
+		}
+	} else if serverOk {
+		p := packets.Close{
+			Which: 'S',
+			Name:  "",
+		}
+		if err := server.WritePacket(ctx, &p); err != nil {
+			return err
+		}
+
+		needsBackendSync = true
+	}
+
+	// find shared prepared statements that aren't on server
+	keep := make(map[string]struct{}, len(c.state.preparedStatements))
+	var missing []*packets.Parse
+	for name, preparedStatement := range c.state.preparedStatements {
+		if name == "" {
+			continue
+		}
+
+		cached := cachedName(preparedStatement)
+		if _, ok := keep[cached]; ok {
+			// another name for the same statement
+			continue
+		}
+		keep[cached] = struct{}{}
+
+		if s.hasPreparedStatement(cached) {
+			s.cache.touch(cached)
+			s.cache.hit()
+			continue
+		}
+
+		missing = append(missing, preparedStatement)
+	}
+
+	// make room for missing prepared statements
+	for _, name := range s.cache.evict(&s.state, s.closing, keep, len(missing)) {
+		p := packets.Close{
+			Which: 'S',
+			Name:  name,
+		}
+		if err := server.WritePacket(ctx, &p); err != nil {
+			return err
+		}
+
+		needsBackendSync = true
+	}
+
+	// parse missing prepared statements, these will be renamed by the server middleware
+	for _, preparedStatement := range missing {
+		if err := server.WritePacket(ctx, preparedStatement); err != nil {
+			return err
+		}
+
+		needsBackendSync = true
+	}
+
+	// bind all portals
+	for _, portal := range c.state.portals {
+		if err := server.WritePacket(ctx, portal); err != nil {
+			return err
+		}
+
+		needsBackendSync = true
+	}
+
+	if needsBackendSync {
+		var err error
+		err, _ = backends.Sync(ctx, server, nil)
+		return err
+	}
+
+	return nil
+}
+
 func Sync(ctx context.Context, client, server *fed.Conn) error {
 	c, ok := fed.LookupMiddleware[*Client](client)
 	if !ok {
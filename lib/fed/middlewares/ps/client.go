@@ -11,6 +11,7 @@ import (
 type Client struct {
 	synced     bool
 	parameters map[strutil.CIString]string
+	settings   settings
 }
 
 func NewClient(parameters map[strutil.CIString]string) *Client {
@@ -24,7 +25,7 @@ func (T *Client) PreRead(ctx context.Context, _ bool) (fed.Packet, error) {
 }
 
 func (T *Client) ReadPacket(ctx context.Context, packet fed.Packet) (fed.Packet, error) {
-	return packet, nil
+	return T.settings.C2S(packet)
 }
 
 func (T *Client) WritePacket(ctx context.Context, packet fed.Packet) (fed.Packet, error) {
@@ -46,7 +47,7 @@ func (T *Client) WritePacket(ctx context.Context, packet fed.Packet) (fed.Packet
 		T.parameters[ikey] = p.Value
 		return &p, nil
 	default:
-		return packet, nil
+		return T.settings.S2C(packet)
 	}
 }
 
@@ -64,6 +65,8 @@ func (T *Client) Set(ctx context.Context, other *Client) {
 	for k, v := range other.parameters {
 		T.parameters[k] = v
 	}
+
+	T.settings.Set(&other.settings)
 }
 
 var _ fed.Middleware = (*Client)(nil)
@@ -9,6 +9,7 @@ import (
 
 type Server struct {
 	parameters map[strutil.CIString]string
+	settings   settings
 }
 
 func NewServer(parameters map[strutil.CIString]string) *Server {
@@ -36,12 +37,12 @@ func (T *Server) ReadPacket(ctx context.Context, packet fed.Packet) (fed.Packet,
 		T.parameters[ikey] = p.Value
 		return &p, nil
 	default:
-		return packet, nil
+		return T.settings.S2C(packet)
 	}
 }
 
 func (T *Server) WritePacket(ctx context.Context, packet fed.Packet) (fed.Packet, error) {
-	return packet, nil
+	return T.settings.C2S(packet)
 }
 
 func (T *Server) PostWrite(ctx context.Context) (fed.Packet, error) {
@@ -0,0 +1,596 @@
+package ps
+
+import (
+	"strings"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+	"gfx.cafe/gfx/pggat/lib/util/maps"
+	"gfx.cafe/gfx/pggat/lib/util/sqlscan"
+	"gfx.cafe/gfx/pggat/lib/util/strutil"
+)
+
+// listQuoteParameters are the parameters whose list elements are quoted like identifiers
+var listQuoteParameters = map[strutil.CIString]struct{}{
+	strutil.MakeCIString("search_path"):               {},
+	strutil.MakeCIString("temp_tablespaces"):          {},
+	strutil.MakeCIString("local_preload_libraries"):   {},
+	strutil.MakeCIString("session_preload_libraries"): {},
+}
+
+type change struct {
+	// all resets every parameter
+	all   bool
+	reset bool
+	name  strutil.CIString
+	value string
+}
+
+type savepointOp int
+
+const (
+	savepointNone savepointOp = iota
+	savepointCreate
+	savepointRelease
+	savepointRollback
+)
+
+// statement is what a statement does to the settings once it completes
+type statement struct {
+	changes   []change
+	op        savepointOp
+	savepoint string
+}
+
+type savepoint struct {
+	name string
+	// pending is the number of pending changes when the savepoint was created
+	pending int
+}
+
+// inflight is a Query or Execute that the server hasn't completed yet
+type inflight struct {
+	// sync is the number of Sync and Query messages sent before this one
+	sync int
+	// query is whether this is a simple query, which completes each of its statements in turn
+	query      bool
+	statements []statement
+}
+
+// settings tracks the session-level parameters changed with SET, RESET and set_config. Changes are recorded as the
+// statements making them complete, are pending until their transaction commits, and are dropped if it or the
+// savepoint they were made in rolls back.
+type settings struct {
+	values     map[strutil.CIString]string
+	pending    []change
+	savepoints []savepoint
+	failed     bool
+
+	// prepared statements and portals which change settings when executed
+	statements map[string]statement
+	portals    map[string]statement
+
+	inflight []inflight
+	sent     int
+	received int
+}
+
+func copyStatements(dst *map[string]statement, src map[string]statement) {
+	maps.Clear(*dst)
+	for name, stmt := range src {
+		if *dst == nil {
+			*dst = make(map[string]statement)
+		}
+		(*dst)[name] = stmt
+	}
+}
+
+func (T *settings) Set(other *settings) {
+	maps.Clear(T.values)
+	for name, value := range other.values {
+		if T.values == nil {
+			T.values = make(map[strutil.CIString]string)
+		}
+		T.values[name] = value
+	}
+	T.pending = append(T.pending[:0], other.pending...)
+	T.savepoints = append(T.savepoints[:0], other.savepoints...)
+	T.failed = other.failed
+
+	copyStatements(&T.statements, other.statements)
+	copyStatements(&T.portals, other.portals)
+
+	T.inflight = T.inflight[:0]
+	for _, f := range other.inflight {
+		f.statements = append([]statement(nil), f.statements...)
+		T.inflight = append(T.inflight, f)
+	}
+	T.sent = other.sent
+	T.received = other.received
+}
+
+// C2S handles packets sent to the server
+func (T *settings) C2S(packet fed.Packet) (fed.Packet, error) {
+	switch packet.Type() {
+	case packets.TypeQuery:
+		var p packets.Query
+		if err := fed.ToConcrete(&p, packet); err != nil {
+			return nil, err
+		}
+		T.inflight = append(T.inflight, inflight{
+			sync:       T.sent,
+			query:      true,
+			statements: parseQuery(string(p)),
+		})
+		T.sent++
+		return &p, nil
+	case packets.TypeParse:
+		var p packets.Parse
+		if err := fed.ToConcrete(&p, packet); err != nil {
+			return nil, err
+		}
+		delete(T.statements, p.Destination)
+		if statements := parseQuery(p.Query); len(statements) > 0 {
+			if T.statements == nil {
+				T.statements = make(map[string]statement)
+			}
+			T.statements[p.Destination] = statements[0]
+		}
+		return &p, nil
+	case packets.TypeBind:
+		var p packets.Bind
+		if err := fed.ToConcrete(&p, packet); err != nil {
+			return nil, err
+		}
+		delete(T.portals, p.Destination)
+		if stmt, ok := T.statements[p.Source]; ok {
+			if T.portals == nil {
+				T.portals = make(map[string]statement)
+			}
+			T.portals[p.Destination] = stmt
+		}
+		return &p, nil
+	case packets.TypeExecute:
+		var p packets.Execute
+		if err := fed.ToConcrete(&p, packet); err != nil {
+			return nil, err
+		}
+		T.inflight = append(T.inflight, inflight{
+			sync:       T.sent,
+			statements: []statement{T.portals[p.Target]},
+		})
+		return &p, nil
+	case packets.TypeClose:
+		var p packets.Close
+		if err := fed.ToConcrete(&p, packet); err != nil {
+			return nil, err
+		}
+		switch p.Which {
+		case 'S':
+			delete(T.statements, p.Name)
+		case 'P':
+			delete(T.portals, p.Name)
+		}
+		return &p, nil
+	case packets.TypeSync:
+		T.sent++
+		return packet, nil
+	default:
+		return packet, nil
+	}
+}
+
+// S2C handles packets sent by the server
+func (T *settings) S2C(packet fed.Packet) (fed.Packet, error) {
+	switch packet.Type() {
+	case packets.TypeCommandComplete:
+		var p packets.CommandComplete
+		if err := fed.ToConcrete(&p, packet); err != nil {
+			return nil, err
+		}
+		T.complete(string(p))
+		return &p, nil
+	case packets.TypeEmptyQueryResponse, packets.TypePortalSuspended:
+		if len(T.inflight) > 0 && !T.inflight[0].query {
+			T.inflight = T.inflight[1:]
+		}
+		return packet, nil
+	case packets.TypeMarkiplierResponse:
+		// an error aborts the transaction, whether it is explicit or not, until it or a savepoint is rolled back
+		T.failed = true
+		return packet, nil
+	case packets.TypeReadyForQuery:
+		var p packets.ReadyForQuery
+		if err := fed.ToConcrete(&p, packet); err != nil {
+			return nil, err
+		}
+		T.ready()
+		if p == 'I' {
+			T.commit()
+		}
+		return &p, nil
+	default:
+		return packet, nil
+	}
+}
+
+// ready drops everything sent before the Sync or Query the server just finished. Anything left was skipped because
+// of an error.
+func (T *settings) ready() {
+	if T.received >= T.sent {
+		return
+	}
+	for len(T.inflight) > 0 && T.inflight[0].sync <= T.received {
+		T.inflight = T.inflight[1:]
+	}
+	T.received++
+}
+
+// complete handles the CommandComplete of the next inflight statement
+func (T *settings) complete(tag string) {
+	var stmt statement
+	if len(T.inflight) > 0 {
+		f := &T.inflight[0]
+		if len(f.statements) > 0 {
+			stmt = f.statements[0]
+			f.statements = f.statements[1:]
+		}
+		if !f.query {
+			T.inflight = T.inflight[1:]
+		}
+	}
+
+	switch tag {
+	case "COMMIT":
+		T.commit()
+	case "ROLLBACK":
+		// ROLLBACK TO SAVEPOINT has the same tag as ROLLBACK, and so does a COMMIT of a failed transaction
+		if stmt.op == savepointRollback {
+			T.rollbackTo(stmt.savepoint)
+		} else {
+			T.rollback()
+		}
+	case "SAVEPOINT":
+		if stmt.op == savepointCreate {
+			T.savepoints = append(T.savepoints, savepoint{
+				name:    stmt.savepoint,
+				pending: len(T.pending),
+			})
+		}
+	case "RELEASE":
+		if stmt.op == savepointRelease {
+			if i := T.findSavepoint(stmt.savepoint); i != -1 {
+				T.savepoints = T.savepoints[:i]
+			}
+		}
+	default:
+		T.pending = append(T.pending, stmt.changes...)
+	}
+}
+
+func (T *settings) findSavepoint(name string) int {
+	for i := len(T.savepoints) - 1; i >= 0; i-- {
+		if T.savepoints[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+// rollbackTo drops the changes made since the savepoint, which is kept
+func (T *settings) rollbackTo(name string) {
+	if i := T.findSavepoint(name); i != -1 {
+		T.pending = T.pending[:T.savepoints[i].pending]
+		T.savepoints = T.savepoints[:i+1]
+	} else {
+		T.pending = T.pending[:0]
+	}
+	T.failed = false
+}
+
+func (T *settings) rollback() {
+	T.pending = T.pending[:0]
+	T.savepoints = T.savepoints[:0]
+	T.failed = false
+}
+
+func (T *settings) commit() {
+	if !T.failed {
+		for _, c := range T.pending {
+			switch {
+			case c.all:
+				maps.Clear(T.values)
+			case c.reset:
+				delete(T.values, c.name)
+			default:
+				if T.values == nil {
+					T.values = make(map[strutil.CIString]string)
+				}
+				T.values[c.name] = c.value
+			}
+		}
+	}
+
+	T.rollback()
+}
+
+// parseQuery returns what each statement of the query does to the settings, or nil if none of them do anything
+func parseQuery(query string) []statement {
+	// fast path, every statement we care about contains one of these
+	lower := strings.ToLower(query)
+	if !strings.Contains(lower, "set") && !strings.Contains(lower, "discard") &&
+		!strings.Contains(lower, "savepoint") && !strings.Contains(lower, "release") &&
+		!strings.Contains(lower, "rollback") {
+		return nil
+	}
+
+	split := sqlscan.Split(query)
+	statements := make([]statement, 0, len(split))
+	var found bool
+	for _, tokens := range split {
+		stmt := parseStatement(tokens)
+		if len(stmt.changes) > 0 || stmt.op != savepointNone {
+			found = true
+		}
+		statements = append(statements, stmt)
+	}
+	if !found {
+		return nil
+	}
+	return statements
+}
+
+// parseStatement parses the changes and savepoint operation of a single statement
+func parseStatement(tokens sqlscan.Statement) statement {
+	if c, ok := parseChange(tokens); ok {
+		return statement{
+			changes: []change{c},
+		}
+	}
+
+	if op, name, ok := parseSavepoint(tokens); ok {
+		return statement{
+			op:        op,
+			savepoint: name,
+		}
+	}
+
+	// set_config can be anywhere in a statement
+	var stmt statement
+	for i := range tokens {
+		if c, ok := parseSetConfig(tokens[i:]); ok {
+			stmt.changes = append(stmt.changes, c)
+		}
+	}
+	return stmt
+}
+
+func foldWord(token sqlscan.Token) string {
+	if token.Kind != sqlscan.Word {
+		return token.Value
+	}
+	return strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}, token.Value)
+}
+
+func isSymbol(token sqlscan.Token, symbol string) bool {
+	return token.Kind == sqlscan.Symbol && token.Value == symbol
+}
+
+// parseName parses a possibly qualified parameter name
+func parseName(tokens sqlscan.Statement) (strutil.CIString, sqlscan.Statement, bool) {
+	var name strings.Builder
+	for {
+		if len(tokens) == 0 || (tokens[0].Kind != sqlscan.Word && tokens[0].Kind != sqlscan.QuotedIdentifier) {
+			return strutil.CIString{}, nil, false
+		}
+		name.WriteString(foldWord(tokens[0]))
+		tokens = tokens[1:]
+
+		if len(tokens) == 0 || !isSymbol(tokens[0], ".") {
+			return strutil.MakeCIString(name.String()), tokens, true
+		}
+		name.WriteByte('.')
+		tokens = tokens[1:]
+	}
+}
+
+func quoteIdentifier(s string) string {
+	simple := s != ""
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if !(c == '_' || (c >= 'a' && c <= 'z') || (i > 0 && (c >= '0' && c <= '9' || c == '$'))) {
+			simple = false
+			break
+		}
+	}
+	if simple {
+		return s
+	}
+	return `"` + strutil.Escape(s, '"') + `"`
+}
+
+// formatValue turns the value of a SET statement into the form used by set_config and SHOW
+func formatValue(name strutil.CIString, tokens sqlscan.Statement) (string, bool) {
+	_, quote := listQuoteParameters[name]
+
+	var items []string
+	for len(tokens) > 0 {
+		var item string
+		switch {
+		case tokens[0].Kind == sqlscan.String || tokens[0].Kind == sqlscan.Word || tokens[0].Kind == sqlscan.QuotedIdentifier:
+			item = foldWord(tokens[0])
+			if quote {
+				item = quoteIdentifier(item)
+			}
+			tokens = tokens[1:]
+		case tokens[0].Kind == sqlscan.Number:
+			item = tokens[0].Value
+			tokens = tokens[1:]
+		case len(tokens) > 1 && (isSymbol(tokens[0], "-") || isSymbol(tokens[0], "+")) && tokens[1].Kind == sqlscan.Number:
+			item = tokens[0].Value + tokens[1].Value
+			tokens = tokens[2:]
+		default:
+			return "", false
+		}
+		items = append(items, item)
+
+		if len(tokens) == 0 {
+			break
+		}
+		if !isSymbol(tokens[0], ",") {
+			return "", false
+		}
+		tokens = tokens[1:]
+	}
+	if len(items) == 0 {
+		return "", false
+	}
+
+	return strings.Join(items, ", "), true
+}
+
+// parseSavepoint parses SAVEPOINT, RELEASE SAVEPOINT, and ROLLBACK TO SAVEPOINT statements
+func parseSavepoint(tokens sqlscan.Statement) (savepointOp, string, bool) {
+	var op savepointOp
+	switch {
+	case tokens.Is("SAVEPOINT"):
+		op = savepointCreate
+		tokens = tokens[1:]
+	case tokens.Is("RELEASE"):
+		op = savepointRelease
+		tokens = tokens[1:]
+		if tokens.Is("SAVEPOINT") {
+			tokens = tokens[1:]
+		}
+	case tokens.Is("ROLLBACK"):
+		op = savepointRollback
+		tokens = tokens[1:]
+		if tokens.Is("WORK") || tokens.Is("TRANSACTION") {
+			tokens = tokens[1:]
+		}
+		if !tokens.Is("TO") {
+			return savepointNone, "", false
+		}
+		tokens = tokens[1:]
+		if tokens.Is("SAVEPOINT") {
+			tokens = tokens[1:]
+		}
+	default:
+		return savepointNone, "", false
+	}
+
+	if len(tokens) != 1 || (tokens[0].Kind != sqlscan.Word && tokens[0].Kind != sqlscan.QuotedIdentifier) {
+		return savepointNone, "", false
+	}
+	return op, foldWord(tokens[0]), true
+}
+
+// parseChange parses SET, RESET, and DISCARD ALL statements
+func parseChange(statement sqlscan.Statement) (change, bool) {
+	switch {
+	case statement.Is("DISCARD", "ALL"), statement.Is("RESET", "ALL"):
+		return change{all: true}, len(statement) == 2
+	case statement.Is("RESET", "TIME", "ZONE"):
+		return change{reset: true, name: strutil.MakeCIString("timezone")}, len(statement) == 3
+	case statement.Is("RESET", "ROLE"):
+		return change{reset: true, name: strutil.MakeCIString("role")}, len(statement) == 2
+	case statement.Is("RESET", "SESSION"):
+		return change{}, false
+	case statement.Is("RESET"):
+		name, rest, ok := parseName(statement[1:])
+		if !ok || len(rest) != 0 {
+			return change{}, false
+		}
+		return change{reset: true, name: name}, true
+	case statement.Is("SET"):
+		return parseSet(statement[1:])
+	default:
+		return change{}, false
+	}
+}
+
+func parseSet(tokens sqlscan.Statement) (change, bool) {
+	if len(tokens) == 0 || tokens[0].Is("LOCAL") {
+		return change{}, false
+	}
+	if tokens[0].Is("SESSION") {
+		tokens = tokens[1:]
+	}
+	if len(tokens) == 0 {
+		return change{}, false
+	}
+
+	var name strutil.CIString
+	switch {
+	case tokens.Is("TRANSACTION"), tokens.Is("CONSTRAINTS"), tokens.Is("AUTHORIZATION"), tokens.Is("CHARACTERISTICS"):
+		return change{}, false
+	case tokens.Is("TIME", "ZONE"):
+		name = strutil.MakeCIString("timezone")
+		tokens = tokens[2:]
+		if tokens.Is("LOCAL") && len(tokens) == 1 {
+			return change{reset: true, name: name}, true
+		}
+	case tokens.Is("SCHEMA"):
+		name = strutil.MakeCIString("search_path")
+		tokens = tokens[1:]
+	case tokens.Is("NAMES"):
+		name = strutil.MakeCIString("client_encoding")
+		tokens = tokens[1:]
+	case tokens.Is("ROLE"):
+		name = strutil.MakeCIString("role")
+		tokens = tokens[1:]
+		if tokens.Is("NONE") && len(tokens) == 1 {
+			return change{reset: true, name: name}, true
+		}
+	default:
+		var ok bool
+		name, tokens, ok = parseName(tokens)
+		if !ok || len(tokens) == 0 || !(tokens[0].Is("TO") || isSymbol(tokens[0], "=")) {
+			return change{}, false
+		}
+		tokens = tokens[1:]
+	}
+
+	if tokens.Is("DEFAULT") && len(tokens) == 1 {
+		return change{reset: true, name: name}, true
+	}
+
+	value, ok := formatValue(name, tokens)
+	if !ok {
+		return change{}, false
+	}
+	return change{name: name, value: value}, true
+}
+
+// parseSetConfig parses a set_config('name', 'value', false) call at the start of tokens
+func parseSetConfig(tokens sqlscan.Statement) (change, bool) {
+	if len(tokens) < 8 || !tokens[0].Is("set_config") || !isSymbol(tokens[1], "(") {
+		return change{}, false
+	}
+	if tokens[2].Kind != sqlscan.String || !isSymbol(tokens[3], ",") ||
+		tokens[4].Kind != sqlscan.String || !isSymbol(tokens[5], ",") ||
+		!isSymbol(tokens[7], ")") {
+		return change{}, false
+	}
+
+	// only session-level changes are tracked
+	local := tokens[6]
+	switch {
+	case local.Is("false"):
+	case local.Kind == sqlscan.String:
+		switch strings.ToLower(local.Value) {
+		case "f", "false", "n", "no", "off", "0":
+		default:
+			return change{}, false
+		}
+	default:
+		return change{}, false
+	}
+
+	return change{name: strutil.MakeCIString(tokens[2].Value), value: tokens[4].Value}, true
+}
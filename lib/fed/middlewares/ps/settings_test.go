@@ -0,0 +1,152 @@
+package ps
+
+import (
+	"testing"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+	"gfx.cafe/gfx/pggat/lib/util/strutil"
+)
+
+func run(t *testing.T, s *settings, query string, complete ...string) {
+	q := packets.Query(query)
+	if _, err := s.C2S(&q); err != nil {
+		t.Fatal(err)
+	}
+	for _, tag := range complete {
+		if tag == "" {
+			var p packets.MarkiplierResponse
+			if _, err := s.S2C(&p); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		p := packets.CommandComplete(tag)
+		if _, err := s.S2C(&p); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func ready(t *testing.T, s *settings, state byte) {
+	p := packets.ReadyForQuery(state)
+	if _, err := s.S2C(&p); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func expect(t *testing.T, s *settings, name, value string) {
+	t.Helper()
+	actual, ok := s.values[strutil.MakeCIString(name)]
+	if value == "" {
+		if ok {
+			t.Errorf("expected %s to be unset but got %q", name, actual)
+		}
+		return
+	}
+	if actual != value {
+		t.Errorf("expected %s to be %q but got %q", name, value, actual)
+	}
+}
+
+func TestSettings(t *testing.T) {
+	var s settings
+
+	run(t, &s, "SET statement_timeout = 5000; SET search_path TO \"My Schema\", Public", "SET", "SET")
+	ready(t, &s, 'I')
+	expect(t, &s, "statement_timeout", "5000")
+	expect(t, &s, "search_path", `"My Schema", public`)
+
+	run(t, &s, "SELECT set_config('myapp.user', 'alice', false), set_config('myapp.local', 'x', true)", "SELECT 1")
+	ready(t, &s, 'I')
+	expect(t, &s, "myapp.user", "alice")
+	expect(t, &s, "myapp.local", "")
+
+	// SET LOCAL is only for the transaction
+	run(t, &s, "BEGIN; SET LOCAL work_mem = '64MB'", "BEGIN", "SET")
+	ready(t, &s, 'T')
+	run(t, &s, "COMMIT", "COMMIT")
+	ready(t, &s, 'I')
+	expect(t, &s, "work_mem", "")
+
+	// changes are dropped when the transaction rolls back
+	run(t, &s, "BEGIN; SET TIME ZONE 'UTC'", "BEGIN", "SET")
+	ready(t, &s, 'T')
+	expect(t, &s, "timezone", "")
+	run(t, &s, "ROLLBACK", "ROLLBACK")
+	ready(t, &s, 'I')
+	expect(t, &s, "timezone", "")
+
+	// or fail
+	run(t, &s, "SET lock_timeout = '1s'; SELECT 1/0", "SET", "")
+	ready(t, &s, 'I')
+	expect(t, &s, "lock_timeout", "")
+
+	run(t, &s, "RESET statement_timeout", "RESET")
+	ready(t, &s, 'I')
+	expect(t, &s, "statement_timeout", "")
+	expect(t, &s, "search_path", `"My Schema", public`)
+
+	run(t, &s, "SET search_path TO DEFAULT", "SET")
+	ready(t, &s, 'I')
+	expect(t, &s, "search_path", "")
+
+	run(t, &s, "DISCARD ALL", "DISCARD ALL")
+	ready(t, &s, 'I')
+	if len(s.values) != 0 {
+		t.Errorf("expected DISCARD ALL to reset everything but got %v", s.values)
+	}
+}
+
+func TestSettingsSavepoint(t *testing.T) {
+	var s settings
+
+	run(t, &s, "BEGIN; SET statement_timeout = 5000; SAVEPOINT a", "BEGIN", "SET", "SAVEPOINT")
+	ready(t, &s, 'T')
+	run(t, &s, "SET lock_timeout = '1s'; SELECT 1/0", "SET", "")
+	ready(t, &s, 'E')
+	run(t, &s, "ROLLBACK TO SAVEPOINT a", "ROLLBACK")
+	ready(t, &s, 'T')
+	run(t, &s, "SAVEPOINT b; SET work_mem = '64MB'; RELEASE b", "SAVEPOINT", "SET", "RELEASE")
+	ready(t, &s, 'T')
+	run(t, &s, "COMMIT", "COMMIT")
+	ready(t, &s, 'I')
+	expect(t, &s, "statement_timeout", "5000")
+	expect(t, &s, "lock_timeout", "")
+	expect(t, &s, "work_mem", "64MB")
+
+	// COMMIT of a failed transaction rolls it back
+	run(t, &s, "BEGIN; RESET statement_timeout", "BEGIN", "RESET")
+	ready(t, &s, 'T')
+	run(t, &s, "SELECT 1/0", "")
+	ready(t, &s, 'E')
+	run(t, &s, "COMMIT", "ROLLBACK")
+	ready(t, &s, 'I')
+	expect(t, &s, "statement_timeout", "5000")
+}
+
+func TestSettingsExtended(t *testing.T) {
+	var s settings
+
+	write := func(packet fed.Packet) {
+		if _, err := s.C2S(packet); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(&packets.Parse{Destination: "a", Query: "SET statement_timeout = 5000"})
+	write(&packets.Sync{})
+	ready(t, &s, 'I')
+	expect(t, &s, "statement_timeout", "")
+
+	write(&packets.Bind{Source: "a"})
+	write(&packets.Execute{})
+	write(&packets.Sync{})
+	complete := packets.CommandComplete("SET")
+	if _, err := s.S2C(&complete); err != nil {
+		t.Fatal(err)
+	}
+	ready(t, &s, 'I')
+	expect(t, &s, "statement_timeout", "5000")
+}
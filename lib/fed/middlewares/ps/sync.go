@@ -56,12 +56,43 @@ func sync(ctx context.Context, tracking []strutil.CIString, client *fed.Conn, c
 	return
 }
 
+// syncSettings replays the session-level parameters the client set on the server and resets the ones left behind by
+// other clients. Reported parameters the client already knows about are left to sync.
+func syncSettings(ctx context.Context, c *Client, server *fed.Conn, s *Server) error {
+	for name, value := range c.settings.values {
+		if current, ok := s.settings.values[name]; ok && current == value {
+			continue
+		}
+		if err, _ := backends.SetParameter(ctx, server, nil, name, value); err != nil {
+			return err
+		}
+	}
+
+	for name := range s.settings.values {
+		if _, ok := c.settings.values[name]; ok {
+			continue
+		}
+		if _, ok := c.parameters[name]; ok {
+			continue
+		}
+		if err, _ := backends.ResetParameter(ctx, server, nil, name); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 func SyncMiddleware(ctx context.Context, tracking []strutil.CIString, c *Client, server *fed.Conn) error {
 	s, ok := fed.LookupMiddleware[*Server](server)
 	if !ok {
 		panic("middleware not found")
 	}
 
+	if err := syncSettings(ctx, c, server, s); err != nil {
+		return err
+	}
+
 	for name := range c.parameters {
 		if _, err := sync(ctx, tracking, nil, c, server, s, name); err != nil {
 			return err
@@ -90,6 +121,10 @@ func Sync(ctx context.Context, tracking []strutil.CIString, client, server *fed.
 		panic("middleware not found")
 	}
 
+	if serverErr = syncSettings(ctx, c, server, s); serverErr != nil {
+		return
+	}
+
 	for name := range c.parameters {
 		if clientErr, serverErr = sync(ctx, tracking, client, c, server, s, name); clientErr != nil || serverErr != nil {
 			return
@@ -0,0 +1,84 @@
+package querytimeout
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+)
+
+// Server calls cancel when the server takes longer than timeout to respond to a query. Each Query, FunctionCall, or
+// Sync written to the server gets its own timeout: the timer starts with the first pending one and restarts with each
+// ReadyForQuery until all of them have been answered.
+type Server struct {
+	timeout time.Duration
+	cancel  func()
+
+	pending int
+	timer   *time.Timer
+	mu      sync.Mutex
+}
+
+func NewServer(timeout time.Duration, cancel func()) *Server {
+	return &Server{
+		timeout: timeout,
+		cancel:  cancel,
+	}
+}
+
+func (T *Server) PreRead(_ context.Context, _ bool) (fed.Packet, error) {
+	return nil, nil
+}
+
+func (T *Server) ReadPacket(_ context.Context, packet fed.Packet) (fed.Packet, error) {
+	if packet.Type() != packets.TypeReadyForQuery {
+		return packet, nil
+	}
+
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	if T.pending > 0 {
+		T.pending--
+	}
+	if T.timer != nil {
+		if T.pending == 0 {
+			T.timer.Stop()
+		} else {
+			// the next query starts now
+			T.timer.Reset(T.timeout)
+		}
+	}
+
+	return packet, nil
+}
+
+func (T *Server) WritePacket(_ context.Context, packet fed.Packet) (fed.Packet, error) {
+	switch packet.Type() {
+	case packets.TypeQuery, packets.TypeFunctionCall, packets.TypeSync:
+	default:
+		return packet, nil
+	}
+
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	T.pending++
+	if T.pending == 1 {
+		if T.timer == nil {
+			T.timer = time.AfterFunc(T.timeout, T.cancel)
+		} else {
+			T.timer.Reset(T.timeout)
+		}
+	}
+
+	return packet, nil
+}
+
+func (T *Server) PostWrite(_ context.Context) (fed.Packet, error) {
+	return nil, nil
+}
+
+var _ fed.Middleware = (*Server)(nil)
@@ -0,0 +1,44 @@
+package querytimeout
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+)
+
+func TestServerPipelined(t *testing.T) {
+	const timeout = 200 * time.Millisecond
+
+	var cancelled atomic.Bool
+	s := NewServer(timeout, func() {
+		cancelled.Store(true)
+	})
+
+	ctx := context.Background()
+	for i := 0; i < 2; i++ {
+		query := packets.Query("select pg_sleep(0.15)")
+		if _, err := s.WritePacket(ctx, &query); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// both queries together take longer than timeout, but each one is in time
+	time.Sleep(timeout * 3 / 4)
+	ready := packets.ReadyForQuery('I')
+	if _, err := s.ReadPacket(ctx, &ready); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(timeout * 3 / 4)
+	if cancelled.Load() {
+		t.Fatal("expected each query to get its own timeout")
+	}
+
+	// the second query is too slow
+	time.Sleep(timeout)
+	if !cancelled.Load() {
+		t.Fatal("expected the second query to time out")
+	}
+}
@@ -11,11 +11,18 @@ type PacketCodec interface {
 	WritePacket(ctx context.Context, packet Packet) error
 	WriteByte(ctx context.Context, b byte) error
 	ReadByte(ctx context.Context) (byte, error)
+	// Buffered returns whether the next packet has already been received, so reading it won't wait on the peer
+	Buffered(typed bool) bool
 
 	LocalAddr() net.Addr
+	RemoteAddr() net.Addr
 	Flush(ctx context.Context) error
 	Close(ctx context.Context) error
 
 	SSL() bool
 	EnableSSL(ctx context.Context, config *tls.Config, isClient bool) error
+
+	GSS() bool
+	// EnableGSS runs handshake on the underlying conn and replaces it with the GSSAPI encrypted conn it returns
+	EnableGSS(ctx context.Context, handshake func(conn net.Conn) (net.Conn, error)) error
 }
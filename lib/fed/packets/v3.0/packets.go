@@ -512,7 +512,7 @@ var _ fed.Packet = (*Authentication)(nil)
 
 type BackendKeyDataPayload struct {
 	ProcessID int32
-	SecretKey int32
+	SecretKey []uint8
 }
 
 type BackendKeyData BackendKeyDataPayload
@@ -524,7 +524,12 @@ func (T *BackendKeyData) Type() fed.Type {
 func (T *BackendKeyData) Length() (length int) {
 	length += 4
 
-	length += 4
+	for _, temp15 := range (*T).SecretKey {
+		_ = temp15
+
+		length += 1
+
+	}
 
 	return
 }
@@ -546,9 +551,20 @@ func (T *BackendKeyData) ReadFrom(decoder *fed.Decoder) (err error) {
 	if err != nil {
 		return
 	}
-	*(*int32)(&((*T).SecretKey)), err = decoder.Int32()
-	if err != nil {
-		return
+	(*T).SecretKey = (*T).SecretKey[:0]
+
+	for {
+		if decoder.Position() >= decoder.Length() {
+			break
+		}
+
+		(*T).SecretKey = slices.Resize((*T).SecretKey, len((*T).SecretKey)+1)
+
+		*(*uint8)(&((*T).SecretKey[len((*T).SecretKey)-1])), err = decoder.Uint8()
+		if err != nil {
+			return
+		}
+
 	}
 
 	return
@@ -560,9 +576,12 @@ func (T *BackendKeyData) WriteTo(encoder *fed.Encoder) (err error) {
 		return
 	}
 
-	err = encoder.Int32(int32((*T).SecretKey))
-	if err != nil {
-		return
+	for _, temp16 := range (*T).SecretKey {
+		err = encoder.Uint8(uint8(temp16))
+		if err != nil {
+			return
+		}
+
 	}
 
 	return
@@ -589,33 +608,33 @@ func (T *Bind) Length() (length int) {
 
 	length += len((*T).Source) + 1
 
-	temp15 := uint16(len((*T).FormatCodes))
-	_ = temp15
+	temp17 := uint16(len((*T).FormatCodes))
+	_ = temp17
 
 	length += 2
 
-	for _, temp16 := range (*T).FormatCodes {
-		_ = temp16
+	for _, temp18 := range (*T).FormatCodes {
+		_ = temp18
 
 		length += 2
 
 	}
 
-	temp17 := uint16(len((*T).Parameters))
-	_ = temp17
+	temp19 := uint16(len((*T).Parameters))
+	_ = temp19
 
 	length += 2
 
-	for _, temp18 := range (*T).Parameters {
-		_ = temp18
+	for _, temp20 := range (*T).Parameters {
+		_ = temp20
 
-		temp19 := int32(len(temp18))
-		_ = temp19
+		temp21 := int32(len(temp20))
+		_ = temp21
 
 		length += 4
 
-		for _, temp20 := range temp18 {
-			_ = temp20
+		for _, temp22 := range temp20 {
+			_ = temp22
 
 			length += 1
 
@@ -623,13 +642,13 @@ func (T *Bind) Length() (length int) {
 
 	}
 
-	temp21 := uint16(len((*T).ResultFormatCodes))
-	_ = temp21
+	temp23 := uint16(len((*T).ResultFormatCodes))
+	_ = temp23
 
 	length += 2
 
-	for _, temp22 := range (*T).ResultFormatCodes {
-		_ = temp22
+	for _, temp24 := range (*T).ResultFormatCodes {
+		_ = temp24
 
 		length += 2
 
@@ -659,48 +678,48 @@ func (T *Bind) ReadFrom(decoder *fed.Decoder) (err error) {
 	if err != nil {
 		return
 	}
-	var temp23 uint16
-	*(*uint16)(&(temp23)), err = decoder.Uint16()
+	var temp25 uint16
+	*(*uint16)(&(temp25)), err = decoder.Uint16()
 	if err != nil {
 		return
 	}
 
-	(*T).FormatCodes = slices.Resize((*T).FormatCodes, int(temp23))
+	(*T).FormatCodes = slices.Resize((*T).FormatCodes, int(temp25))
 
-	for temp24 := 0; temp24 < int(temp23); temp24++ {
-		*(*int16)(&((*T).FormatCodes[temp24])), err = decoder.Int16()
+	for temp26 := 0; temp26 < int(temp25); temp26++ {
+		*(*int16)(&((*T).FormatCodes[temp26])), err = decoder.Int16()
 		if err != nil {
 			return
 		}
 
 	}
 
-	var temp25 uint16
-	*(*uint16)(&(temp25)), err = decoder.Uint16()
+	var temp27 uint16
+	*(*uint16)(&(temp27)), err = decoder.Uint16()
 	if err != nil {
 		return
 	}
 
-	(*T).Parameters = slices.Resize((*T).Parameters, int(temp25))
+	(*T).Parameters = slices.Resize((*T).Parameters, int(temp27))
 
-	for temp26 := 0; temp26 < int(temp25); temp26++ {
-		var temp27 int32
-		*(*int32)(&(temp27)), err = decoder.Int32()
+	for temp28 := 0; temp28 < int(temp27); temp28++ {
+		var temp29 int32
+		*(*int32)(&(temp29)), err = decoder.Int32()
 		if err != nil {
 			return
 		}
 
-		if temp27 == -1 {
-			(*T).Parameters[temp26] = nil
+		if temp29 == -1 {
+			(*T).Parameters[temp28] = nil
 		} else {
-			if (*T).Parameters[temp26] == nil {
-				(*T).Parameters[temp26] = make([]uint8, int(temp27))
+			if (*T).Parameters[temp28] == nil {
+				(*T).Parameters[temp28] = make([]uint8, int(temp29))
 			} else {
-				(*T).Parameters[temp26] = slices.Resize((*T).Parameters[temp26], int(temp27))
+				(*T).Parameters[temp28] = slices.Resize((*T).Parameters[temp28], int(temp29))
 			}
 
-			for temp28 := 0; temp28 < int(temp27); temp28++ {
-				*(*uint8)(&((*T).Parameters[temp26][temp28])), err = decoder.Uint8()
+			for temp30 := 0; temp30 < int(temp29); temp30++ {
+				*(*uint8)(&((*T).Parameters[temp28][temp30])), err = decoder.Uint8()
 				if err != nil {
 					return
 				}
@@ -710,16 +729,16 @@ func (T *Bind) ReadFrom(decoder *fed.Decoder) (err error) {
 
 	}
 
-	var temp29 uint16
-	*(*uint16)(&(temp29)), err = decoder.Uint16()
+	var temp31 uint16
+	*(*uint16)(&(temp31)), err = decoder.Uint16()
 	if err != nil {
 		return
 	}
 
-	(*T).ResultFormatCodes = slices.Resize((*T).ResultFormatCodes, int(temp29))
+	(*T).ResultFormatCodes = slices.Resize((*T).ResultFormatCodes, int(temp31))
 
-	for temp30 := 0; temp30 < int(temp29); temp30++ {
-		*(*int16)(&((*T).ResultFormatCodes[temp30])), err = decoder.Int16()
+	for temp32 := 0; temp32 < int(temp31); temp32++ {
+		*(*int16)(&((*T).ResultFormatCodes[temp32])), err = decoder.Int16()
 		if err != nil {
 			return
 		}
@@ -740,42 +759,42 @@ func (T *Bind) WriteTo(encoder *fed.Encoder) (err error) {
 		return
 	}
 
-	temp31 := uint16(len((*T).FormatCodes))
+	temp33 := uint16(len((*T).FormatCodes))
 
-	err = encoder.Uint16(uint16(temp31))
+	err = encoder.Uint16(uint16(temp33))
 	if err != nil {
 		return
 	}
 
-	for _, temp32 := range (*T).FormatCodes {
-		err = encoder.Int16(int16(temp32))
+	for _, temp34 := range (*T).FormatCodes {
+		err = encoder.Int16(int16(temp34))
 		if err != nil {
 			return
 		}
 
 	}
 
-	temp33 := uint16(len((*T).Parameters))
+	temp35 := uint16(len((*T).Parameters))
 
-	err = encoder.Uint16(uint16(temp33))
+	err = encoder.Uint16(uint16(temp35))
 	if err != nil {
 		return
 	}
 
-	for _, temp34 := range (*T).Parameters {
-		temp35 := int32(len(temp34))
+	for _, temp36 := range (*T).Parameters {
+		temp37 := int32(len(temp36))
 
-		if temp34 == nil {
-			temp35 = -1
+		if temp36 == nil {
+			temp37 = -1
 		}
 
-		err = encoder.Int32(int32(temp35))
+		err = encoder.Int32(int32(temp37))
 		if err != nil {
 			return
 		}
 
-		for _, temp36 := range temp34 {
-			err = encoder.Uint8(uint8(temp36))
+		for _, temp38 := range temp36 {
+			err = encoder.Uint8(uint8(temp38))
 			if err != nil {
 				return
 			}
@@ -784,15 +803,15 @@ func (T *Bind) WriteTo(encoder *fed.Encoder) (err error) {
 
 	}
 
-	temp37 := uint16(len((*T).ResultFormatCodes))
+	temp39 := uint16(len((*T).ResultFormatCodes))
 
-	err = encoder.Uint16(uint16(temp37))
+	err = encoder.Uint16(uint16(temp39))
 	if err != nil {
 		return
 	}
 
-	for _, temp38 := range (*T).ResultFormatCodes {
-		err = encoder.Int16(int16(temp38))
+	for _, temp40 := range (*T).ResultFormatCodes {
+		err = encoder.Int16(int16(temp40))
 		if err != nil {
 			return
 		}
@@ -990,13 +1009,13 @@ func (T *CopyBothResponse) Type() fed.Type {
 func (T *CopyBothResponse) Length() (length int) {
 	length += 1
 
-	temp39 := uint16(len((*T).ColumnFormatCodes))
-	_ = temp39
+	temp41 := uint16(len((*T).ColumnFormatCodes))
+	_ = temp41
 
 	length += 2
 
-	for _, temp40 := range (*T).ColumnFormatCodes {
-		_ = temp40
+	for _, temp42 := range (*T).ColumnFormatCodes {
+		_ = temp42
 
 		length += 2
 
@@ -1022,16 +1041,16 @@ func (T *CopyBothResponse) ReadFrom(decoder *fed.Decoder) (err error) {
 	if err != nil {
 		return
 	}
-	var temp41 uint16
-	*(*uint16)(&(temp41)), err = decoder.Uint16()
+	var temp43 uint16
+	*(*uint16)(&(temp43)), err = decoder.Uint16()
 	if err != nil {
 		return
 	}
 
-	(*T).ColumnFormatCodes = slices.Resize((*T).ColumnFormatCodes, int(temp41))
+	(*T).ColumnFormatCodes = slices.Resize((*T).ColumnFormatCodes, int(temp43))
 
-	for temp42 := 0; temp42 < int(temp41); temp42++ {
-		*(*int16)(&((*T).ColumnFormatCodes[temp42])), err = decoder.Int16()
+	for temp44 := 0; temp44 < int(temp43); temp44++ {
+		*(*int16)(&((*T).ColumnFormatCodes[temp44])), err = decoder.Int16()
 		if err != nil {
 			return
 		}
@@ -1047,15 +1066,15 @@ func (T *CopyBothResponse) WriteTo(encoder *fed.Encoder) (err error) {
 		return
 	}
 
-	temp43 := uint16(len((*T).ColumnFormatCodes))
+	temp45 := uint16(len((*T).ColumnFormatCodes))
 
-	err = encoder.Uint16(uint16(temp43))
+	err = encoder.Uint16(uint16(temp45))
 	if err != nil {
 		return
 	}
 
-	for _, temp44 := range (*T).ColumnFormatCodes {
-		err = encoder.Int16(int16(temp44))
+	for _, temp46 := range (*T).ColumnFormatCodes {
+		err = encoder.Int16(int16(temp46))
 		if err != nil {
 			return
 		}
@@ -1074,8 +1093,8 @@ func (T *CopyData) Type() fed.Type {
 }
 
 func (T *CopyData) Length() (length int) {
-	for _, temp45 := range *T {
-		_ = temp45
+	for _, temp47 := range *T {
+		_ = temp47
 
 		length += 1
 
@@ -1117,8 +1136,8 @@ func (T *CopyData) ReadFrom(decoder *fed.Decoder) (err error) {
 }
 
 func (T *CopyData) WriteTo(encoder *fed.Encoder) (err error) {
-	for _, temp46 := range *T {
-		err = encoder.Uint8(uint8(temp46))
+	for _, temp48 := range *T {
+		err = encoder.Uint8(uint8(temp48))
 		if err != nil {
 			return
 		}
@@ -1222,13 +1241,13 @@ func (T *CopyInResponse) Type() fed.Type {
 func (T *CopyInResponse) Length() (length int) {
 	length += 1
 
-	temp47 := uint16(len((*T).ColumnFormatCodes))
-	_ = temp47
+	temp49 := uint16(len((*T).ColumnFormatCodes))
+	_ = temp49
 
 	length += 2
 
-	for _, temp48 := range (*T).ColumnFormatCodes {
-		_ = temp48
+	for _, temp50 := range (*T).ColumnFormatCodes {
+		_ = temp50
 
 		length += 2
 
@@ -1254,16 +1273,16 @@ func (T *CopyInResponse) ReadFrom(decoder *fed.Decoder) (err error) {
 	if err != nil {
 		return
 	}
-	var temp49 uint16
-	*(*uint16)(&(temp49)), err = decoder.Uint16()
+	var temp51 uint16
+	*(*uint16)(&(temp51)), err = decoder.Uint16()
 	if err != nil {
 		return
 	}
 
-	(*T).ColumnFormatCodes = slices.Resize((*T).ColumnFormatCodes, int(temp49))
+	(*T).ColumnFormatCodes = slices.Resize((*T).ColumnFormatCodes, int(temp51))
 
-	for temp50 := 0; temp50 < int(temp49); temp50++ {
-		*(*int16)(&((*T).ColumnFormatCodes[temp50])), err = decoder.Int16()
+	for temp52 := 0; temp52 < int(temp51); temp52++ {
+		*(*int16)(&((*T).ColumnFormatCodes[temp52])), err = decoder.Int16()
 		if err != nil {
 			return
 		}
@@ -1279,15 +1298,15 @@ func (T *CopyInResponse) WriteTo(encoder *fed.Encoder) (err error) {
 		return
 	}
 
-	temp51 := uint16(len((*T).ColumnFormatCodes))
+	temp53 := uint16(len((*T).ColumnFormatCodes))
 
-	err = encoder.Uint16(uint16(temp51))
+	err = encoder.Uint16(uint16(temp53))
 	if err != nil {
 		return
 	}
 
-	for _, temp52 := range (*T).ColumnFormatCodes {
-		err = encoder.Int16(int16(temp52))
+	for _, temp54 := range (*T).ColumnFormatCodes {
+		err = encoder.Int16(int16(temp54))
 		if err != nil {
 			return
 		}
@@ -1313,13 +1332,13 @@ func (T *CopyOutResponse) Type() fed.Type {
 func (T *CopyOutResponse) Length() (length int) {
 	length += 1
 
-	temp53 := uint16(len((*T).ColumnFormatCodes))
-	_ = temp53
+	temp55 := uint16(len((*T).ColumnFormatCodes))
+	_ = temp55
 
 	length += 2
 
-	for _, temp54 := range (*T).ColumnFormatCodes {
-		_ = temp54
+	for _, temp56 := range (*T).ColumnFormatCodes {
+		_ = temp56
 
 		length += 2
 
@@ -1345,16 +1364,16 @@ func (T *CopyOutResponse) ReadFrom(decoder *fed.Decoder) (err error) {
 	if err != nil {
 		return
 	}
-	var temp55 uint16
-	*(*uint16)(&(temp55)), err = decoder.Uint16()
+	var temp57 uint16
+	*(*uint16)(&(temp57)), err = decoder.Uint16()
 	if err != nil {
 		return
 	}
 
-	(*T).ColumnFormatCodes = slices.Resize((*T).ColumnFormatCodes, int(temp55))
+	(*T).ColumnFormatCodes = slices.Resize((*T).ColumnFormatCodes, int(temp57))
 
-	for temp56 := 0; temp56 < int(temp55); temp56++ {
-		*(*int16)(&((*T).ColumnFormatCodes[temp56])), err = decoder.Int16()
+	for temp58 := 0; temp58 < int(temp57); temp58++ {
+		*(*int16)(&((*T).ColumnFormatCodes[temp58])), err = decoder.Int16()
 		if err != nil {
 			return
 		}
@@ -1370,15 +1389,15 @@ func (T *CopyOutResponse) WriteTo(encoder *fed.Encoder) (err error) {
 		return
 	}
 
-	temp57 := uint16(len((*T).ColumnFormatCodes))
+	temp59 := uint16(len((*T).ColumnFormatCodes))
 
-	err = encoder.Uint16(uint16(temp57))
+	err = encoder.Uint16(uint16(temp59))
 	if err != nil {
 		return
 	}
 
-	for _, temp58 := range (*T).ColumnFormatCodes {
-		err = encoder.Int16(int16(temp58))
+	for _, temp60 := range (*T).ColumnFormatCodes {
+		err = encoder.Int16(int16(temp60))
 		if err != nil {
 			return
 		}
@@ -1397,21 +1416,21 @@ func (T *DataRow) Type() fed.Type {
 }
 
 func (T *DataRow) Length() (length int) {
-	temp59 := uint16(len((*T)))
-	_ = temp59
+	temp61 := uint16(len((*T)))
+	_ = temp61
 
 	length += 2
 
-	for _, temp60 := range *T {
-		_ = temp60
+	for _, temp62 := range *T {
+		_ = temp62
 
-		temp61 := int32(len(temp60))
-		_ = temp61
+		temp63 := int32(len(temp62))
+		_ = temp63
 
 		length += 4
 
-		for _, temp62 := range temp60 {
-			_ = temp62
+		for _, temp64 := range temp62 {
+			_ = temp64
 
 			length += 1
 
@@ -1435,32 +1454,32 @@ func (T *DataRow) ReadFrom(decoder *fed.Decoder) (err error) {
 		return ErrUnexpectedPacket
 	}
 
-	var temp63 uint16
-	*(*uint16)(&(temp63)), err = decoder.Uint16()
+	var temp65 uint16
+	*(*uint16)(&(temp65)), err = decoder.Uint16()
 	if err != nil {
 		return
 	}
 
-	(*T) = slices.Resize((*T), int(temp63))
+	(*T) = slices.Resize((*T), int(temp65))
 
-	for temp64 := 0; temp64 < int(temp63); temp64++ {
-		var temp65 int32
-		*(*int32)(&(temp65)), err = decoder.Int32()
+	for temp66 := 0; temp66 < int(temp65); temp66++ {
+		var temp67 int32
+		*(*int32)(&(temp67)), err = decoder.Int32()
 		if err != nil {
 			return
 		}
 
-		if temp65 == -1 {
-			(*T)[temp64] = nil
+		if temp67 == -1 {
+			(*T)[temp66] = nil
 		} else {
-			if (*T)[temp64] == nil {
-				(*T)[temp64] = make([]uint8, int(temp65))
+			if (*T)[temp66] == nil {
+				(*T)[temp66] = make([]uint8, int(temp67))
 			} else {
-				(*T)[temp64] = slices.Resize((*T)[temp64], int(temp65))
+				(*T)[temp66] = slices.Resize((*T)[temp66], int(temp67))
 			}
 
-			for temp66 := 0; temp66 < int(temp65); temp66++ {
-				*(*uint8)(&((*T)[temp64][temp66])), err = decoder.Uint8()
+			for temp68 := 0; temp68 < int(temp67); temp68++ {
+				*(*uint8)(&((*T)[temp66][temp68])), err = decoder.Uint8()
 				if err != nil {
 					return
 				}
@@ -1474,27 +1493,27 @@ func (T *DataRow) ReadFrom(decoder *fed.Decoder) (err error) {
 }
 
 func (T *DataRow) WriteTo(encoder *fed.Encoder) (err error) {
-	temp67 := uint16(len((*T)))
+	temp69 := uint16(len((*T)))
 
-	err = encoder.Uint16(uint16(temp67))
+	err = encoder.Uint16(uint16(temp69))
 	if err != nil {
 		return
 	}
 
-	for _, temp68 := range *T {
-		temp69 := int32(len(temp68))
+	for _, temp70 := range *T {
+		temp71 := int32(len(temp70))
 
-		if temp68 == nil {
-			temp69 = -1
+		if temp70 == nil {
+			temp71 = -1
 		}
 
-		err = encoder.Int32(int32(temp69))
+		err = encoder.Int32(int32(temp71))
 		if err != nil {
 			return
 		}
 
-		for _, temp70 := range temp68 {
-			err = encoder.Uint8(uint8(temp70))
+		for _, temp72 := range temp70 {
+			err = encoder.Uint8(uint8(temp72))
 			if err != nil {
 				return
 			}
@@ -1712,33 +1731,33 @@ func (T *FunctionCall) Type() fed.Type {
 func (T *FunctionCall) Length() (length int) {
 	length += 4
 
-	temp71 := uint16(len((*T).ArgumentFormatCodes))
-	_ = temp71
+	temp73 := uint16(len((*T).ArgumentFormatCodes))
+	_ = temp73
 
 	length += 2
 
-	for _, temp72 := range (*T).ArgumentFormatCodes {
-		_ = temp72
+	for _, temp74 := range (*T).ArgumentFormatCodes {
+		_ = temp74
 
 		length += 2
 
 	}
 
-	temp73 := uint16(len((*T).Arguments))
-	_ = temp73
+	temp75 := uint16(len((*T).Arguments))
+	_ = temp75
 
 	length += 2
 
-	for _, temp74 := range (*T).Arguments {
-		_ = temp74
+	for _, temp76 := range (*T).Arguments {
+		_ = temp76
 
-		temp75 := int32(len(temp74))
-		_ = temp75
+		temp77 := int32(len(temp76))
+		_ = temp77
 
 		length += 4
 
-		for _, temp76 := range temp74 {
-			_ = temp76
+		for _, temp78 := range temp76 {
+			_ = temp78
 
 			length += 1
 
@@ -1768,48 +1787,48 @@ func (T *FunctionCall) ReadFrom(decoder *fed.Decoder) (err error) {
 	if err != nil {
 		return
 	}
-	var temp77 uint16
-	*(*uint16)(&(temp77)), err = decoder.Uint16()
+	var temp79 uint16
+	*(*uint16)(&(temp79)), err = decoder.Uint16()
 	if err != nil {
 		return
 	}
 
-	(*T).ArgumentFormatCodes = slices.Resize((*T).ArgumentFormatCodes, int(temp77))
+	(*T).ArgumentFormatCodes = slices.Resize((*T).ArgumentFormatCodes, int(temp79))
 
-	for temp78 := 0; temp78 < int(temp77); temp78++ {
-		*(*int16)(&((*T).ArgumentFormatCodes[temp78])), err = decoder.Int16()
+	for temp80 := 0; temp80 < int(temp79); temp80++ {
+		*(*int16)(&((*T).ArgumentFormatCodes[temp80])), err = decoder.Int16()
 		if err != nil {
 			return
 		}
 
 	}
 
-	var temp79 uint16
-	*(*uint16)(&(temp79)), err = decoder.Uint16()
+	var temp81 uint16
+	*(*uint16)(&(temp81)), err = decoder.Uint16()
 	if err != nil {
 		return
 	}
 
-	(*T).Arguments = slices.Resize((*T).Arguments, int(temp79))
+	(*T).Arguments = slices.Resize((*T).Arguments, int(temp81))
 
-	for temp80 := 0; temp80 < int(temp79); temp80++ {
-		var temp81 int32
-		*(*int32)(&(temp81)), err = decoder.Int32()
+	for temp82 := 0; temp82 < int(temp81); temp82++ {
+		var temp83 int32
+		*(*int32)(&(temp83)), err = decoder.Int32()
 		if err != nil {
 			return
 		}
 
-		if temp81 == -1 {
-			(*T).Arguments[temp80] = nil
+		if temp83 == -1 {
+			(*T).Arguments[temp82] = nil
 		} else {
-			if (*T).Arguments[temp80] == nil {
-				(*T).Arguments[temp80] = make([]uint8, int(temp81))
+			if (*T).Arguments[temp82] == nil {
+				(*T).Arguments[temp82] = make([]uint8, int(temp83))
 			} else {
-				(*T).Arguments[temp80] = slices.Resize((*T).Arguments[temp80], int(temp81))
+				(*T).Arguments[temp82] = slices.Resize((*T).Arguments[temp82], int(temp83))
 			}
 
-			for temp82 := 0; temp82 < int(temp81); temp82++ {
-				*(*uint8)(&((*T).Arguments[temp80][temp82])), err = decoder.Uint8()
+			for temp84 := 0; temp84 < int(temp83); temp84++ {
+				*(*uint8)(&((*T).Arguments[temp82][temp84])), err = decoder.Uint8()
 				if err != nil {
 					return
 				}
@@ -1833,42 +1852,42 @@ func (T *FunctionCall) WriteTo(encoder *fed.Encoder) (err error) {
 		return
 	}
 
-	temp83 := uint16(len((*T).ArgumentFormatCodes))
+	temp85 := uint16(len((*T).ArgumentFormatCodes))
 
-	err = encoder.Uint16(uint16(temp83))
+	err = encoder.Uint16(uint16(temp85))
 	if err != nil {
 		return
 	}
 
-	for _, temp84 := range (*T).ArgumentFormatCodes {
-		err = encoder.Int16(int16(temp84))
+	for _, temp86 := range (*T).ArgumentFormatCodes {
+		err = encoder.Int16(int16(temp86))
 		if err != nil {
 			return
 		}
 
 	}
 
-	temp85 := uint16(len((*T).Arguments))
+	temp87 := uint16(len((*T).Arguments))
 
-	err = encoder.Uint16(uint16(temp85))
+	err = encoder.Uint16(uint16(temp87))
 	if err != nil {
 		return
 	}
 
-	for _, temp86 := range (*T).Arguments {
-		temp87 := int32(len(temp86))
+	for _, temp88 := range (*T).Arguments {
+		temp89 := int32(len(temp88))
 
-		if temp86 == nil {
-			temp87 = -1
+		if temp88 == nil {
+			temp89 = -1
 		}
 
-		err = encoder.Int32(int32(temp87))
+		err = encoder.Int32(int32(temp89))
 		if err != nil {
 			return
 		}
 
-		for _, temp88 := range temp86 {
-			err = encoder.Uint8(uint8(temp88))
+		for _, temp90 := range temp88 {
+			err = encoder.Uint8(uint8(temp90))
 			if err != nil {
 				return
 			}
@@ -1894,13 +1913,13 @@ func (T *FunctionCallResponse) Type() fed.Type {
 }
 
 func (T *FunctionCallResponse) Length() (length int) {
-	temp89 := int32(len((*T)))
-	_ = temp89
+	temp91 := int32(len((*T)))
+	_ = temp91
 
 	length += 4
 
-	for _, temp90 := range *T {
-		_ = temp90
+	for _, temp92 := range *T {
+		_ = temp92
 
 		length += 1
 
@@ -1922,23 +1941,23 @@ func (T *FunctionCallResponse) ReadFrom(decoder *fed.Decoder) (err error) {
 		return ErrUnexpectedPacket
 	}
 
-	var temp91 int32
-	*(*int32)(&(temp91)), err = decoder.Int32()
+	var temp93 int32
+	*(*int32)(&(temp93)), err = decoder.Int32()
 	if err != nil {
 		return
 	}
 
-	if temp91 == -1 {
+	if temp93 == -1 {
 		(*T) = nil
 	} else {
 		if (*T) == nil {
-			(*T) = make([]uint8, int(temp91))
+			(*T) = make([]uint8, int(temp93))
 		} else {
-			(*T) = slices.Resize((*T), int(temp91))
+			(*T) = slices.Resize((*T), int(temp93))
 		}
 
-		for temp92 := 0; temp92 < int(temp91); temp92++ {
-			*(*uint8)(&((*T)[temp92])), err = decoder.Uint8()
+		for temp94 := 0; temp94 < int(temp93); temp94++ {
+			*(*uint8)(&((*T)[temp94])), err = decoder.Uint8()
 			if err != nil {
 				return
 			}
@@ -1950,19 +1969,19 @@ func (T *FunctionCallResponse) ReadFrom(decoder *fed.Decoder) (err error) {
 }
 
 func (T *FunctionCallResponse) WriteTo(encoder *fed.Encoder) (err error) {
-	temp93 := int32(len((*T)))
+	temp95 := int32(len((*T)))
 
 	if (*T) == nil {
-		temp93 = -1
+		temp95 = -1
 	}
 
-	err = encoder.Int32(int32(temp93))
+	err = encoder.Int32(int32(temp95))
 	if err != nil {
 		return
 	}
 
-	for _, temp94 := range *T {
-		err = encoder.Uint8(uint8(temp94))
+	for _, temp96 := range *T {
+		err = encoder.Uint8(uint8(temp96))
 		if err != nil {
 			return
 		}
@@ -1981,8 +2000,8 @@ func (T *GSSResponse) Type() fed.Type {
 }
 
 func (T *GSSResponse) Length() (length int) {
-	for _, temp95 := range *T {
-		_ = temp95
+	for _, temp97 := range *T {
+		_ = temp97
 
 		length += 1
 
@@ -2024,8 +2043,8 @@ func (T *GSSResponse) ReadFrom(decoder *fed.Decoder) (err error) {
 }
 
 func (T *GSSResponse) WriteTo(encoder *fed.Encoder) (err error) {
-	for _, temp96 := range *T {
-		err = encoder.Uint8(uint8(temp96))
+	for _, temp98 := range *T {
+		err = encoder.Uint8(uint8(temp98))
 		if err != nil {
 			return
 		}
@@ -2049,17 +2068,17 @@ func (T *MarkiplierResponse) Type() fed.Type {
 }
 
 func (T *MarkiplierResponse) Length() (length int) {
-	for _, temp97 := range *T {
-		_ = temp97
+	for _, temp99 := range *T {
+		_ = temp99
 
 		length += 1
 
-		length += len(temp97.Value) + 1
+		length += len(temp99.Value) + 1
 
 	}
 
-	var temp98 uint8
-	_ = temp98
+	var temp100 uint8
+	_ = temp100
 
 	length += 1
 
@@ -2102,22 +2121,22 @@ func (T *MarkiplierResponse) ReadFrom(decoder *fed.Decoder) (err error) {
 }
 
 func (T *MarkiplierResponse) WriteTo(encoder *fed.Encoder) (err error) {
-	for _, temp99 := range *T {
-		err = encoder.Uint8(uint8(temp99.Code))
+	for _, temp101 := range *T {
+		err = encoder.Uint8(uint8(temp101.Code))
 		if err != nil {
 			return
 		}
 
-		err = encoder.String(string(temp99.Value))
+		err = encoder.String(string(temp101.Value))
 		if err != nil {
 			return
 		}
 
 	}
 
-	var temp100 uint8
+	var temp102 uint8
 
-	err = encoder.Uint8(uint8(temp100))
+	err = encoder.Uint8(uint8(temp102))
 	if err != nil {
 		return
 	}
@@ -2141,15 +2160,15 @@ func (T *NegotiateProtocolVersion) Type() fed.Type {
 func (T *NegotiateProtocolVersion) Length() (length int) {
 	length += 4
 
-	temp101 := uint32(len((*T).UnrecognizedProtocolOptions))
-	_ = temp101
+	temp103 := uint32(len((*T).UnrecognizedProtocolOptions))
+	_ = temp103
 
 	length += 4
 
-	for _, temp102 := range (*T).UnrecognizedProtocolOptions {
-		_ = temp102
+	for _, temp104 := range (*T).UnrecognizedProtocolOptions {
+		_ = temp104
 
-		length += len(temp102) + 1
+		length += len(temp104) + 1
 
 	}
 
@@ -2173,16 +2192,16 @@ func (T *NegotiateProtocolVersion) ReadFrom(decoder *fed.Decoder) (err error) {
 	if err != nil {
 		return
 	}
-	var temp103 uint32
-	*(*uint32)(&(temp103)), err = decoder.Uint32()
+	var temp105 uint32
+	*(*uint32)(&(temp105)), err = decoder.Uint32()
 	if err != nil {
 		return
 	}
 
-	(*T).UnrecognizedProtocolOptions = slices.Resize((*T).UnrecognizedProtocolOptions, int(temp103))
+	(*T).UnrecognizedProtocolOptions = slices.Resize((*T).UnrecognizedProtocolOptions, int(temp105))
 
-	for temp104 := 0; temp104 < int(temp103); temp104++ {
-		*(*string)(&((*T).UnrecognizedProtocolOptions[temp104])), err = decoder.String()
+	for temp106 := 0; temp106 < int(temp105); temp106++ {
+		*(*string)(&((*T).UnrecognizedProtocolOptions[temp106])), err = decoder.String()
 		if err != nil {
 			return
 		}
@@ -2198,15 +2217,15 @@ func (T *NegotiateProtocolVersion) WriteTo(encoder *fed.Encoder) (err error) {
 		return
 	}
 
-	temp105 := uint32(len((*T).UnrecognizedProtocolOptions))
+	temp107 := uint32(len((*T).UnrecognizedProtocolOptions))
 
-	err = encoder.Uint32(uint32(temp105))
+	err = encoder.Uint32(uint32(temp107))
 	if err != nil {
 		return
 	}
 
-	for _, temp106 := range (*T).UnrecognizedProtocolOptions {
-		err = encoder.String(string(temp106))
+	for _, temp108 := range (*T).UnrecognizedProtocolOptions {
+		err = encoder.String(string(temp108))
 		if err != nil {
 			return
 		}
@@ -2264,17 +2283,17 @@ func (T *NoticeResponse) Type() fed.Type {
 }
 
 func (T *NoticeResponse) Length() (length int) {
-	for _, temp107 := range *T {
-		_ = temp107
+	for _, temp109 := range *T {
+		_ = temp109
 
 		length += 1
 
-		length += len(temp107.Value) + 1
+		length += len(temp109.Value) + 1
 
 	}
 
-	var temp108 uint8
-	_ = temp108
+	var temp110 uint8
+	_ = temp110
 
 	length += 1
 
@@ -2317,22 +2336,22 @@ func (T *NoticeResponse) ReadFrom(decoder *fed.Decoder) (err error) {
 }
 
 func (T *NoticeResponse) WriteTo(encoder *fed.Encoder) (err error) {
-	for _, temp109 := range *T {
-		err = encoder.Uint8(uint8(temp109.Code))
+	for _, temp111 := range *T {
+		err = encoder.Uint8(uint8(temp111.Code))
 		if err != nil {
 			return
 		}
 
-		err = encoder.String(string(temp109.Value))
+		err = encoder.String(string(temp111.Value))
 		if err != nil {
 			return
 		}
 
 	}
 
-	var temp110 uint8
+	var temp112 uint8
 
-	err = encoder.Uint8(uint8(temp110))
+	err = encoder.Uint8(uint8(temp112))
 	if err != nil {
 		return
 	}
@@ -2421,13 +2440,13 @@ func (T *ParameterDescription) Type() fed.Type {
 }
 
 func (T *ParameterDescription) Length() (length int) {
-	temp111 := uint16(len((*T)))
-	_ = temp111
+	temp113 := uint16(len((*T)))
+	_ = temp113
 
 	length += 2
 
-	for _, temp112 := range *T {
-		_ = temp112
+	for _, temp114 := range *T {
+		_ = temp114
 
 		length += 4
 
@@ -2449,16 +2468,16 @@ func (T *ParameterDescription) ReadFrom(decoder *fed.Decoder) (err error) {
 		return ErrUnexpectedPacket
 	}
 
-	var temp113 uint16
-	*(*uint16)(&(temp113)), err = decoder.Uint16()
+	var temp115 uint16
+	*(*uint16)(&(temp115)), err = decoder.Uint16()
 	if err != nil {
 		return
 	}
 
-	(*T) = slices.Resize((*T), int(temp113))
+	(*T) = slices.Resize((*T), int(temp115))
 
-	for temp114 := 0; temp114 < int(temp113); temp114++ {
-		*(*int32)(&((*T)[temp114])), err = decoder.Int32()
+	for temp116 := 0; temp116 < int(temp115); temp116++ {
+		*(*int32)(&((*T)[temp116])), err = decoder.Int32()
 		if err != nil {
 			return
 		}
@@ -2469,15 +2488,15 @@ func (T *ParameterDescription) ReadFrom(decoder *fed.Decoder) (err error) {
 }
 
 func (T *ParameterDescription) WriteTo(encoder *fed.Encoder) (err error) {
-	temp115 := uint16(len((*T)))
+	temp117 := uint16(len((*T)))
 
-	err = encoder.Uint16(uint16(temp115))
+	err = encoder.Uint16(uint16(temp117))
 	if err != nil {
 		return
 	}
 
-	for _, temp116 := range *T {
-		err = encoder.Int32(int32(temp116))
+	for _, temp118 := range *T {
+		err = encoder.Int32(int32(temp118))
 		if err != nil {
 			return
 		}
@@ -2566,13 +2585,13 @@ func (T *Parse) Length() (length int) {
 
 	length += len((*T).Query) + 1
 
-	temp117 := uint16(len((*T).ParameterDataTypes))
-	_ = temp117
+	temp119 := uint16(len((*T).ParameterDataTypes))
+	_ = temp119
 
 	length += 2
 
-	for _, temp118 := range (*T).ParameterDataTypes {
-		_ = temp118
+	for _, temp120 := range (*T).ParameterDataTypes {
+		_ = temp120
 
 		length += 4
 
@@ -2602,16 +2621,16 @@ func (T *Parse) ReadFrom(decoder *fed.Decoder) (err error) {
 	if err != nil {
 		return
 	}
-	var temp119 uint16
-	*(*uint16)(&(temp119)), err = decoder.Uint16()
+	var temp121 uint16
+	*(*uint16)(&(temp121)), err = decoder.Uint16()
 	if err != nil {
 		return
 	}
 
-	(*T).ParameterDataTypes = slices.Resize((*T).ParameterDataTypes, int(temp119))
+	(*T).ParameterDataTypes = slices.Resize((*T).ParameterDataTypes, int(temp121))
 
-	for temp120 := 0; temp120 < int(temp119); temp120++ {
-		*(*int32)(&((*T).ParameterDataTypes[temp120])), err = decoder.Int32()
+	for temp122 := 0; temp122 < int(temp121); temp122++ {
+		*(*int32)(&((*T).ParameterDataTypes[temp122])), err = decoder.Int32()
 		if err != nil {
 			return
 		}
@@ -2632,15 +2651,15 @@ func (T *Parse) WriteTo(encoder *fed.Encoder) (err error) {
 		return
 	}
 
-	temp121 := uint16(len((*T).ParameterDataTypes))
+	temp123 := uint16(len((*T).ParameterDataTypes))
 
-	err = encoder.Uint16(uint16(temp121))
+	err = encoder.Uint16(uint16(temp123))
 	if err != nil {
 		return
 	}
 
-	for _, temp122 := range (*T).ParameterDataTypes {
-		err = encoder.Int32(int32(temp122))
+	for _, temp124 := range (*T).ParameterDataTypes {
+		err = encoder.Int32(int32(temp124))
 		if err != nil {
 			return
 		}
@@ -2869,15 +2888,15 @@ func (T *RowDescription) Type() fed.Type {
 }
 
 func (T *RowDescription) Length() (length int) {
-	temp123 := uint16(len((*T)))
-	_ = temp123
+	temp125 := uint16(len((*T)))
+	_ = temp125
 
 	length += 2
 
-	for _, temp124 := range *T {
-		_ = temp124
+	for _, temp126 := range *T {
+		_ = temp126
 
-		length += len(temp124.Name) + 1
+		length += len(temp126.Name) + 1
 
 		length += 4
 
@@ -2909,40 +2928,40 @@ func (T *RowDescription) ReadFrom(decoder *fed.Decoder) (err error) {
 		return ErrUnexpectedPacket
 	}
 
-	var temp125 uint16
-	*(*uint16)(&(temp125)), err = decoder.Uint16()
+	var temp127 uint16
+	*(*uint16)(&(temp127)), err = decoder.Uint16()
 	if err != nil {
 		return
 	}
 
-	(*T) = slices.Resize((*T), int(temp125))
+	(*T) = slices.Resize((*T), int(temp127))
 
-	for temp126 := 0; temp126 < int(temp125); temp126++ {
-		*(*string)(&((*T)[temp126].Name)), err = decoder.String()
+	for temp128 := 0; temp128 < int(temp127); temp128++ {
+		*(*string)(&((*T)[temp128].Name)), err = decoder.String()
 		if err != nil {
 			return
 		}
-		*(*int32)(&((*T)[temp126].TableID)), err = decoder.Int32()
+		*(*int32)(&((*T)[temp128].TableID)), err = decoder.Int32()
 		if err != nil {
 			return
 		}
-		*(*int16)(&((*T)[temp126].ColumnAttributeNumber)), err = decoder.Int16()
+		*(*int16)(&((*T)[temp128].ColumnAttributeNumber)), err = decoder.Int16()
 		if err != nil {
 			return
 		}
-		*(*int32)(&((*T)[temp126].FieldDataType)), err = decoder.Int32()
+		*(*int32)(&((*T)[temp128].FieldDataType)), err = decoder.Int32()
 		if err != nil {
 			return
 		}
-		*(*int16)(&((*T)[temp126].DataTypeSize)), err = decoder.Int16()
+		*(*int16)(&((*T)[temp128].DataTypeSize)), err = decoder.Int16()
 		if err != nil {
 			return
 		}
-		*(*int32)(&((*T)[temp126].TypeModifier)), err = decoder.Int32()
+		*(*int32)(&((*T)[temp128].TypeModifier)), err = decoder.Int32()
 		if err != nil {
 			return
 		}
-		*(*int16)(&((*T)[temp126].FormatCode)), err = decoder.Int16()
+		*(*int16)(&((*T)[temp128].FormatCode)), err = decoder.Int16()
 		if err != nil {
 			return
 		}
@@ -2953,45 +2972,45 @@ func (T *RowDescription) ReadFrom(decoder *fed.Decoder) (err error) {
 }
 
 func (T *RowDescription) WriteTo(encoder *fed.Encoder) (err error) {
-	temp127 := uint16(len((*T)))
+	temp129 := uint16(len((*T)))
 
-	err = encoder.Uint16(uint16(temp127))
+	err = encoder.Uint16(uint16(temp129))
 	if err != nil {
 		return
 	}
 
-	for _, temp128 := range *T {
-		err = encoder.String(string(temp128.Name))
+	for _, temp130 := range *T {
+		err = encoder.String(string(temp130.Name))
 		if err != nil {
 			return
 		}
 
-		err = encoder.Int32(int32(temp128.TableID))
+		err = encoder.Int32(int32(temp130.TableID))
 		if err != nil {
 			return
 		}
 
-		err = encoder.Int16(int16(temp128.ColumnAttributeNumber))
+		err = encoder.Int16(int16(temp130.ColumnAttributeNumber))
 		if err != nil {
 			return
 		}
 
-		err = encoder.Int32(int32(temp128.FieldDataType))
+		err = encoder.Int32(int32(temp130.FieldDataType))
 		if err != nil {
 			return
 		}
 
-		err = encoder.Int16(int16(temp128.DataTypeSize))
+		err = encoder.Int16(int16(temp130.DataTypeSize))
 		if err != nil {
 			return
 		}
 
-		err = encoder.Int32(int32(temp128.TypeModifier))
+		err = encoder.Int32(int32(temp130.TypeModifier))
 		if err != nil {
 			return
 		}
 
-		err = encoder.Int16(int16(temp128.FormatCode))
+		err = encoder.Int16(int16(temp130.FormatCode))
 		if err != nil {
 			return
 		}
@@ -3017,13 +3036,13 @@ func (T *SASLInitialResponse) Type() fed.Type {
 func (T *SASLInitialResponse) Length() (length int) {
 	length += len((*T).Mechanism) + 1
 
-	temp129 := int32(len((*T).InitialClientResponse))
-	_ = temp129
+	temp131 := int32(len((*T).InitialClientResponse))
+	_ = temp131
 
 	length += 4
 
-	for _, temp130 := range (*T).InitialClientResponse {
-		_ = temp130
+	for _, temp132 := range (*T).InitialClientResponse {
+		_ = temp132
 
 		length += 1
 
@@ -3049,23 +3068,23 @@ func (T *SASLInitialResponse) ReadFrom(decoder *fed.Decoder) (err error) {
 	if err != nil {
 		return
 	}
-	var temp131 int32
-	*(*int32)(&(temp131)), err = decoder.Int32()
+	var temp133 int32
+	*(*int32)(&(temp133)), err = decoder.Int32()
 	if err != nil {
 		return
 	}
 
-	if temp131 == -1 {
+	if temp133 == -1 {
 		(*T).InitialClientResponse = nil
 	} else {
 		if (*T).InitialClientResponse == nil {
-			(*T).InitialClientResponse = make([]uint8, int(temp131))
+			(*T).InitialClientResponse = make([]uint8, int(temp133))
 		} else {
-			(*T).InitialClientResponse = slices.Resize((*T).InitialClientResponse, int(temp131))
+			(*T).InitialClientResponse = slices.Resize((*T).InitialClientResponse, int(temp133))
 		}
 
-		for temp132 := 0; temp132 < int(temp131); temp132++ {
-			*(*uint8)(&((*T).InitialClientResponse[temp132])), err = decoder.Uint8()
+		for temp134 := 0; temp134 < int(temp133); temp134++ {
+			*(*uint8)(&((*T).InitialClientResponse[temp134])), err = decoder.Uint8()
 			if err != nil {
 				return
 			}
@@ -3082,19 +3101,19 @@ func (T *SASLInitialResponse) WriteTo(encoder *fed.Encoder) (err error) {
 		return
 	}
 
-	temp133 := int32(len((*T).InitialClientResponse))
+	temp135 := int32(len((*T).InitialClientResponse))
 
 	if (*T).InitialClientResponse == nil {
-		temp133 = -1
+		temp135 = -1
 	}
 
-	err = encoder.Int32(int32(temp133))
+	err = encoder.Int32(int32(temp135))
 	if err != nil {
 		return
 	}
 
-	for _, temp134 := range (*T).InitialClientResponse {
-		err = encoder.Uint8(uint8(temp134))
+	for _, temp136 := range (*T).InitialClientResponse {
+		err = encoder.Uint8(uint8(temp136))
 		if err != nil {
 			return
 		}
@@ -3113,8 +3132,8 @@ func (T *SASLResponse) Type() fed.Type {
 }
 
 func (T *SASLResponse) Length() (length int) {
-	for _, temp135 := range *T {
-		_ = temp135
+	for _, temp137 := range *T {
+		_ = temp137
 
 		length += 1
 
@@ -3156,8 +3175,8 @@ func (T *SASLResponse) ReadFrom(decoder *fed.Decoder) (err error) {
 }
 
 func (T *SASLResponse) WriteTo(encoder *fed.Encoder) (err error) {
-	for _, temp136 := range *T {
-		err = encoder.Uint8(uint8(temp136))
+	for _, temp138 := range *T {
+		err = encoder.Uint8(uint8(temp138))
 		if err != nil {
 			return
 		}
@@ -3171,7 +3190,7 @@ var _ fed.Packet = (*SASLResponse)(nil)
 
 type StartupPayloadControlPayloadCancelKey struct {
 	ProcessID int32
-	SecretKey int32
+	SecretKey []uint8
 }
 
 type StartupPayloadControlPayloadCancel StartupPayloadControlPayloadCancelKey
@@ -3183,7 +3202,12 @@ func (*StartupPayloadControlPayloadCancel) StartupPayloadControlPayloadMode() in
 func (T *StartupPayloadControlPayloadCancel) Length() (length int) {
 	length += 4
 
-	length += 4
+	for _, temp139 := range (*T).SecretKey {
+		_ = temp139
+
+		length += 1
+
+	}
 
 	return
 }
@@ -3193,9 +3217,20 @@ func (T *StartupPayloadControlPayloadCancel) ReadFrom(decoder *fed.Decoder) (err
 	if err != nil {
 		return
 	}
-	*(*int32)(&((*T).SecretKey)), err = decoder.Int32()
-	if err != nil {
-		return
+	(*T).SecretKey = (*T).SecretKey[:0]
+
+	for {
+		if decoder.Position() >= decoder.Length() {
+			break
+		}
+
+		(*T).SecretKey = slices.Resize((*T).SecretKey, len((*T).SecretKey)+1)
+
+		*(*uint8)(&((*T).SecretKey[len((*T).SecretKey)-1])), err = decoder.Uint8()
+		if err != nil {
+			return
+		}
+
 	}
 
 	return
@@ -3207,9 +3242,12 @@ func (T *StartupPayloadControlPayloadCancel) WriteTo(encoder *fed.Encoder) (err
 		return
 	}
 
-	err = encoder.Int32(int32((*T).SecretKey))
-	if err != nil {
-		return
+	for _, temp140 := range (*T).SecretKey {
+		err = encoder.Uint8(uint8(temp140))
+		if err != nil {
+			return
+		}
+
 	}
 
 	return
@@ -3283,14 +3321,14 @@ func (T *StartupPayloadControl) Length() (length int) {
 }
 
 func (T *StartupPayloadControl) ReadFrom(decoder *fed.Decoder) (err error) {
-	var temp137 int16
+	var temp141 int16
 
-	*(*int16)(&(temp137)), err = decoder.Int16()
+	*(*int16)(&(temp141)), err = decoder.Int16()
 	if err != nil {
 		return
 	}
 
-	switch temp137 {
+	switch temp141 {
 	case 5678:
 		(*T).Mode = new(StartupPayloadControlPayloadCancel)
 	case 5680:
@@ -3343,19 +3381,19 @@ func (*StartupPayloadVersion3) StartupPayloadMode() int16 {
 func (T *StartupPayloadVersion3) Length() (length int) {
 	length += 2
 
-	for _, temp138 := range (*T).Parameters {
-		_ = temp138
+	for _, temp142 := range (*T).Parameters {
+		_ = temp142
 
-		length += len(temp138.Key) + 1
+		length += len(temp142.Key) + 1
 
-		length += len(temp138.Value) + 1
+		length += len(temp142.Value) + 1
 
 	}
 
-	var temp139 string
-	_ = temp139
+	var temp143 string
+	_ = temp143
 
-	length += len(temp139) + 1
+	length += len(temp143) + 1
 
 	return
 }
@@ -3393,22 +3431,22 @@ func (T *StartupPayloadVersion3) WriteTo(encoder *fed.Encoder) (err error) {
 		return
 	}
 
-	for _, temp140 := range (*T).Parameters {
-		err = encoder.String(string(temp140.Key))
+	for _, temp144 := range (*T).Parameters {
+		err = encoder.String(string(temp144.Key))
 		if err != nil {
 			return
 		}
 
-		err = encoder.String(string(temp140.Value))
+		err = encoder.String(string(temp144.Value))
 		if err != nil {
 			return
 		}
 
 	}
 
-	var temp141 string
+	var temp145 string
 
-	err = encoder.String(string(temp141))
+	err = encoder.String(string(temp145))
 	if err != nil {
 		return
 	}
@@ -3454,14 +3492,14 @@ func (T *Startup) ReadFrom(decoder *fed.Decoder) (err error) {
 		return ErrUnexpectedPacket
 	}
 
-	var temp142 int16
+	var temp146 int16
 
-	*(*int16)(&(temp142)), err = decoder.Int16()
+	*(*int16)(&(temp146)), err = decoder.Int16()
 	if err != nil {
 		return
 	}
 
-	switch temp142 {
+	switch temp146 {
 	case 1234:
 		(*T).Mode = new(StartupPayloadControl)
 	case 3:
@@ -0,0 +1,33 @@
+package gatcaddyfile
+
+import (
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool/balancers/least_active"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool/balancers/power_of_two"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool/balancers/round_robin"
+)
+
+func init() {
+	// Register directives for the balancers which pick which recipe (replica) new servers are opened with. Recipes are
+	// balanced within their priority, and critic penalties only break ties.
+	//
+	// Config Format
+	//
+	//	pool hybrid {
+	//		balance round_robin		# weighted by each node's weight
+	//		balance least_active	# fewest servers in use
+	//		balance power_of_two	# lower latency of two random recipes
+	//	}
+	RegisterDirective(Balancer, "round_robin", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
+		return &round_robin.Balancer{}, nil
+	})
+	RegisterDirective(Balancer, "least_active", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
+		return &least_active.Balancer{}, nil
+	})
+	RegisterDirective(Balancer, "power_of_two", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
+		return &power_of_two.Balancer{}, nil
+	})
+}
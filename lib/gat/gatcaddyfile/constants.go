@@ -3,9 +3,11 @@ package gatcaddyfile
 // Common directive and configuration constants
 const (
 	// Directives
-	directiveSSL = "ssl"
+	directiveSSL           = "ssl"
+	directiveGSS           = "gss"
+	directiveProxyProtocol = "proxy_protocol"
 
 	// Boolean string values
 	boolTrue  = "true"
 	boolFalse = "false"
-)
\ No newline at end of file
+)
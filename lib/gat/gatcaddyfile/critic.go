@@ -1,12 +1,15 @@
 package gatcaddyfile
 
 import (
+	"strconv"
+
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool/critics/replication"
 	"github.com/caddyserver/caddy/v2"
 	"github.com/caddyserver/caddy/v2/caddyconfig"
 	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
 
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool/critics/latency"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool/critics/role"
 )
 
 func init() {
@@ -105,6 +108,60 @@ func init() {
 
 		return module, nil
 	})
+
+	// Register a directive handler for the role critic which checks
+	// pg_is_in_recovery() on each server. Servers in recovery are penalized,
+	// and hybrid pools move recipes between primaries and replicas when a
+	// server changes role
+	//
+	//	Config format
+	//
+	//	* All fields are optional and will fall back to a suitable default
+	//	* Duration values use caddy.Duration syntax
+	//
+	//	pool hybrid {
+	//			penalize role		# valid declaration. Use default values
+	//
+	//			penalize role {
+	//				penalty 1000
+	//				validity 5s
+	//			}
+	//	}
+	RegisterDirective(Critic, "role", func(d *caddyfile.Dispenser, warnings *[]caddyconfig.Warning) (caddy.Module, error) {
+		module := role.NewCritic()
+
+		for nesting := d.Nesting(); d.NextBlock(nesting); {
+			directive := d.Val()
+			switch directive {
+			case "validity":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				validity, err := caddy.ParseDuration(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.Validity = caddy.Duration(validity)
+			case "penalty":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				penalty, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.Penalty = penalty
+			default:
+				return nil, d.ArgErr()
+			}
+		}
+
+		return module, nil
+	})
 }
 
 func parseQueryCritic(d *caddyfile.Dispenser, warnings *[]caddyconfig.Warning) (caddy.Module, error) {
@@ -5,8 +5,14 @@ import (
 	"strconv"
 
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/cloudnative_pg"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/consul"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/digitalocean"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/dns"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/etcd"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/file"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/google_cloud_sql"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/k8s_labels"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/patroni"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/zalando_operator"
 	"gfx.cafe/gfx/pggat/lib/k8s"
 	"github.com/caddyserver/caddy/v2"
@@ -88,6 +94,17 @@ func init() {
 
 		return &module, nil
 	})
+	RegisterDirective(Discoverer, "file", func(d *caddyfile.Dispenser, warnings *[]caddyconfig.Warning) (caddy.Module, error) {
+		if !d.NextArg() {
+			return nil, d.ArgErr()
+		}
+
+		return &file.Discoverer{
+			Config: file.Config{
+				Path: d.Val(),
+			},
+		}, nil
+	})
 	RegisterDirective(Discoverer, "google_cloud_sql", func(d *caddyfile.Dispenser, warnings *[]caddyconfig.Warning) (caddy.Module, error) {
 		module := google_cloud_sql.Discoverer{
 			Config: google_cloud_sql.Config{
@@ -112,6 +129,289 @@ func init() {
 
 		return &module, nil
 	})
+	RegisterDirective(Discoverer, "consul", func(d *caddyfile.Dispenser, warnings *[]caddyconfig.Warning) (caddy.Module, error) {
+		module := consul.Discoverer{}
+
+		for nesting := d.Nesting(); d.NextBlock(nesting); {
+			directive := d.Val()
+			switch directive {
+			case "service":
+				services := d.RemainingArgs()
+				if len(services) == 0 {
+					return nil, d.ArgErr()
+				}
+				module.Services = append(module.Services, services...)
+			case "address", "token", "datacenter", "primary_tag", "replica_tag", "kv_prefix":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				switch directive {
+				case "address":
+					module.Address = d.Val()
+				case "token":
+					module.Token = d.Val()
+				case "datacenter":
+					module.Datacenter = d.Val()
+				case "primary_tag":
+					module.PrimaryTag = d.Val()
+				case "replica_tag":
+					module.ReplicaTag = d.Val()
+				case "kv_prefix":
+					module.KVPrefix = d.Val()
+				}
+			case "wait", "timeout":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := caddy.ParseDuration(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				if directive == "wait" {
+					module.Wait = caddy.Duration(val)
+				} else {
+					module.Timeout = caddy.Duration(val)
+				}
+			default:
+				return nil, d.ArgErr()
+			}
+		}
+
+		return &module, nil
+	})
+	RegisterDirective(Discoverer, "dns", func(d *caddyfile.Dispenser, warnings *[]caddyconfig.Warning) (caddy.Module, error) {
+		module := dns.Discoverer{}
+
+		for nesting := d.Nesting(); d.NextBlock(nesting); {
+			directive := d.Val()
+			switch directive {
+			case "service":
+				// service <id> {
+				//   primary <address>
+				//   replicas <name> [port]
+				//   srv <name>
+				//   database <name...>
+				//   user <username> [password]
+				// }
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+				service := dns.Service{
+					ID: d.Val(),
+				}
+
+				for nesting := d.Nesting(); d.NextBlock(nesting); {
+					subDirective := d.Val()
+					switch subDirective {
+					case "primary":
+						if !d.NextArg() {
+							return nil, d.ArgErr()
+						}
+						service.Primary = d.Val()
+					case "replicas":
+						if !d.NextArg() {
+							return nil, d.ArgErr()
+						}
+						service.Replicas = d.Val()
+						service.SRV = false
+
+						if d.NextArg() {
+							port, err := strconv.Atoi(d.Val())
+							if err != nil {
+								return nil, d.WrapErr(err)
+							}
+							service.Port = port
+						}
+					case "srv":
+						if !d.NextArg() {
+							return nil, d.ArgErr()
+						}
+						service.Replicas = d.Val()
+						service.SRV = true
+					case "database":
+						databases := d.RemainingArgs()
+						if len(databases) == 0 {
+							return nil, d.ArgErr()
+						}
+						service.Databases = append(service.Databases, databases...)
+					case "user":
+						if !d.NextArg() {
+							return nil, d.ArgErr()
+						}
+						user := dns.User{
+							Username: d.Val(),
+						}
+						if d.NextArg() {
+							user.Password = d.Val()
+						}
+						service.Users = append(service.Users, user)
+					default:
+						return nil, d.Errf("unrecognized service subdirective: %s", subDirective)
+					}
+				}
+
+				module.Services = append(module.Services, service)
+			case "nameserver":
+				nameservers := d.RemainingArgs()
+				if len(nameservers) == 0 {
+					return nil, d.ArgErr()
+				}
+				module.Nameservers = append(module.Nameservers, nameservers...)
+			case "min_ttl", "max_ttl", "nxdomain_ttl":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := caddy.ParseDuration(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				switch directive {
+				case "min_ttl":
+					module.MinTTL = caddy.Duration(val)
+				case "max_ttl":
+					module.MaxTTL = caddy.Duration(val)
+				case "nxdomain_ttl":
+					module.NXDomainTTL = caddy.Duration(val)
+				}
+			default:
+				return nil, d.ArgErr()
+			}
+		}
+
+		return &module, nil
+	})
+	RegisterDirective(Discoverer, "etcd", func(d *caddyfile.Dispenser, warnings *[]caddyconfig.Warning) (caddy.Module, error) {
+		module := etcd.Discoverer{}
+
+		for nesting := d.Nesting(); d.NextBlock(nesting); {
+			directive := d.Val()
+			switch directive {
+			case "endpoint":
+				endpoints := d.RemainingArgs()
+				if len(endpoints) == 0 {
+					return nil, d.ArgErr()
+				}
+				module.Endpoints = append(module.Endpoints, endpoints...)
+			case "username", "password", "prefix":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				switch directive {
+				case "username":
+					module.Username = d.Val()
+				case "password":
+					module.Password = d.Val()
+				case "prefix":
+					module.Prefix = d.Val()
+				}
+			case "timeout":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := caddy.ParseDuration(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.Timeout = caddy.Duration(val)
+			default:
+				return nil, d.ArgErr()
+			}
+		}
+
+		return &module, nil
+	})
+	RegisterDirective(Discoverer, "patroni", func(d *caddyfile.Dispenser, warnings *[]caddyconfig.Warning) (caddy.Module, error) {
+		module := patroni.Discoverer{}
+
+		for nesting := d.Nesting(); d.NextBlock(nesting); {
+			directive := d.Val()
+			switch directive {
+			case "scope":
+				// scope <id> {
+				//   endpoint <url...>
+				//   database <name...>
+				//   user <username> [password]
+				// }
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+				scope := patroni.Scope{
+					ID: d.Val(),
+				}
+
+				for nesting := d.Nesting(); d.NextBlock(nesting); {
+					subDirective := d.Val()
+					switch subDirective {
+					case "endpoint":
+						endpoints := d.RemainingArgs()
+						if len(endpoints) == 0 {
+							return nil, d.ArgErr()
+						}
+						scope.Endpoints = append(scope.Endpoints, endpoints...)
+					case "database":
+						databases := d.RemainingArgs()
+						if len(databases) == 0 {
+							return nil, d.ArgErr()
+						}
+						scope.Databases = append(scope.Databases, databases...)
+					case "user":
+						if !d.NextArg() {
+							return nil, d.ArgErr()
+						}
+						user := patroni.User{
+							Username: d.Val(),
+						}
+						if d.NextArg() {
+							user.Password = d.Val()
+						}
+						scope.Users = append(scope.Users, user)
+					default:
+						return nil, d.Errf("unrecognized scope subdirective: %s", subDirective)
+					}
+				}
+
+				module.Scopes = append(module.Scopes, scope)
+			case "poll_interval", "timeout":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := caddy.ParseDuration(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				if directive == "poll_interval" {
+					module.PollInterval = caddy.Duration(val)
+				} else {
+					module.Timeout = caddy.Duration(val)
+				}
+			case "max_lag":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := strconv.ParseInt(d.Val(), 10, 64)
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.MaxLag = val
+			default:
+				return nil, d.ArgErr()
+			}
+		}
+
+		return &module, nil
+	})
 	RegisterDirective(Discoverer, "zalando_operator", func(d *caddyfile.Dispenser, warnings *[]caddyconfig.Warning) (caddy.Module, error) {
 		module := zalando_operator.Discoverer{
 			Config: zalando_operator.Config{
@@ -311,6 +611,97 @@ func init() {
 			}
 		}
 
+		return &module, nil
+	})
+	RegisterDirective(Discoverer, "k8s_labels", func(d *caddyfile.Dispenser, warnings *[]caddyconfig.Warning) (caddy.Module, error) {
+		module := k8s_labels.Discoverer{
+			Config: k8s_labels.Config{
+				Namespace: k8s.NamespaceMatcher{
+					Labels: make(map[string]string),
+				},
+			},
+		}
+
+		for nesting := d.Nesting(); d.NextBlock(nesting); {
+			directive := d.Val()
+			switch directive {
+			case "namespace":
+				// namespace can be either:
+				// namespace <name>
+				// or
+				// namespace [<name>] {
+				//   label key value
+				// }
+				if d.NextArg() {
+					module.Namespace.Namespace = d.Val()
+				}
+				// Check for block
+				for nesting := d.Nesting(); d.NextBlock(nesting); {
+					subDirective := d.Val()
+					switch subDirective {
+					case "label":
+						if !d.NextArg() {
+							return nil, d.Err("label directive requires a key argument")
+						}
+						key := d.Val()
+						if !d.NextArg() {
+							return nil, d.Errf("label directive requires a value argument for key %s", key)
+						}
+						value := d.Val()
+						module.Namespace.Labels[key] = value
+					default:
+						return nil, d.Errf("unrecognized namespace subdirective: %s", subDirective)
+					}
+				}
+			case "kind", "selector", "cluster_label", "role_label", "cluster_domain", "secrets_annotation":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				switch directive {
+				case "kind":
+					module.Kind = d.Val()
+				case "selector":
+					module.Selector = d.Val()
+				case "cluster_label":
+					module.ClusterLabel = d.Val()
+				case "role_label":
+					module.RoleLabel = d.Val()
+				case "cluster_domain":
+					module.ClusterDomain = d.Val()
+				case "secrets_annotation":
+					module.SecretsAnnotation = d.Val()
+				}
+			case "primary_roles", "replica_roles", "secret", "database":
+				values := d.RemainingArgs()
+				if len(values) == 0 {
+					return nil, d.ArgErr()
+				}
+
+				switch directive {
+				case "primary_roles":
+					module.PrimaryRoles = append(module.PrimaryRoles, values...)
+				case "replica_roles":
+					module.ReplicaRoles = append(module.ReplicaRoles, values...)
+				case "secret":
+					module.Secrets = append(module.Secrets, values...)
+				case "database":
+					module.Databases = append(module.Databases, values...)
+				}
+			case "port":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+				port, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return nil, fmt.Errorf("error parsing port: %v", err)
+				}
+				module.Port = port
+			default:
+				return nil, d.ArgErr()
+			}
+		}
+
 		return &module, nil
 	})
 }
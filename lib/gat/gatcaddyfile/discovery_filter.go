@@ -0,0 +1,136 @@
+package gatcaddyfile
+
+import (
+	"strconv"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/caddyserver/caddy/v2/caddyconfig"
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/filters"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/filters/databases"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/filters/id"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/filters/min_replicas"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/filters/rename_database"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/filters/users"
+	"gfx.cafe/gfx/pggat/lib/util/strutil"
+)
+
+func init() {
+	// Register a directive for the cluster id filter, which keeps only clusters with an ID matching a regex
+	//
+	//	discovery {
+	//		filter id ^prod-
+	//	}
+	RegisterDirective(DiscoveryFilter, "id", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
+		if !d.NextArg() {
+			return nil, d.ArgErr()
+		}
+
+		return &id.Filter{
+			Pattern: d.Val(),
+		}, nil
+	})
+
+	// Register directives for the database and user filters, which remove databases or users that aren't allowed.
+	// Patterns may use * as a wildcard
+	//
+	//	discovery {
+	//		filter databases deny postgres template*
+	//		filter users {
+	//			allow app_*
+	//			deny app_admin
+	//		}
+	//	}
+	RegisterDirective(DiscoveryFilter, "databases", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
+		module := databases.Filter{}
+		if err := unmarshalFilterList(d, &module.List); err != nil {
+			return nil, err
+		}
+		return &module, nil
+	})
+	RegisterDirective(DiscoveryFilter, "users", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
+		module := users.Filter{}
+		if err := unmarshalFilterList(d, &module.List); err != nil {
+			return nil, err
+		}
+		return &module, nil
+	})
+
+	// Register a directive for the min replicas filter, which drops clusters with fewer replicas
+	//
+	//	discovery {
+	//		filter min_replicas 1
+	//	}
+	RegisterDirective(DiscoveryFilter, "min_replicas", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
+		if !d.NextArg() {
+			return nil, d.ArgErr()
+		}
+
+		val, err := strconv.Atoi(d.Val())
+		if err != nil {
+			return nil, d.WrapErr(err)
+		}
+
+		return &min_replicas.Filter{
+			Min: val,
+		}, nil
+	})
+
+	// Register a directive for the rename database filter. Clients connect with the new name
+	//
+	//	discovery {
+	//		filter rename_database app_production app
+	//	}
+	RegisterDirective(DiscoveryFilter, "rename_database", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
+		module := rename_database.Filter{}
+
+		if !d.NextArg() {
+			return nil, d.ArgErr()
+		}
+		module.From = d.Val()
+
+		if !d.NextArg() {
+			return nil, d.ArgErr()
+		}
+		module.To = d.Val()
+
+		return &module, nil
+	})
+}
+
+func unmarshalFilterListLine(d *caddyfile.Dispenser, list *filters.List) error {
+	kind := d.Val()
+
+	patterns := d.RemainingArgs()
+	if len(patterns) == 0 {
+		return d.ArgErr()
+	}
+
+	for _, pattern := range patterns {
+		switch kind {
+		case "allow":
+			list.Allow = append(list.Allow, strutil.Matcher(pattern))
+		case "deny":
+			list.Deny = append(list.Deny, strutil.Matcher(pattern))
+		default:
+			return d.Errf(`expected "allow" or "deny" but got "%s"`, kind)
+		}
+	}
+
+	return nil
+}
+
+func unmarshalFilterList(d *caddyfile.Dispenser, list *filters.List) error {
+	if d.NextArg() {
+		return unmarshalFilterListLine(d, list)
+	}
+
+	for nesting := d.Nesting(); d.NextBlock(nesting); {
+		if err := unmarshalFilterListLine(d, list); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
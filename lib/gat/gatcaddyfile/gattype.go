@@ -119,6 +119,45 @@ func (ServerType) Setup(blocks []caddyfile.ServerBlock, m map[string]any) (*cadd
 				if d.CountRemainingArgs() > 0 {
 					return nil, nil, d.ArgErr()
 				}
+			case directive == directiveGSS:
+				// gss <keytab> [principal]
+				if !d.NextArg() {
+					return nil, nil, d.ArgErr()
+				}
+				gss := gat.GSSConfig{
+					Keytab: d.Val(),
+				}
+				if d.NextArg() {
+					gss.Principal = d.Val()
+				}
+
+				// set
+				for i := range server.Listen {
+					if server.Listen[i].GSS != nil {
+						return nil, nil, d.Err("duplicate gss directive")
+					}
+					server.Listen[i].GSS = &gss
+				}
+
+				if d.CountRemainingArgs() > 0 {
+					return nil, nil, d.ArgErr()
+				}
+			case directive == directiveProxyProtocol:
+				// proxy_protocol <trusted cidrs...>
+				proxy := gat.ProxyProtocolConfig{
+					Trusted: d.RemainingArgs(),
+				}
+				if len(proxy.Trusted) == 0 {
+					return nil, nil, d.ArgErr()
+				}
+
+				// set
+				for i := range server.Listen {
+					if server.Listen[i].ProxyProtocol != nil {
+						return nil, nil, d.Err("duplicate proxy_protocol directive")
+					}
+					server.Listen[i].ProxyProtocol = &proxy
+				}
 			case directive == "max_connections":
 				if !d.NextArg() {
 					return nil, nil, d.ArgErr()
@@ -1,6 +1,7 @@
 package gatcaddyfile
 
 import (
+	"encoding/json"
 	"strconv"
 	"strings"
 	"time"
@@ -16,7 +17,9 @@ import (
 	"gfx.cafe/gfx/pggat/lib/bouncer"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/allowed_startup_parameters"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/gssapi"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/pgbouncer"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/replication"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/require_ssl"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/rewrite_database"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/rewrite_parameter"
@@ -43,6 +46,50 @@ func init() {
 			SSL: ssl,
 		}, nil
 	})
+	// gssapi [keytab] {
+	//     keytab <path>
+	//     principal <service principal>
+	//     include_realm
+	//     realm <realm>
+	// }
+	RegisterDirective(Handler, "gssapi", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
+		var module gssapi.Module
+
+		if d.NextArg() {
+			module.Keytab = d.Val()
+		}
+
+		for nesting := d.Nesting(); d.NextBlock(nesting); {
+			directive := d.Val()
+			switch directive {
+			case "keytab":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+				module.Keytab = d.Val()
+			case "principal":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+				module.Principal = d.Val()
+			case "include_realm":
+				module.IncludeRealm = true
+			case "realm":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+				module.Realm = d.Val()
+			default:
+				return nil, d.ArgErr()
+			}
+		}
+
+		if module.Keytab == "" {
+			return nil, d.Err("keytab is required")
+		}
+
+		return &module, nil
+	})
 	RegisterDirective(Handler, "allowed_startup_parameters", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
 		if !d.NextBlock(d.Nesting()) {
 			return nil, d.ArgErr()
@@ -145,7 +192,10 @@ func init() {
 	RegisterDirective(Handler, "discovery", func(d *caddyfile.Dispenser, warnings *[]caddyconfig.Warning) (caddy.Module, error) {
 		module := discovery.Module{
 			Config: discovery.Config{
-				ReconcilePeriod: caddy.Duration(5 * time.Minute),
+				ReconcilePeriod:   caddy.Duration(5 * time.Minute),
+				Debounce:          caddy.Duration(time.Second),
+				RemoveGracePeriod: caddy.Duration(30 * time.Second),
+				DrainTimeout:      caddy.Duration(time.Minute),
 				Pool: JSONModuleObject(
 					defaultPool,
 					Pool,
@@ -195,6 +245,36 @@ func init() {
 						return nil, d.WrapErr(err)
 					}
 					module.ReconcilePeriod = caddy.Duration(val)
+				case "debounce":
+					if !d.NextArg() {
+						return nil, d.ArgErr()
+					}
+
+					val, err := time.ParseDuration(d.Val())
+					if err != nil {
+						return nil, d.WrapErr(err)
+					}
+					module.Debounce = caddy.Duration(val)
+				case "remove_grace_period":
+					if !d.NextArg() {
+						return nil, d.ArgErr()
+					}
+
+					val, err := time.ParseDuration(d.Val())
+					if err != nil {
+						return nil, d.WrapErr(err)
+					}
+					module.RemoveGracePeriod = caddy.Duration(val)
+				case "drain_timeout":
+					if !d.NextArg() {
+						return nil, d.ArgErr()
+					}
+
+					val, err := time.ParseDuration(d.Val())
+					if err != nil {
+						return nil, d.WrapErr(err)
+					}
+					module.DrainTimeout = caddy.Duration(val)
 				case "max_connections":
 					if !d.NextArg() {
 						return nil, d.ArgErr()
@@ -245,6 +325,45 @@ func init() {
 					if err != nil {
 						return nil, err
 					}
+				case "filter":
+					if !d.NextArg() {
+						return nil, d.ArgErr()
+					}
+
+					val, err := UnmarshalDirectiveJSONModuleObject(
+						d,
+						DiscoveryFilter,
+						"filter",
+						warnings,
+					)
+					if err != nil {
+						return nil, err
+					}
+					module.Filters = append(module.Filters, val)
+				case "pool_mode":
+					// pool_mode <name> <pool>, picked by clusters with the pool-mode override
+					if !d.NextArg() {
+						return nil, d.ArgErr()
+					}
+					mode := d.Val()
+
+					if !d.NextArg() {
+						return nil, d.ArgErr()
+					}
+
+					val, err := UnmarshalDirectiveJSONModuleObject(
+						d,
+						Pool,
+						"pool",
+						warnings,
+					)
+					if err != nil {
+						return nil, err
+					}
+					if module.PoolModes == nil {
+						module.PoolModes = make(map[string]json.RawMessage)
+					}
+					module.PoolModes[mode] = val
 				case directiveSSL:
 					if !d.NextArg() {
 						return nil, d.ArgErr()
@@ -461,67 +580,14 @@ func init() {
 					if err != nil {
 						return nil, err
 					}
-				case "address":
-					if !d.NextArg() {
-						return nil, d.ArgErr()
-					}
-
-					module.Recipe.Address = d.Val()
-				case directiveSSL:
-					if !d.NextArg() {
-						return nil, d.ArgErr()
-					}
-
-					module.Recipe.SSLMode = bouncer.SSLMode(d.Val())
-
-					if !d.NextArg() {
-						return nil, d.ArgErr()
-					}
-
-					var err error
-					module.Recipe.RawSSL, err = UnmarshalDirectiveJSONModuleObject(
-						d,
-						SSLClient,
-						"provider",
-						warnings,
-					)
+				default:
+					ok, err := unmarshalRecipeDirective(d, &module.Recipe, warnings)
 					if err != nil {
 						return nil, err
 					}
-				case "username":
-					if !d.NextArg() {
-						return nil, d.ArgErr()
-					}
-
-					module.Recipe.Username = d.Val()
-				case "password":
-					if !d.NextArg() {
-						return nil, d.ArgErr()
-					}
-
-					module.Recipe.RawPassword = d.Val()
-				case "database":
-					if !d.NextArg() {
-						return nil, d.ArgErr()
-					}
-
-					module.Recipe.Database = d.Val()
-				case "parameter":
-					if !d.NextArg() {
-						return nil, d.ArgErr()
-					}
-
-					keyValue := d.Val()
-					key, value, ok := strings.Cut(keyValue, "=")
 					if !ok {
-						return nil, d.SyntaxErr("key=value")
-					}
-					if module.Recipe.RawParameters == nil {
-						module.Recipe.RawParameters = make(map[string]string)
+						return nil, d.ArgErr()
 					}
-					module.Recipe.RawParameters[key] = value
-				default:
-					return nil, d.ArgErr()
 				}
 			}
 		}
@@ -529,6 +595,18 @@ func init() {
 		return &module, nil
 	})
 
+	// Register a directive for the replication handler, which proxies replication connections 1:1 to the primary of
+	// the pool they are routed to. Other connections are passed to the next handler, so it should come before the pool.
+	// Replication sessions count towards the primary recipe's max connections.
+	//
+	//	replication
+	RegisterDirective(Handler, "replication", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
+		if d.NextArg() {
+			return nil, d.ArgErr()
+		}
+		return &replication.Module{}, nil
+	})
+
 	RegisterDirective(Handler, "tracing", func(d *caddyfile.Dispenser, warnings *[]caddyconfig.Warning) (caddy.Module, error) {
 		var module caddy.Module
 		var err error
@@ -553,3 +631,103 @@ func init() {
 		return module, nil
 	})
 }
+
+// unmarshalRecipeDirective parses a dialer option of a recipe. Returns false if the directive isn't one.
+func unmarshalRecipeDirective(d *caddyfile.Dispenser, recipe *pool_handler.Recipe, warnings *[]caddyconfig.Warning) (bool, error) {
+	switch d.Val() {
+	case "address":
+		// multiple hosts may be listed as separate args or comma separated
+		hosts := d.RemainingArgs()
+		if len(hosts) == 0 {
+			return false, d.ArgErr()
+		}
+
+		recipe.Address = strings.Join(hosts, ",")
+	case "target_session_attrs":
+		if !d.NextArg() {
+			return false, d.ArgErr()
+		}
+
+		recipe.TargetSessionAttrs = pool_handler.TargetSessionAttrs(d.Val())
+		if !recipe.TargetSessionAttrs.Valid() {
+			return false, d.Errf("invalid target_session_attrs: %s", d.Val())
+		}
+	case "load_balance_hosts":
+		if !d.NextArg() {
+			return false, d.ArgErr()
+		}
+
+		recipe.LoadBalanceHosts = pool_handler.LoadBalanceHosts(d.Val())
+		if !recipe.LoadBalanceHosts.Valid() {
+			return false, d.Errf("invalid load_balance_hosts: %s", d.Val())
+		}
+	case "connect_timeout":
+		if !d.NextArg() {
+			return false, d.ArgErr()
+		}
+
+		val, err := caddy.ParseDuration(d.Val())
+		if err != nil {
+			return false, d.WrapErr(err)
+		}
+
+		recipe.ConnectTimeout = caddy.Duration(val)
+	case directiveSSL:
+		if !d.NextArg() {
+			return false, d.ArgErr()
+		}
+
+		recipe.SSLMode = bouncer.SSLMode(d.Val())
+
+		if !d.NextArg() {
+			return false, d.ArgErr()
+		}
+
+		var err error
+		recipe.RawSSL, err = UnmarshalDirectiveJSONModuleObject(
+			d,
+			SSLClient,
+			"provider",
+			warnings,
+		)
+		if err != nil {
+			return false, err
+		}
+	case "username":
+		if !d.NextArg() {
+			return false, d.ArgErr()
+		}
+
+		recipe.Username = d.Val()
+	case "password":
+		if !d.NextArg() {
+			return false, d.ArgErr()
+		}
+
+		recipe.RawPassword = d.Val()
+	case "database":
+		if !d.NextArg() {
+			return false, d.ArgErr()
+		}
+
+		recipe.Database = d.Val()
+	case "parameter":
+		if !d.NextArg() {
+			return false, d.ArgErr()
+		}
+
+		keyValue := d.Val()
+		key, value, ok := strings.Cut(keyValue, "=")
+		if !ok {
+			return false, d.SyntaxErr("key=value")
+		}
+		if recipe.RawParameters == nil {
+			recipe.RawParameters = make(map[string]string)
+		}
+		recipe.RawParameters[key] = value
+	default:
+		return false, nil
+	}
+
+	return true, nil
+}
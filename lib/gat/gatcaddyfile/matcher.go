@@ -47,6 +47,15 @@ func init() {
 			Address: address,
 		}, nil
 	})
+	RegisterDirective(Matcher, "remote_address", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
+		ranges := d.RemainingArgs()
+		if len(ranges) == 0 {
+			return nil, d.ArgErr()
+		}
+		return &matchers.RemoteAddress{
+			Ranges: ranges,
+		}, nil
+	})
 	RegisterDirective(Matcher, "parameter", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
 		if !d.NextArg() {
 			return nil, d.ArgErr()
@@ -1,6 +1,7 @@
 package gatcaddyfile
 
 import (
+	"strconv"
 	"time"
 
 	"github.com/caddyserver/caddy/v2"
@@ -109,6 +110,32 @@ func init() {
 				} else {
 					module.ExtendedQuerySync = true
 				}
+			case "multiplex_notifications":
+				if d.NextArg() {
+					switch d.Val() {
+					case boolTrue:
+						module.MultiplexNotifications = true
+					case boolFalse:
+						module.MultiplexNotifications = false
+					default:
+						return nil, d.ArgErr()
+					}
+				} else {
+					module.MultiplexNotifications = true
+				}
+			case "pin_session_features":
+				if d.NextArg() {
+					switch d.Val() {
+					case boolTrue:
+						module.PinSessionFeatures = true
+					case boolFalse:
+						module.PinSessionFeatures = false
+					default:
+						return nil, d.ArgErr()
+					}
+				} else {
+					module.PinSessionFeatures = true
+				}
 			case "packet_tracing_option":
 				if d.NextArg() {
 					opt, err := basic.MapTracingOption(d.Val())
@@ -157,6 +184,72 @@ func init() {
 				}
 
 				module.ServerIdleTimeout = caddy.Duration(val)
+			case "max_prepared_statements":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.MaxPreparedStatements = val
+			case "client_max_waiting":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.ClientMaxWaiting = val
+			case "client_max_wait_estimate":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := time.ParseDuration(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.ClientMaxWaitEstimate = caddy.Duration(val)
+			case "client_idle_timeout":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := time.ParseDuration(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.ClientIdleTimeout = caddy.Duration(val)
+			case "idle_transaction_timeout":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := time.ParseDuration(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.IdleTransactionTimeout = caddy.Duration(val)
+			case "query_timeout":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := time.ParseDuration(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.QueryTimeout = caddy.Duration(val)
 			case "reconnect":
 				if !d.NextArg() {
 					return nil, d.ArgErr()
@@ -193,6 +286,22 @@ func init() {
 				}
 
 				module.RawCritics = append(module.RawCritics, critic)
+			case "balance":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				balancer, err := UnmarshalDirectiveJSONModuleObject(
+					d,
+					Balancer,
+					"balancer",
+					warnings,
+				)
+				if err != nil {
+					return nil, err
+				}
+
+				module.RawBalancer = balancer
 			default:
 				return nil, d.ArgErr()
 			}
@@ -228,6 +337,96 @@ func init() {
 				}
 
 				module.ServerIdleTimeout = caddy.Duration(val)
+			case "max_prepared_statements":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.MaxPreparedStatements = val
+			case "max_buffer_size":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.MaxBufferSize = val
+			case "multiplex_notifications":
+				if d.NextArg() {
+					switch d.Val() {
+					case boolTrue:
+						module.MultiplexNotifications = true
+					case boolFalse:
+						module.MultiplexNotifications = false
+					default:
+						return nil, d.ArgErr()
+					}
+				} else {
+					module.MultiplexNotifications = true
+				}
+			case "client_max_waiting":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := strconv.Atoi(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.ClientMaxWaiting = val
+			case "client_max_wait_estimate":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := time.ParseDuration(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.ClientMaxWaitEstimate = caddy.Duration(val)
+			case "client_idle_timeout":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := time.ParseDuration(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.ClientIdleTimeout = caddy.Duration(val)
+			case "idle_transaction_timeout":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := time.ParseDuration(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.IdleTransactionTimeout = caddy.Duration(val)
+			case "query_timeout":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				val, err := time.ParseDuration(d.Val())
+				if err != nil {
+					return nil, d.WrapErr(err)
+				}
+
+				module.QueryTimeout = caddy.Duration(val)
 			case "reconnect":
 				if !d.NextArg() {
 					return nil, d.ArgErr()
@@ -270,6 +469,22 @@ func init() {
 				}
 
 				module.RawCritics = append(module.RawCritics, critic)
+			case "balance":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				balancer, err := UnmarshalDirectiveJSONModuleObject(
+					d,
+					Balancer,
+					"balancer",
+					warnings,
+				)
+				if err != nil {
+					return nil, err
+				}
+
+				module.RawBalancer = balancer
 			default:
 				return nil, d.ArgErr()
 			}
@@ -1,6 +1,8 @@
 package gatcaddyfile
 
 import (
+	"strconv"
+
 	"github.com/caddyserver/caddy/v2"
 	"github.com/caddyserver/caddy/v2/caddyconfig"
 	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
@@ -13,7 +15,88 @@ func init() {
 	RegisterDirective(Pooler, "lifo", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
 		return &lifo.Factory{}, nil
 	})
+
+	// Register a directive for the rob pooler, which fair shares servers between clients.
+	//
+	// Config Format
+	//
+	//	* All fields are optional
+	//	* group_by is user (default) or application_name
+	//	* a group's weight is the share of server time of the whole group relative to each ungrouped client (which has a
+	//	  weight of 1), split equally between the group's clients
+	//	* a group's max_connections limits how many servers its clients may hold at once
+	//
+	//	pool basic transaction {
+	//		pooler rob {
+	//			group_by user
+	//			group batch_job {
+	//				weight 0.1
+	//				max_connections 4
+	//			}
+	//		}
+	//	}
 	RegisterDirective(Pooler, "rob", func(d *caddyfile.Dispenser, _ *[]caddyconfig.Warning) (caddy.Module, error) {
-		return &rob.Factory{}, nil
+		module := rob.Factory{}
+
+		for nesting := d.Nesting(); d.NextBlock(nesting); {
+			directive := d.Val()
+			switch directive {
+			case "group_by":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+
+				switch groupBy := rob.GroupBy(d.Val()); groupBy {
+				case rob.GroupByUser, rob.GroupByApplicationName:
+					module.GroupBy = groupBy
+				default:
+					return nil, d.ArgErr()
+				}
+			case "group":
+				if !d.NextArg() {
+					return nil, d.ArgErr()
+				}
+				name := d.Val()
+
+				var group rob.Group
+				for groupNesting := d.Nesting(); d.NextBlock(groupNesting); {
+					switch d.Val() {
+					case "weight":
+						if !d.NextArg() {
+							return nil, d.ArgErr()
+						}
+
+						weight, err := strconv.ParseFloat(d.Val(), 64)
+						if err != nil {
+							return nil, d.WrapErr(err)
+						}
+
+						group.Weight = weight
+					case "max_connections":
+						if !d.NextArg() {
+							return nil, d.ArgErr()
+						}
+
+						maxConnections, err := strconv.Atoi(d.Val())
+						if err != nil {
+							return nil, d.WrapErr(err)
+						}
+
+						group.MaxConnections = maxConnections
+					default:
+						return nil, d.ArgErr()
+					}
+				}
+
+				if module.Groups == nil {
+					module.Groups = make(map[string]rob.Group)
+				}
+				module.Groups[name] = group
+			default:
+				return nil, d.ArgErr()
+			}
+		}
+
+		return &module, nil
 	})
 }
@@ -14,11 +14,13 @@ import (
 const (
 	Discoverer         = "pggat.handlers.discovery.discoverers"
 	DigitaloceanFilter = "pggat.handlers.discovery.discoverers.digitalocean.filters"
+	DiscoveryFilter    = "pggat.handlers.discovery.filters"
 	Handler            = "pggat.handlers"
 	Matcher            = "pggat.matchers"
 	Pool               = "pggat.handlers.pool.pools"
 	Pooler             = "pggat.handlers.pool.poolers"
 	Critic             = "pggat.handlers.pool.critics"
+	Balancer           = "pggat.handlers.pool.balancers"
 	SSLServer          = "pggat.ssl.servers"
 	SSLClient          = "pggat.ssl.clients"
 	Tracing            = "pggat.handlers.tracing"
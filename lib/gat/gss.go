@@ -0,0 +1,9 @@
+package gat
+
+// GSSConfig enables GSSAPI encryption of client connections with kerberos
+type GSSConfig struct {
+	// Keytab is the path of the keytab with the service keys
+	Keytab string `json:"keytab"`
+	// Principal is the service principal to accept tickets for. If empty, any principal in the keytab is accepted
+	Principal string `json:"principal,omitempty"`
+}
@@ -6,8 +6,11 @@ type User struct {
 }
 
 type Node struct {
-	Address  string
+	Address string
+	// Priority orders the nodes, lower is preferred
 	Priority int
+	// Weight is the node's share of new servers compared to nodes of the same priority. Defaults to 1
+	Weight int
 }
 
 type Cluster struct {
@@ -18,4 +21,6 @@ type Cluster struct {
 
 	Databases []string
 	Users     []User
+
+	Overrides Overrides
 }
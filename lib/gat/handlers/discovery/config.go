@@ -11,11 +11,23 @@ import (
 type Config struct {
 	// ReconcilePeriod is how often the module should check for changes. 0 = disable
 	ReconcilePeriod caddy.Duration `json:"reconcile_period"`
+	// Debounce is how long a cluster must stop changing before the change is applied. 0 = disable
+	Debounce caddy.Duration `json:"debounce,omitempty"`
+	// RemoveGracePeriod is how long a cluster must be gone before its pools are removed. 0 = disable
+	RemoveGracePeriod caddy.Duration `json:"remove_grace_period,omitempty"`
+	// DrainTimeout is how long removed pools wait for their clients to leave before being closed. 0 = close immediately
+	DrainTimeout caddy.Duration `json:"drain_timeout,omitempty"`
 
 	Discoverer json.RawMessage `json:"discoverer" caddy:"namespace=pggat.handlers.discovery.discoverers inline_key=discoverer"`
 
+	// Filters are applied in order to every cluster from the discoverer
+	Filters []json.RawMessage `json:"filters,omitempty" caddy:"namespace=pggat.handlers.discovery.filters inline_key=filter"`
+
 	Pool json.RawMessage `json:"pool" caddy:"namespace=pggat.handlers.pool.pools inline_key=pool"`
 
+	// PoolModes are pools which clusters can pick instead of Pool with the pool-mode override
+	PoolModes map[string]json.RawMessage `json:"pool_modes,omitempty" caddy:"namespace=pggat.handlers.pool.pools inline_key=pool"`
+
 	ServerSSLMode bouncer.SSLMode `json:"server_ssl_mode,omitempty"`
 	ServerSSL     json.RawMessage `json:"server_ssl,omitempty" caddy:"namespace=pggat.ssl.clients inline_key=provider"`
 
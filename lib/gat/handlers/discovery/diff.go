@@ -0,0 +1,137 @@
+package discovery
+
+import (
+	"reflect"
+	"slices"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// clusterDiff is what changed between two versions of a cluster
+type clusterDiff struct {
+	ID string
+
+	Primary bool
+
+	AddedReplicas   []string
+	RemovedReplicas []string
+	ChangedReplicas []string
+
+	AddedUsers   []string
+	RemovedUsers []string
+	// ChangedUsers had their password changed
+	ChangedUsers []string
+
+	AddedDatabases   []string
+	RemovedDatabases []string
+
+	Overrides bool
+}
+
+func diffCluster(prev, next Cluster) clusterDiff {
+	diff := clusterDiff{
+		ID:        next.ID,
+		Primary:   prev.Primary != next.Primary,
+		Overrides: !reflect.DeepEqual(prev.Overrides, next.Overrides),
+	}
+
+	for id, replica := range next.Replicas {
+		if prevReplica, ok := prev.Replicas[id]; !ok {
+			diff.AddedReplicas = append(diff.AddedReplicas, id)
+		} else if prevReplica != replica {
+			diff.ChangedReplicas = append(diff.ChangedReplicas, id)
+		}
+	}
+	for id := range prev.Replicas {
+		if _, ok := next.Replicas[id]; !ok {
+			diff.RemovedReplicas = append(diff.RemovedReplicas, id)
+		}
+	}
+	slices.Sort(diff.AddedReplicas)
+	slices.Sort(diff.RemovedReplicas)
+	slices.Sort(diff.ChangedReplicas)
+
+	for _, user := range next.Users {
+		i := slices.IndexFunc(prev.Users, func(u User) bool {
+			return u.Username == user.Username
+		})
+		if i == -1 {
+			diff.AddedUsers = append(diff.AddedUsers, user.Username)
+		} else if prev.Users[i].Password != user.Password {
+			diff.ChangedUsers = append(diff.ChangedUsers, user.Username)
+		}
+	}
+	for _, user := range prev.Users {
+		if !slices.ContainsFunc(next.Users, func(u User) bool {
+			return u.Username == user.Username
+		}) {
+			diff.RemovedUsers = append(diff.RemovedUsers, user.Username)
+		}
+	}
+
+	for _, database := range next.Databases {
+		if !slices.Contains(prev.Databases, database) {
+			diff.AddedDatabases = append(diff.AddedDatabases, database)
+		}
+	}
+	for _, database := range prev.Databases {
+		if !slices.Contains(next.Databases, database) {
+			diff.RemovedDatabases = append(diff.RemovedDatabases, database)
+		}
+	}
+
+	return diff
+}
+
+// Empty returns whether nothing changed
+func (T *clusterDiff) Empty() bool {
+	return !T.Primary && !T.Overrides &&
+		len(T.AddedReplicas) == 0 && len(T.RemovedReplicas) == 0 && len(T.ChangedReplicas) == 0 &&
+		len(T.AddedUsers) == 0 && len(T.RemovedUsers) == 0 && len(T.ChangedUsers) == 0 &&
+		len(T.AddedDatabases) == 0 && len(T.RemovedDatabases) == 0
+}
+
+func addStrings(enc zapcore.ObjectEncoder, key string, values []string) error {
+	if len(values) == 0 {
+		return nil
+	}
+	return enc.AddArray(key, zapcore.ArrayMarshalerFunc(func(enc zapcore.ArrayEncoder) error {
+		for _, value := range values {
+			enc.AppendString(value)
+		}
+		return nil
+	}))
+}
+
+func (T *clusterDiff) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("id", T.ID)
+	if T.Primary {
+		enc.AddBool("primary", true)
+	}
+	if T.Overrides {
+		enc.AddBool("overrides", true)
+	}
+
+	fields := []struct {
+		key    string
+		values []string
+	}{
+		{"added_replicas", T.AddedReplicas},
+		{"removed_replicas", T.RemovedReplicas},
+		{"changed_replicas", T.ChangedReplicas},
+		{"added_users", T.AddedUsers},
+		{"removed_users", T.RemovedUsers},
+		{"changed_users", T.ChangedUsers},
+		{"added_databases", T.AddedDatabases},
+		{"removed_databases", T.RemovedDatabases},
+	}
+	for _, field := range fields {
+		if err := addStrings(enc, field.key, field.values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+var _ zapcore.ObjectMarshaler = (*clusterDiff)(nil)
@@ -310,6 +310,15 @@ func (d *Discoverer) clusterToDiscoveryCluster(ctx context.Context, cluster cnpg
 	// Add postgres database (always exists)
 	discoveryCluster.Databases = append(discoveryCluster.Databases, "postgres")
 
+	overrides, err := discovery.ParseOverrides(discovery.OverridesPrefix, cluster.Annotations)
+	if err != nil {
+		d.log.Warn("invalid overrides",
+			zap.String("cluster", cluster.Name),
+			zap.String("namespace", namespace),
+			zap.Error(err))
+	}
+	discoveryCluster.Overrides = overrides
+
 	return discoveryCluster, nil
 }
 
@@ -0,0 +1,102 @@
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Node struct {
+	Node    string `json:"Node"`
+	Address string `json:"Address"`
+}
+
+type AgentService struct {
+	ID      string   `json:"ID"`
+	Service string   `json:"Service"`
+	Tags    []string `json:"Tags"`
+	Address string   `json:"Address"`
+	Port    int      `json:"Port"`
+}
+
+// ServiceEntry is an instance returned by the health API
+type ServiceEntry struct {
+	Node    Node         `json:"Node"`
+	Service AgentService `json:"Service"`
+}
+
+type KVPair struct {
+	Key   string `json:"Key"`
+	Value []byte `json:"Value"`
+}
+
+// get runs a blocking query. It returns once the index changes from index or wait passes, along with the new index.
+// An index of 0 returns immediately.
+func (T *Discoverer) get(ctx context.Context, path string, query url.Values, index uint64, out any) (uint64, error) {
+	if query == nil {
+		query = make(url.Values)
+	}
+	if T.Datacenter != "" {
+		query.Set("dc", T.Datacenter)
+	}
+	timeout := time.Duration(T.Timeout)
+	if index != 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", fmt.Sprintf("%ds", int(time.Duration(T.Wait).Seconds())))
+
+		// consul adds up to wait/16 of jitter to blocking queries
+		timeout += time.Duration(T.Wait) + time.Duration(T.Wait)/16
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(T.Address, "/")+path+"?"+query.Encode(), nil)
+	if err != nil {
+		return 0, err
+	}
+	if T.Token != "" {
+		req.Header.Set("X-Consul-Token", T.Token)
+	}
+
+	resp, err := T.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	next, _ := strconv.ParseUint(resp.Header.Get("X-Consul-Index"), 10, 64)
+	// the index can go backwards, like after a snapshot restore. it must also never be 0 or the query won't block
+	if next < index || next == 0 {
+		next = 1
+	}
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return next, json.NewDecoder(resp.Body).Decode(out)
+	case http.StatusNotFound:
+		// empty kv prefix
+		return next, nil
+	default:
+		return 0, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+}
+
+func (T *Discoverer) health(ctx context.Context, service string, index uint64) ([]ServiceEntry, uint64, error) {
+	var entries []ServiceEntry
+	index, err := T.get(ctx, "/v1/health/service/"+url.PathEscape(service), url.Values{"passing": {"true"}}, index, &entries)
+	return entries, index, err
+}
+
+func (T *Discoverer) kv(ctx context.Context, index uint64) ([]KVPair, uint64, error) {
+	var pairs []KVPair
+	index, err := T.get(ctx, "/v1/kv/"+strings.Trim(T.KVPrefix, "/"), url.Values{"recurse": {"true"}}, index, &pairs)
+	return pairs, index, err
+}
@@ -0,0 +1,28 @@
+package consul
+
+import "github.com/caddyserver/caddy/v2"
+
+type Config struct {
+	// Address is the Consul HTTP API. Defaults to http://127.0.0.1:8500
+	Address    string `json:"address,omitempty"`
+	Token      string `json:"token,omitempty"`
+	Datacenter string `json:"datacenter,omitempty"`
+
+	// Services are the Consul services to discover, one cluster per service
+	Services []string `json:"services"`
+
+	// PrimaryTag and ReplicaTag are the tags of the primary and replica instances of each service. Defaults to primary
+	// and replica
+	PrimaryTag string `json:"primary_tag,omitempty"`
+	ReplicaTag string `json:"replica_tag,omitempty"`
+
+	// KVPrefix is where the credentials of each service are stored. Databases are the keys under
+	// <prefix>/<service>/databases/, and users are the keys under <prefix>/<service>/users/ with their password as the
+	// value. Defaults to pggat
+	KVPrefix string `json:"kv_prefix,omitempty"`
+
+	// Wait is the max duration of each blocking query. Defaults to 5m
+	Wait caddy.Duration `json:"wait,omitempty"`
+	// Timeout is the timeout of each request to the HTTP API. Blocking queries get Wait on top of it. Defaults to 10s
+	Timeout caddy.Duration `json:"timeout,omitempty"`
+}
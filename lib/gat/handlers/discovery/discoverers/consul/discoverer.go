@@ -0,0 +1,317 @@
+package consul
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+// retryDelay is how long to wait after a failed query
+const retryDelay = 5 * time.Second
+
+func init() {
+	caddy.RegisterModule((*Discoverer)(nil))
+}
+
+type credentials struct {
+	databases []string
+	users     []discovery.User
+}
+
+// Discoverer watches the Consul health API for the instances of each service, and the KV store for their credentials
+type Discoverer struct {
+	Config
+
+	client http.Client
+
+	instances   map[string][]ServiceEntry
+	credentials map[string]credentials
+	// clusters are the clusters last emitted, by service
+	clusters map[string]discovery.Cluster
+	mu       sync.Mutex
+
+	added chan discovery.Cluster
+
+	cancel context.CancelFunc
+
+	log *zap.Logger
+}
+
+func (T *Discoverer) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.discovery.discoverers.consul",
+		New: func() caddy.Module {
+			return new(Discoverer)
+		},
+	}
+}
+
+func (T *Discoverer) Provision(ctx caddy.Context) error {
+	T.log = ctx.Logger().With(zap.String("discoverer", "consul"))
+
+	if T.Address == "" {
+		T.Address = "http://127.0.0.1:8500"
+	}
+	if T.PrimaryTag == "" {
+		T.PrimaryTag = "primary"
+	}
+	if T.ReplicaTag == "" {
+		T.ReplicaTag = "replica"
+	}
+	if T.KVPrefix == "" {
+		T.KVPrefix = "pggat"
+	}
+	if T.Wait == 0 {
+		T.Wait = caddy.Duration(5 * time.Minute)
+	}
+	if T.Timeout == 0 {
+		T.Timeout = caddy.Duration(10 * time.Second)
+	}
+	if len(T.Services) == 0 {
+		return errors.New("no services")
+	}
+
+	T.instances = make(map[string][]ServiceEntry, len(T.Services))
+	T.credentials = make(map[string]credentials, len(T.Services))
+	T.clusters = make(map[string]discovery.Cluster, len(T.Services))
+	T.added = make(chan discovery.Cluster, 200)
+
+	var watchCtx context.Context
+	watchCtx, T.cancel = context.WithCancel(context.Background())
+	for _, service := range T.Services {
+		go T.watchService(watchCtx, service)
+	}
+	go T.watchKV(watchCtx)
+
+	return nil
+}
+
+func (T *Discoverer) Cleanup() error {
+	if T.cancel != nil {
+		T.cancel()
+		T.cancel = nil
+	}
+	return nil
+}
+
+func (T *Discoverer) address(entry ServiceEntry) string {
+	host := entry.Service.Address
+	if host == "" {
+		host = entry.Node.Address
+	}
+	return net.JoinHostPort(host, strconv.Itoa(entry.Service.Port))
+}
+
+// cluster builds the cluster of a service. It returns false if the service doesn't have exactly one primary, like in
+// the middle of a failover. T.mu must be held
+func (T *Discoverer) cluster(service string) (discovery.Cluster, bool) {
+	var primary *ServiceEntry
+	replicas := make(map[string]discovery.Node)
+	for i, entry := range T.instances[service] {
+		switch {
+		case slices.Contains(entry.Service.Tags, T.PrimaryTag):
+			if primary != nil {
+				return discovery.Cluster{}, false
+			}
+			primary = &T.instances[service][i]
+		case slices.Contains(entry.Service.Tags, T.ReplicaTag):
+			replicas[entry.Service.ID] = discovery.Node{
+				Address: T.address(entry),
+			}
+		}
+	}
+	if primary == nil {
+		return discovery.Cluster{}, false
+	}
+
+	creds := T.credentials[service]
+	return discovery.Cluster{
+		ID: service,
+		Primary: discovery.Node{
+			Address: T.address(*primary),
+		},
+		Replicas:  replicas,
+		Databases: creds.databases,
+		Users:     creds.users,
+	}, true
+}
+
+// update rebuilds the clusters of services, and returns the ones which changed since they were last emitted. T.mu must
+// be held
+func (T *Discoverer) update(services ...string) []discovery.Cluster {
+	var changed []discovery.Cluster
+	for _, service := range services {
+		c, ok := T.cluster(service)
+		if !ok {
+			continue
+		}
+		if prev, ok := T.clusters[service]; ok && reflect.DeepEqual(prev, c) {
+			continue
+		}
+		T.clusters[service] = c
+		changed = append(changed, c)
+	}
+	return changed
+}
+
+func (T *Discoverer) emit(ctx context.Context, clusters []discovery.Cluster) {
+	for _, cluster := range clusters {
+		select {
+		case T.added <- cluster:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (T *Discoverer) setInstances(service string, entries []ServiceEntry) []discovery.Cluster {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	T.instances[service] = entries
+	return T.update(service)
+}
+
+func (T *Discoverer) setCredentials(pairs []KVPair) []discovery.Cluster {
+	prefix := strings.Trim(T.KVPrefix, "/") + "/"
+
+	creds := make(map[string]credentials, len(T.Services))
+	for _, pair := range pairs {
+		key, ok := strings.CutPrefix(pair.Key, prefix)
+		if !ok {
+			continue
+		}
+		parts := strings.Split(key, "/")
+		if len(parts) != 3 || parts[2] == "" {
+			continue
+		}
+
+		c := creds[parts[0]]
+		switch parts[1] {
+		case "databases":
+			c.databases = append(c.databases, parts[2])
+		case "users":
+			c.users = append(c.users, discovery.User{
+				Username: parts[2],
+				Password: string(pair.Value),
+			})
+		default:
+			continue
+		}
+		creds[parts[0]] = c
+	}
+	for _, c := range creds {
+		sort.Strings(c.databases)
+		sort.Slice(c.users, func(i, j int) bool {
+			return c.users[i].Username < c.users[j].Username
+		})
+	}
+
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	T.credentials = creds
+	return T.update(T.Services...)
+}
+
+func (T *Discoverer) sleep(ctx context.Context) {
+	select {
+	case <-ctx.Done():
+	case <-time.After(retryDelay):
+	}
+}
+
+func (T *Discoverer) watchService(ctx context.Context, service string) {
+	var index uint64
+	for ctx.Err() == nil {
+		entries, next, err := T.health(ctx, service, index)
+		if err != nil {
+			if ctx.Err() == nil {
+				T.log.Warn("failed to query service health", zap.String("service", service), zap.Error(err))
+				T.sleep(ctx)
+			}
+			continue
+		}
+		if next == index {
+			continue
+		}
+		index = next
+
+		T.emit(ctx, T.setInstances(service, entries))
+	}
+}
+
+func (T *Discoverer) watchKV(ctx context.Context) {
+	var index uint64
+	for ctx.Err() == nil {
+		pairs, next, err := T.kv(ctx, index)
+		if err != nil {
+			if ctx.Err() == nil {
+				T.log.Warn("failed to query kv", zap.Error(err))
+				T.sleep(ctx)
+			}
+			continue
+		}
+		if next == index {
+			continue
+		}
+		index = next
+
+		T.emit(ctx, T.setCredentials(pairs))
+	}
+}
+
+func (T *Discoverer) Clusters() ([]discovery.Cluster, error) {
+	ctx := context.Background()
+
+	pairs, _, err := T.kv(ctx, 0)
+	if err != nil {
+		return nil, fmt.Errorf("querying kv: %v", err)
+	}
+	T.setCredentials(pairs)
+
+	for _, service := range T.Services {
+		var entries []ServiceEntry
+		entries, _, err = T.health(ctx, service, 0)
+		if err != nil {
+			return nil, fmt.Errorf("querying service %s: %v", service, err)
+		}
+		T.setInstances(service, entries)
+	}
+
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	res := make([]discovery.Cluster, 0, len(T.clusters))
+	for _, cluster := range T.clusters {
+		res = append(res, cluster)
+	}
+	return res, nil
+}
+
+func (T *Discoverer) Added() <-chan discovery.Cluster {
+	return T.added
+}
+
+func (T *Discoverer) Removed() <-chan string {
+	return nil
+}
+
+var _ discovery.Discoverer = (*Discoverer)(nil)
+var _ caddy.Module = (*Discoverer)(nil)
+var _ caddy.Provisioner = (*Discoverer)(nil)
+var _ caddy.CleanerUpper = (*Discoverer)(nil)
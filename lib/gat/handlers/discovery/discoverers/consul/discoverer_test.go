@@ -0,0 +1,173 @@
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+// standIn is a Consul agent with one service which supports blocking queries
+type standIn struct {
+	index   uint64
+	entries []ServiceEntry
+	pairs   []KVPair
+	changed chan struct{}
+	mu      sync.Mutex
+}
+
+func (T *standIn) set(entries []ServiceEntry) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	T.index++
+	T.entries = entries
+	close(T.changed)
+	T.changed = make(chan struct{})
+}
+
+func (T *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	index, _ := strconv.ParseUint(r.URL.Query().Get("index"), 10, 64)
+
+	T.mu.Lock()
+	if index != 0 && index == T.index {
+		changed := T.changed
+		T.mu.Unlock()
+		select {
+		case <-changed:
+		case <-time.After(time.Second):
+		case <-r.Context().Done():
+			return
+		}
+		T.mu.Lock()
+	}
+	defer T.mu.Unlock()
+
+	w.Header().Set("X-Consul-Index", strconv.FormatUint(T.index, 10))
+	switch r.URL.Path {
+	case "/v1/health/service/main":
+		_ = json.NewEncoder(w).Encode(T.entries)
+	case "/v1/kv/pggat":
+		_ = json.NewEncoder(w).Encode(T.pairs)
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func entry(id, address string, tags ...string) ServiceEntry {
+	return ServiceEntry{
+		Node: Node{
+			Node:    id,
+			Address: address,
+		},
+		Service: AgentService{
+			ID:      id,
+			Service: "main",
+			Tags:    tags,
+			Port:    5432,
+		},
+	}
+}
+
+func TestDiscoverer(t *testing.T) {
+	consul := &standIn{
+		index: 1,
+		entries: []ServiceEntry{
+			entry("pg1", "10.0.0.1", "primary"),
+			entry("pg2", "10.0.0.2", "replica"),
+			entry("pg3", "10.0.0.3", "other"),
+		},
+		pairs: []KVPair{
+			{Key: "pggat/main/databases/app"},
+			{Key: "pggat/main/users/app", Value: []byte("secret")},
+			{Key: "pggat/main/users/admin", Value: []byte("hunter2")},
+			{Key: "pggat/other/users/app", Value: []byte("other")},
+		},
+		changed: make(chan struct{}),
+	}
+	server := httptest.NewServer(consul)
+	defer server.Close()
+
+	d := &Discoverer{
+		Config: Config{
+			Address:    server.URL,
+			Services:   []string{"main"},
+			PrimaryTag: "primary",
+			ReplicaTag: "replica",
+			KVPrefix:   "pggat",
+			Wait:       caddy.Duration(time.Second),
+			Timeout:    caddy.Duration(5 * time.Second),
+		},
+		instances:   make(map[string][]ServiceEntry),
+		credentials: make(map[string]credentials),
+		clusters:    make(map[string]discovery.Cluster),
+		added:       make(chan discovery.Cluster, 200),
+		log:         zap.NewNop(),
+	}
+
+	clusters, err := d.Clusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := discovery.Cluster{
+		ID: "main",
+		Primary: discovery.Node{
+			Address: "10.0.0.1:5432",
+		},
+		Replicas: map[string]discovery.Node{
+			"pg2": {Address: "10.0.0.2:5432"},
+		},
+		Databases: []string{"app"},
+		Users: []discovery.User{
+			{Username: "admin", Password: "hunter2"},
+			{Username: "app", Password: "secret"},
+		},
+	}
+	if len(clusters) != 1 || !reflect.DeepEqual(clusters[0], expected) {
+		t.Fatalf("expected %#v but got %#v", expected, clusters)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	go d.watchService(ctx, "main")
+	defer func() {
+		_ = d.Cleanup()
+	}()
+
+	// two primaries in the middle of the failover, the previous members are kept
+	consul.set([]ServiceEntry{
+		entry("pg1", "10.0.0.1", "primary"),
+		entry("pg2", "10.0.0.2", "primary"),
+	})
+	select {
+	case cluster := <-d.Added():
+		t.Fatalf("unexpected cluster %#v", cluster)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	consul.set([]ServiceEntry{
+		entry("pg1", "10.0.0.1", "replica"),
+		entry("pg2", "10.0.0.2", "primary"),
+	})
+	select {
+	case cluster := <-d.Added():
+		if cluster.Primary.Address != "10.0.0.2:5432" {
+			t.Fatalf("expected pg2 to be the primary but got %s", cluster.Primary.Address)
+		}
+		if !reflect.DeepEqual(cluster.Replicas, map[string]discovery.Node{"pg1": {Address: "10.0.0.1:5432"}}) {
+			t.Fatalf("unexpected replicas %#v", cluster.Replicas)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for failover")
+	}
+}
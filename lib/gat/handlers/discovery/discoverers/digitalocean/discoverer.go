@@ -8,6 +8,7 @@ import (
 
 	"github.com/caddyserver/caddy/v2"
 	"github.com/digitalocean/godo"
+	"go.uber.org/zap"
 
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
 )
@@ -22,6 +23,8 @@ type Discoverer struct {
 	filter Filter
 
 	do *godo.Client
+
+	log *zap.Logger
 }
 
 func (T *Discoverer) CaddyModule() caddy.ModuleInfo {
@@ -34,6 +37,8 @@ func (T *Discoverer) CaddyModule() caddy.ModuleInfo {
 }
 
 func (T *Discoverer) Provision(ctx caddy.Context) error {
+	T.log = ctx.Logger()
+
 	if T.Filter != nil {
 		val, err := ctx.LoadModule(T, "Filter")
 		if err != nil {
@@ -127,6 +132,11 @@ func (T *Discoverer) Clusters() ([]discovery.Cluster, error) {
 			})
 		}
 
+		c.Overrides, err = discovery.ParseOverrideTags(discovery.OverridesTagPrefix, cluster.Tags)
+		if err != nil {
+			T.log.Warn("invalid overrides", zap.String("cluster", cluster.ID), zap.Error(err))
+		}
+
 		replicas, _, err := T.do.Databases.ListReplicas(context.Background(), cluster.ID, nil)
 		if err != nil {
 			return nil, err
@@ -0,0 +1,40 @@
+package dns
+
+import "github.com/caddyserver/caddy/v2"
+
+type User struct {
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+}
+
+// Service is a cluster with replicas resolved from DNS
+type Service struct {
+	ID string `json:"id"`
+
+	// Primary is the address of the primary. It isn't resolved by the discoverer
+	Primary string `json:"primary"`
+
+	// Replicas is the name which is resolved into the replicas. By default, every A and AAAA record of the name is a
+	// replica on Port. If SRV is set, every SRV record of the name is a replica instead
+	Replicas string `json:"replicas"`
+	SRV      bool   `json:"srv,omitempty"`
+	// Port is the port of the replicas resolved from A and AAAA records. Defaults to 5432
+	Port int `json:"port,omitempty"`
+
+	Databases []string `json:"databases"`
+	Users     []User   `json:"users"`
+}
+
+type Config struct {
+	Services []Service `json:"services"`
+
+	// Nameservers are the servers to query, like 10.0.0.1:53. They are tried in order until one responds. Defaults to
+	// the nameservers in /etc/resolv.conf
+	Nameservers []string `json:"nameservers,omitempty"`
+
+	// MinTTL and MaxTTL bound how long records are used before they are resolved again. Default to 1s and 15s
+	MinTTL caddy.Duration `json:"min_ttl,omitempty"`
+	MaxTTL caddy.Duration `json:"max_ttl,omitempty"`
+	// NXDomainTTL is how long to wait before resolving a name which doesn't exist again. Defaults to 15s
+	NXDomainTTL caddy.Duration `json:"nxdomain_ttl,omitempty"`
+}
@@ -0,0 +1,211 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+func init() {
+	caddy.RegisterModule((*Discoverer)(nil))
+}
+
+// Discoverer resolves the replicas of each service from DNS, and resolves them again whenever their records expire
+type Discoverer struct {
+	Config
+
+	client dns.Client
+
+	// replicas are the replicas last resolved, by service
+	replicas map[string]map[string]discovery.Node
+	// clusters are the clusters last emitted, by service
+	clusters map[string]discovery.Cluster
+	mu       sync.Mutex
+
+	added chan discovery.Cluster
+
+	cancel context.CancelFunc
+
+	log *zap.Logger
+}
+
+func (T *Discoverer) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.discovery.discoverers.dns",
+		New: func() caddy.Module {
+			return new(Discoverer)
+		},
+	}
+}
+
+func (T *Discoverer) Provision(ctx caddy.Context) error {
+	T.log = ctx.Logger().With(zap.String("discoverer", "dns"))
+
+	if len(T.Nameservers) == 0 {
+		conf, err := dns.ClientConfigFromFile("/etc/resolv.conf")
+		if err != nil {
+			return fmt.Errorf("reading nameservers: %v", err)
+		}
+		for _, server := range conf.Servers {
+			T.Nameservers = append(T.Nameservers, net.JoinHostPort(server, conf.Port))
+		}
+	}
+	for i, nameserver := range T.Nameservers {
+		if _, _, err := net.SplitHostPort(nameserver); err != nil {
+			T.Nameservers[i] = net.JoinHostPort(nameserver, "53")
+		}
+	}
+	if T.MinTTL == 0 {
+		T.MinTTL = caddy.Duration(time.Second)
+	}
+	if T.MaxTTL == 0 {
+		T.MaxTTL = caddy.Duration(15 * time.Second)
+	}
+	if T.NXDomainTTL == 0 {
+		T.NXDomainTTL = caddy.Duration(15 * time.Second)
+	}
+
+	ids := make(map[string]struct{}, len(T.Services))
+	for i := range T.Services {
+		service := &T.Services[i]
+		if service.ID == "" {
+			return errors.New("service missing id")
+		}
+		if _, ok := ids[service.ID]; ok {
+			return fmt.Errorf("duplicate service %s", service.ID)
+		}
+		ids[service.ID] = struct{}{}
+		if service.Primary == "" || service.Replicas == "" {
+			return fmt.Errorf("service %s: missing primary or replicas", service.ID)
+		}
+		if service.Port == 0 {
+			service.Port = 5432
+		}
+	}
+
+	T.client.Timeout = 5 * time.Second
+	T.replicas = make(map[string]map[string]discovery.Node, len(T.Services))
+	T.clusters = make(map[string]discovery.Cluster, len(T.Services))
+	T.added = make(chan discovery.Cluster, 200)
+
+	var watchCtx context.Context
+	watchCtx, T.cancel = context.WithCancel(context.Background())
+	for i := range T.Services {
+		go T.watch(watchCtx, &T.Services[i])
+	}
+
+	return nil
+}
+
+func (T *Discoverer) Cleanup() error {
+	if T.cancel != nil {
+		T.cancel()
+		T.cancel = nil
+	}
+	return nil
+}
+
+// refresh resolves the replicas of the service. It returns the cluster if it changed since it was last emitted, and
+// how long until the replicas should be resolved again
+func (T *Discoverer) refresh(service *Service) (*discovery.Cluster, time.Duration, error) {
+	replicas, ttl, err := T.resolve(service)
+
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	if err != nil {
+		// keep the previous replicas
+		if _, ok := T.clusters[service.ID]; ok {
+			return nil, time.Duration(T.MaxTTL), err
+		}
+		replicas = nil
+		ttl = time.Duration(T.MaxTTL)
+	}
+	T.replicas[service.ID] = replicas
+
+	c := discovery.Cluster{
+		ID: service.ID,
+		Primary: discovery.Node{
+			Address: service.Primary,
+		},
+		Replicas:  T.replicas[service.ID],
+		Databases: service.Databases,
+		Users:     make([]discovery.User, 0, len(service.Users)),
+	}
+	for _, user := range service.Users {
+		c.Users = append(c.Users, discovery.User{
+			Username: user.Username,
+			Password: user.Password,
+		})
+	}
+
+	if prev, ok := T.clusters[service.ID]; ok && reflect.DeepEqual(prev, c) {
+		return nil, ttl, err
+	}
+	T.clusters[service.ID] = c
+	return &c, ttl, err
+}
+
+func (T *Discoverer) watch(ctx context.Context, service *Service) {
+	for {
+		c, ttl, err := T.refresh(service)
+		if err != nil {
+			T.log.Warn("failed to resolve replicas", zap.String("service", service.ID), zap.Error(err))
+		}
+		if c != nil {
+			select {
+			case T.added <- *c:
+			case <-ctx.Done():
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(ttl):
+		}
+	}
+}
+
+func (T *Discoverer) Clusters() ([]discovery.Cluster, error) {
+	// services which fail to resolve keep their previous replicas, so they are only logged
+	for i := range T.Services {
+		service := &T.Services[i]
+		if _, _, err := T.refresh(service); err != nil {
+			T.log.Warn("failed to resolve replicas", zap.String("service", service.ID), zap.Error(err))
+		}
+	}
+
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	res := make([]discovery.Cluster, 0, len(T.clusters))
+	for _, cluster := range T.clusters {
+		res = append(res, cluster)
+	}
+	return res, nil
+}
+
+func (T *Discoverer) Added() <-chan discovery.Cluster {
+	return T.added
+}
+
+func (T *Discoverer) Removed() <-chan string {
+	return nil
+}
+
+var _ discovery.Discoverer = (*Discoverer)(nil)
+var _ caddy.Module = (*Discoverer)(nil)
+var _ caddy.Provisioner = (*Discoverer)(nil)
+var _ caddy.CleanerUpper = (*Discoverer)(nil)
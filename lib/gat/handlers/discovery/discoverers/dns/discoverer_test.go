@@ -0,0 +1,200 @@
+package dns
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/miekg/dns"
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+// zone is an in process nameserver which answers from a set of records
+type zone struct {
+	records []string
+	mu      sync.Mutex
+}
+
+func (T *zone) set(records ...string) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+	T.records = records
+}
+
+func (T *zone) ServeDNS(w dns.ResponseWriter, r *dns.Msg) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	resp := new(dns.Msg)
+	resp.SetReply(r)
+
+	q := r.Question[0]
+	var exists bool
+	for _, record := range T.records {
+		rr, err := dns.NewRR(record)
+		if err != nil {
+			panic(err)
+		}
+		if rr.Header().Name != q.Name {
+			continue
+		}
+		exists = true
+		if rr.Header().Rrtype == q.Qtype {
+			resp.Answer = append(resp.Answer, rr)
+		}
+	}
+	if !exists {
+		resp.Rcode = dns.RcodeNameError
+	}
+
+	_ = w.WriteMsg(resp)
+}
+
+func listen(t *testing.T, handler dns.Handler) string {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Skip("unable to listen:", err)
+	}
+
+	started := make(chan struct{})
+	server := &dns.Server{
+		PacketConn:        conn,
+		Handler:           handler,
+		NotifyStartedFunc: func() { close(started) },
+	}
+	go func() {
+		_ = server.ActivateAndServe()
+	}()
+	t.Cleanup(func() {
+		_ = server.Shutdown()
+	})
+	<-started
+
+	return conn.LocalAddr().String()
+}
+
+func newDiscoverer(nameserver string, services ...Service) *Discoverer {
+	d := &Discoverer{
+		Config: Config{
+			Services:    services,
+			Nameservers: []string{nameserver},
+			MinTTL:      caddy.Duration(10 * time.Millisecond),
+			MaxTTL:      caddy.Duration(time.Second),
+			NXDomainTTL: caddy.Duration(10 * time.Millisecond),
+		},
+		replicas: make(map[string]map[string]discovery.Node),
+		clusters: make(map[string]discovery.Cluster),
+		added:    make(chan discovery.Cluster, 200),
+		log:      zap.NewNop(),
+	}
+	d.client.Timeout = time.Second
+	return d
+}
+
+func TestDiscovererA(t *testing.T) {
+	records := &zone{}
+	records.set(
+		"ro.example.com. 0 IN A 10.0.0.2",
+		"ro.example.com. 0 IN A 10.0.0.3",
+		"ro.example.com. 0 IN AAAA ::4",
+	)
+	nameserver := listen(t, records)
+
+	d := newDiscoverer(nameserver, Service{
+		ID:        "main",
+		Primary:   "rw.example.com:5432",
+		Replicas:  "ro.example.com",
+		Port:      5433,
+		Databases: []string{"app"},
+		Users:     []User{{Username: "app", Password: "secret"}},
+	})
+
+	clusters, err := d.Clusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := discovery.Cluster{
+		ID: "main",
+		Primary: discovery.Node{
+			Address: "rw.example.com:5432",
+		},
+		Replicas: map[string]discovery.Node{
+			"10.0.0.2": {Address: "10.0.0.2:5433"},
+			"10.0.0.3": {Address: "10.0.0.3:5433"},
+			"::4":      {Address: "[::4]:5433"},
+		},
+		Databases: []string{"app"},
+		Users:     []discovery.User{{Username: "app", Password: "secret"}},
+	}
+	if len(clusters) != 1 || !reflect.DeepEqual(clusters[0], expected) {
+		t.Fatalf("expected %#v but got %#v", expected, clusters)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	go d.watch(ctx, &d.Services[0])
+	defer func() {
+		_ = d.Cleanup()
+	}()
+
+	// the records expire immediately, so the change is picked up after MinTTL
+	records.set("ro.example.com. 0 IN A 10.0.0.3")
+	select {
+	case cluster := <-d.Added():
+		if !reflect.DeepEqual(cluster.Replicas, map[string]discovery.Node{"10.0.0.3": {Address: "10.0.0.3:5433"}}) {
+			t.Fatalf("unexpected replicas %#v", cluster.Replicas)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for records to change")
+	}
+
+	// every replica is removed if the name no longer exists
+	records.set()
+	select {
+	case cluster := <-d.Added():
+		if len(cluster.Replicas) != 0 {
+			t.Fatalf("unexpected replicas %#v", cluster.Replicas)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for records to be removed")
+	}
+}
+
+func TestDiscovererSRV(t *testing.T) {
+	records := &zone{}
+	records.set(
+		"_postgresql._tcp.example.com. 30 IN SRV 0 10 5432 pg2.example.com.",
+		"_postgresql._tcp.example.com. 60 IN SRV 1 20 5433 pg3.example.com.",
+	)
+	nameserver := listen(t, records)
+
+	d := newDiscoverer(nameserver, Service{
+		ID:       "main",
+		Primary:  "pg1.example.com:5432",
+		Replicas: "_postgresql._tcp.example.com",
+		SRV:      true,
+		Port:     5432,
+	})
+
+	replicas, ttl, err := d.resolve(&d.Services[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]discovery.Node{
+		"pg2.example.com:5432": {Address: "pg2.example.com:5432", Weight: 10},
+		"pg3.example.com:5433": {Address: "pg3.example.com:5433", Priority: 1, Weight: 20},
+	}
+	if !reflect.DeepEqual(replicas, expected) {
+		t.Fatalf("expected %#v but got %#v", expected, replicas)
+	}
+	// capped by MaxTTL
+	if ttl != time.Second {
+		t.Fatalf("expected ttl of 1s but got %s", ttl)
+	}
+}
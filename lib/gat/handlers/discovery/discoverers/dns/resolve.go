@@ -0,0 +1,127 @@
+package dns
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/miekg/dns"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+// exchange sends the query to each nameserver in order until one responds. Truncated responses are retried over TCP
+func (T *Discoverer) exchange(msg *dns.Msg) (*dns.Msg, error) {
+	var errs []error
+	for _, nameserver := range T.Nameservers {
+		resp, _, err := T.client.Exchange(msg, nameserver)
+		if err == nil && resp.Truncated {
+			tcp := T.client
+			tcp.Net = "tcp"
+			resp, _, err = tcp.Exchange(msg, nameserver)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", nameserver, err))
+			continue
+		}
+
+		switch resp.Rcode {
+		case dns.RcodeSuccess, dns.RcodeNameError:
+			return resp, nil
+		default:
+			errs = append(errs, fmt.Errorf("%s: %s", nameserver, dns.RcodeToString[resp.Rcode]))
+		}
+	}
+
+	return nil, errors.Join(errs...)
+}
+
+// lookup returns the records of name with type qtype, and the min ttl of the records. If there are no records, the ttl
+// is 0
+func (T *Discoverer) lookup(name string, qtype uint16) ([]dns.RR, time.Duration, error) {
+	msg := new(dns.Msg)
+	msg.SetQuestion(dns.Fqdn(name), qtype)
+
+	resp, err := T.exchange(msg)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var records []dns.RR
+	var ttl time.Duration
+	for _, rr := range resp.Answer {
+		if rr.Header().Rrtype != qtype {
+			// CNAMEs on the way
+			continue
+		}
+		records = append(records, rr)
+
+		rrTTL := time.Duration(rr.Header().Ttl) * time.Second
+		if ttl == 0 || rrTTL < ttl {
+			ttl = rrTTL
+		}
+	}
+	return records, ttl, nil
+}
+
+// resolve returns the replicas of the service, and how long until they should be resolved again
+func (T *Discoverer) resolve(service *Service) (map[string]discovery.Node, time.Duration, error) {
+	replicas := make(map[string]discovery.Node)
+	var ttl time.Duration
+
+	add := func(records []dns.RR, recordsTTL time.Duration) {
+		for _, rr := range records {
+			var id string
+			var node discovery.Node
+			switch record := rr.(type) {
+			case *dns.SRV:
+				id = net.JoinHostPort(strings.TrimSuffix(record.Target, "."), strconv.Itoa(int(record.Port)))
+				// lower priorities are preferred, and targets share traffic within a priority by weight
+				node = discovery.Node{
+					Address:  id,
+					Priority: int(record.Priority),
+					Weight:   int(record.Weight),
+				}
+			case *dns.A:
+				id = record.A.String()
+				node.Address = net.JoinHostPort(id, strconv.Itoa(service.Port))
+			case *dns.AAAA:
+				id = record.AAAA.String()
+				node.Address = net.JoinHostPort(id, strconv.Itoa(service.Port))
+			default:
+				continue
+			}
+			replicas[id] = node
+		}
+		if recordsTTL != 0 && (ttl == 0 || recordsTTL < ttl) {
+			ttl = recordsTTL
+		}
+	}
+
+	if service.SRV {
+		records, recordsTTL, err := T.lookup(service.Replicas, dns.TypeSRV)
+		if err != nil {
+			return nil, 0, err
+		}
+		add(records, recordsTTL)
+	} else {
+		for _, qtype := range []uint16{dns.TypeA, dns.TypeAAAA} {
+			records, recordsTTL, err := T.lookup(service.Replicas, qtype)
+			if err != nil {
+				return nil, 0, err
+			}
+			add(records, recordsTTL)
+		}
+	}
+
+	if len(replicas) == 0 {
+		return replicas, time.Duration(T.NXDomainTTL), nil
+	}
+
+	ttl = max(ttl, time.Duration(T.MinTTL))
+	ttl = min(ttl, time.Duration(T.MaxTTL))
+	return replicas, ttl, nil
+}
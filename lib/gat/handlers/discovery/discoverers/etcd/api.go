@@ -0,0 +1,246 @@
+package etcd
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// these are the JSON gateway versions of the etcd v3 grpc messages. int64s are encoded as strings and bytes as base64
+
+type ResponseHeader struct {
+	Revision int64 `json:"revision,string"`
+}
+
+type KeyValue struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+type RangeRequest struct {
+	Key      []byte `json:"key"`
+	RangeEnd []byte `json:"range_end"`
+}
+
+type RangeResponse struct {
+	Header ResponseHeader `json:"header"`
+	Kvs    []KeyValue     `json:"kvs"`
+}
+
+type WatchCreateRequest struct {
+	Key           []byte `json:"key"`
+	RangeEnd      []byte `json:"range_end"`
+	StartRevision int64  `json:"start_revision,string"`
+}
+
+type WatchRequest struct {
+	CreateRequest WatchCreateRequest `json:"create_request"`
+}
+
+type WatchResponse struct {
+	Result struct {
+		Header          ResponseHeader    `json:"header"`
+		Created         bool              `json:"created,omitempty"`
+		Canceled        bool              `json:"canceled,omitempty"`
+		CompactRevision int64             `json:"compact_revision,string,omitempty"`
+		Events          []json.RawMessage `json:"events,omitempty"`
+	} `json:"result"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error,omitempty"`
+}
+
+type AuthenticateRequest struct {
+	Name     string `json:"name"`
+	Password string `json:"password"`
+}
+
+type AuthenticateResponse struct {
+	Token string `json:"token"`
+}
+
+// rangeEnd returns the end of the range of keys starting with prefix
+func rangeEnd(prefix []byte) []byte {
+	end := bytes.Clone(prefix)
+	for i := len(end) - 1; i >= 0; i-- {
+		if end[i] < 0xff {
+			end[i]++
+			return end[:i+1]
+		}
+	}
+	// every key
+	return []byte{0}
+}
+
+const authenticatePath = "/v3/auth/authenticate"
+
+func (T *Discoverer) do(ctx context.Context, endpoint, path string, body []byte, token string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(endpoint, "/")+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if token != "" {
+		req.Header.Set("Authorization", token)
+	}
+
+	return T.client.Do(req)
+}
+
+func (T *Discoverer) post(ctx context.Context, endpoint, path string, request any) (*http.Response, error) {
+	body, err := json.Marshal(request)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := T.Username != "" && path != authenticatePath
+
+	var token string
+	if auth {
+		token, err = T.token(ctx, endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("authenticating: %w", err)
+		}
+	}
+
+	resp, err := T.do(ctx, endpoint, path, body, token)
+	if err != nil {
+		return nil, err
+	}
+	if auth && resp.StatusCode == http.StatusUnauthorized {
+		// the token expired, get a new one and try again
+		_ = resp.Body.Close()
+		T.forgetToken(endpoint, token)
+
+		token, err = T.token(ctx, endpoint)
+		if err != nil {
+			return nil, fmt.Errorf("authenticating: %w", err)
+		}
+		resp, err = T.do(ctx, endpoint, path, body, token)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("unexpected status %s: %s", resp.Status, bytes.TrimSpace(msg))
+	}
+	return resp, nil
+}
+
+// call makes a unary request, which unlike a watch must finish within the timeout
+func (T *Discoverer) call(ctx context.Context, endpoint, path string, request, response any) error {
+	ctx, cancel := context.WithTimeout(ctx, time.Duration(T.Timeout))
+	defer cancel()
+
+	resp, err := T.post(ctx, endpoint, path, request)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	return json.NewDecoder(resp.Body).Decode(response)
+}
+
+// token returns the cached token for the endpoint, authenticating if there isn't one
+func (T *Discoverer) token(ctx context.Context, endpoint string) (string, error) {
+	T.tokensMu.Lock()
+	token, ok := T.tokens[endpoint]
+	T.tokensMu.Unlock()
+	if ok {
+		return token, nil
+	}
+
+	var resp AuthenticateResponse
+	if err := T.call(ctx, endpoint, authenticatePath, AuthenticateRequest{
+		Name:     T.Username,
+		Password: T.Password,
+	}, &resp); err != nil {
+		return "", err
+	}
+
+	T.tokensMu.Lock()
+	defer T.tokensMu.Unlock()
+	if T.tokens == nil {
+		T.tokens = make(map[string]string)
+	}
+	T.tokens[endpoint] = resp.Token
+	return resp.Token, nil
+}
+
+// forgetToken drops the cached token for the endpoint if it is still token
+func (T *Discoverer) forgetToken(endpoint, token string) {
+	T.tokensMu.Lock()
+	defer T.tokensMu.Unlock()
+	if T.tokens[endpoint] == token {
+		delete(T.tokens, endpoint)
+	}
+}
+
+// rangePrefix returns every key under the prefix from the first endpoint that responds, and the endpoint
+func (T *Discoverer) rangePrefix(ctx context.Context) (RangeResponse, string, error) {
+	prefix := []byte(T.Prefix)
+
+	var errs []error
+	for _, endpoint := range T.Endpoints {
+		var resp RangeResponse
+		err := T.call(ctx, endpoint, "/v3/kv/range", RangeRequest{
+			Key:      prefix,
+			RangeEnd: rangeEnd(prefix),
+		}, &resp)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", endpoint, err))
+			continue
+		}
+		return resp, endpoint, nil
+	}
+
+	return RangeResponse{}, "", errors.Join(errs...)
+}
+
+// watchPrefix calls fn after each batch of changes under the prefix since revision, until the watch fails
+func (T *Discoverer) watchPrefix(ctx context.Context, endpoint string, revision int64, fn func()) error {
+	prefix := []byte(T.Prefix)
+
+	resp, err := T.post(ctx, endpoint, "/v3/watch", WatchRequest{
+		CreateRequest: WatchCreateRequest{
+			Key:           prefix,
+			RangeEnd:      rangeEnd(prefix),
+			StartRevision: revision,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	dec := json.NewDecoder(bufio.NewReader(resp.Body))
+	for {
+		var msg WatchResponse
+		if err = dec.Decode(&msg); err != nil {
+			return err
+		}
+
+		if msg.Error != nil {
+			return errors.New(msg.Error.Message)
+		}
+		if msg.Result.Canceled || msg.Result.CompactRevision != 0 {
+			return errors.New("watch canceled")
+		}
+		if len(msg.Result.Events) > 0 {
+			fn()
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package etcd
+
+import "github.com/caddyserver/caddy/v2"
+
+type Config struct {
+	// Endpoints are the etcd v3 HTTP endpoints, like http://127.0.0.1:2379. They are tried in order until one responds
+	Endpoints []string `json:"endpoints,omitempty"`
+
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+
+	// Prefix is where the clusters are stored. Each cluster is stored under <prefix><id>/ as
+	//
+	//	primary              the address of the primary
+	//	replicas/<name>      the address of each replica
+	//	databases/<name>     each database, the value is ignored
+	//	users/<name>         the password of each user
+	//
+	// Defaults to /pggat/
+	Prefix string `json:"prefix,omitempty"`
+
+	// Timeout is the timeout of each request other than watches. Defaults to 10s
+	Timeout caddy.Duration `json:"timeout,omitempty"`
+}
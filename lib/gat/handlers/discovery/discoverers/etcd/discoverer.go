@@ -0,0 +1,261 @@
+package etcd
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+// retryDelay is how long to wait after a failed range or watch
+const retryDelay = 5 * time.Second
+
+func init() {
+	caddy.RegisterModule((*Discoverer)(nil))
+}
+
+// Discoverer watches a prefix in etcd for clusters
+type Discoverer struct {
+	Config
+
+	client http.Client
+
+	// tokens are the auth tokens of each endpoint
+	tokens   map[string]string
+	tokensMu sync.Mutex
+
+	// clusters are the clusters last emitted, by id
+	clusters map[string]discovery.Cluster
+	mu       sync.Mutex
+
+	added   chan discovery.Cluster
+	removed chan string
+
+	cancel context.CancelFunc
+
+	log *zap.Logger
+}
+
+func (T *Discoverer) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.discovery.discoverers.etcd",
+		New: func() caddy.Module {
+			return new(Discoverer)
+		},
+	}
+}
+
+func (T *Discoverer) Provision(ctx caddy.Context) error {
+	T.log = ctx.Logger().With(zap.String("discoverer", "etcd"))
+
+	if len(T.Endpoints) == 0 {
+		T.Endpoints = []string{"http://127.0.0.1:2379"}
+	}
+	if T.Prefix == "" {
+		T.Prefix = "/pggat/"
+	}
+	if !strings.HasSuffix(T.Prefix, "/") {
+		T.Prefix += "/"
+	}
+	if T.Timeout == 0 {
+		T.Timeout = caddy.Duration(10 * time.Second)
+	}
+
+	T.clusters = make(map[string]discovery.Cluster)
+	T.added = make(chan discovery.Cluster, 200)
+	T.removed = make(chan string, 200)
+
+	var watchCtx context.Context
+	watchCtx, T.cancel = context.WithCancel(context.Background())
+	go T.watch(watchCtx)
+
+	return nil
+}
+
+func (T *Discoverer) Cleanup() error {
+	if T.cancel != nil {
+		T.cancel()
+		T.cancel = nil
+	}
+	return nil
+}
+
+// parse builds the clusters from the keys under the prefix. Clusters without a primary are returned in pending, so they
+// aren't removed in the middle of a failover
+func (T *Discoverer) parse(kvs []KeyValue) (clusters map[string]discovery.Cluster, pending map[string]struct{}) {
+	clusters = make(map[string]discovery.Cluster)
+	pending = make(map[string]struct{})
+
+	for _, kv := range kvs {
+		key, ok := strings.CutPrefix(string(kv.Key), T.Prefix)
+		if !ok {
+			continue
+		}
+		parts := strings.Split(key, "/")
+		if parts[0] == "" {
+			continue
+		}
+
+		c, ok := clusters[parts[0]]
+		if !ok {
+			c = discovery.Cluster{
+				ID:       parts[0],
+				Replicas: make(map[string]discovery.Node),
+			}
+		}
+
+		switch {
+		case len(parts) == 2 && parts[1] == "primary":
+			c.Primary.Address = string(kv.Value)
+		case len(parts) == 3 && parts[1] == "replicas" && parts[2] != "":
+			c.Replicas[parts[2]] = discovery.Node{
+				Address: string(kv.Value),
+			}
+		case len(parts) == 3 && parts[1] == "databases" && parts[2] != "":
+			c.Databases = append(c.Databases, parts[2])
+		case len(parts) == 3 && parts[1] == "users" && parts[2] != "":
+			c.Users = append(c.Users, discovery.User{
+				Username: parts[2],
+				Password: string(kv.Value),
+			})
+		default:
+			continue
+		}
+
+		clusters[parts[0]] = c
+	}
+
+	for id, c := range clusters {
+		if c.Primary.Address == "" {
+			delete(clusters, id)
+			pending[id] = struct{}{}
+			continue
+		}
+
+		// etcd returns keys in order, but keep them sorted in case that changes
+		sort.Strings(c.Databases)
+		sort.Slice(c.Users, func(i, j int) bool {
+			return c.Users[i].Username < c.Users[j].Username
+		})
+	}
+
+	return
+}
+
+// update replaces the clusters with the ones in kvs, and returns the ones which were added or changed and the ids of
+// the ones which were removed
+func (T *Discoverer) update(kvs []KeyValue) (changed []discovery.Cluster, removed []string) {
+	clusters, pending := T.parse(kvs)
+
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	for id, c := range clusters {
+		if prev, ok := T.clusters[id]; ok && reflect.DeepEqual(prev, c) {
+			continue
+		}
+		T.clusters[id] = c
+		changed = append(changed, c)
+	}
+
+	for id := range T.clusters {
+		if _, ok := clusters[id]; ok {
+			continue
+		}
+		if _, ok := pending[id]; ok {
+			continue
+		}
+		delete(T.clusters, id)
+		removed = append(removed, id)
+	}
+
+	return
+}
+
+func (T *Discoverer) emit(ctx context.Context, changed []discovery.Cluster, removed []string) {
+	for _, cluster := range changed {
+		select {
+		case T.added <- cluster:
+		case <-ctx.Done():
+			return
+		}
+	}
+	for _, id := range removed {
+		select {
+		case T.removed <- id:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (T *Discoverer) refresh(ctx context.Context) (revision int64, endpoint string, err error) {
+	resp, endpoint, err := T.rangePrefix(ctx)
+	if err != nil {
+		return 0, "", err
+	}
+
+	changed, removed := T.update(resp.Kvs)
+	T.emit(ctx, changed, removed)
+	return resp.Header.Revision, endpoint, nil
+}
+
+func (T *Discoverer) watch(ctx context.Context) {
+	for ctx.Err() == nil {
+		revision, endpoint, err := T.refresh(ctx)
+		if err == nil {
+			err = T.watchPrefix(ctx, endpoint, revision+1, func() {
+				if _, _, err := T.refresh(ctx); err != nil && ctx.Err() == nil {
+					T.log.Warn("failed to refresh clusters", zap.Error(err))
+				}
+			})
+		}
+		if ctx.Err() != nil {
+			return
+		}
+
+		T.log.Warn("failed to watch clusters", zap.Error(err))
+		select {
+		case <-ctx.Done():
+		case <-time.After(retryDelay):
+		}
+	}
+}
+
+func (T *Discoverer) Clusters() ([]discovery.Cluster, error) {
+	resp, _, err := T.rangePrefix(context.Background())
+	if err != nil {
+		return nil, err
+	}
+	T.update(resp.Kvs)
+
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	res := make([]discovery.Cluster, 0, len(T.clusters))
+	for _, cluster := range T.clusters {
+		res = append(res, cluster)
+	}
+	return res, nil
+}
+
+func (T *Discoverer) Added() <-chan discovery.Cluster {
+	return T.added
+}
+
+func (T *Discoverer) Removed() <-chan string {
+	return T.removed
+}
+
+var _ discovery.Discoverer = (*Discoverer)(nil)
+var _ caddy.Module = (*Discoverer)(nil)
+var _ caddy.Provisioner = (*Discoverer)(nil)
+var _ caddy.CleanerUpper = (*Discoverer)(nil)
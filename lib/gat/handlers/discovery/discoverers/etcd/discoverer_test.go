@@ -0,0 +1,277 @@
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+// standIn is an etcd JSON gateway which supports range and watch over every key
+type standIn struct {
+	revision int64
+	kvs      map[string]string
+	changed  chan struct{}
+
+	// auth requires a token. issued is the number of tokens issued, only the last one is valid
+	auth   bool
+	issued int
+
+	mu sync.Mutex
+}
+
+// expire invalidates the current token
+func (T *standIn) expire() {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	T.issued++
+}
+
+func (T *standIn) put(key, value string) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	T.revision++
+	T.kvs[key] = value
+	close(T.changed)
+	T.changed = make(chan struct{})
+}
+
+func (T *standIn) delete(key string) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	T.revision++
+	delete(T.kvs, key)
+	close(T.changed)
+	T.changed = make(chan struct{})
+}
+
+func (T *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if T.auth {
+		T.mu.Lock()
+		if r.URL.Path == "/v3/auth/authenticate" {
+			T.issued++
+			token := strconv.Itoa(T.issued)
+			T.mu.Unlock()
+			_ = json.NewEncoder(w).Encode(AuthenticateResponse{Token: token})
+			return
+		}
+		valid := r.Header.Get("Authorization") == strconv.Itoa(T.issued)
+		T.mu.Unlock()
+		if !valid {
+			http.Error(w, "invalid auth token", http.StatusUnauthorized)
+			return
+		}
+	}
+
+	switch r.URL.Path {
+	case "/v3/kv/range":
+		T.mu.Lock()
+		defer T.mu.Unlock()
+
+		var resp RangeResponse
+		resp.Header.Revision = T.revision
+		for key, value := range T.kvs {
+			resp.Kvs = append(resp.Kvs, KeyValue{Key: []byte(key), Value: []byte(value)})
+		}
+		sort.Slice(resp.Kvs, func(i, j int) bool {
+			return string(resp.Kvs[i].Key) < string(resp.Kvs[j].Key)
+		})
+		_ = json.NewEncoder(w).Encode(resp)
+	case "/v3/watch":
+		flusher := w.(http.Flusher)
+		enc := json.NewEncoder(w)
+
+		var created WatchResponse
+		created.Result.Created = true
+		_ = enc.Encode(created)
+		flusher.Flush()
+
+		for {
+			T.mu.Lock()
+			changed := T.changed
+			T.mu.Unlock()
+
+			select {
+			case <-changed:
+			case <-r.Context().Done():
+				return
+			}
+
+			var event WatchResponse
+			event.Result.Events = []json.RawMessage{json.RawMessage(`{}`)}
+			_ = enc.Encode(event)
+			flusher.Flush()
+		}
+	default:
+		http.NotFound(w, r)
+	}
+}
+
+func TestDiscoverer(t *testing.T) {
+	etcd := &standIn{
+		revision: 1,
+		kvs: map[string]string{
+			"/pggat/main/primary":         "10.0.0.1:5432",
+			"/pggat/main/replicas/pg2":    "10.0.0.2:5432",
+			"/pggat/main/databases/app":   "",
+			"/pggat/main/users/app":       "secret",
+			"/pggat/main/unknown":         "",
+			"/pggat/pending/users/app":    "secret",
+			"/pggat/pending/databases/db": "",
+		},
+		changed: make(chan struct{}),
+	}
+	server := httptest.NewServer(etcd)
+	defer server.Close()
+
+	d := &Discoverer{
+		Config: Config{
+			Endpoints: []string{"http://127.0.0.1:1", server.URL},
+			Prefix:    "/pggat/",
+			Timeout:   caddy.Duration(5 * time.Second),
+		},
+		clusters: make(map[string]discovery.Cluster),
+		added:    make(chan discovery.Cluster, 200),
+		removed:  make(chan string, 200),
+		log:      zap.NewNop(),
+	}
+
+	clusters, err := d.Clusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := discovery.Cluster{
+		ID: "main",
+		Primary: discovery.Node{
+			Address: "10.0.0.1:5432",
+		},
+		Replicas: map[string]discovery.Node{
+			"pg2": {Address: "10.0.0.2:5432"},
+		},
+		Databases: []string{"app"},
+		Users:     []discovery.User{{Username: "app", Password: "secret"}},
+	}
+	if len(clusters) != 1 || !reflect.DeepEqual(clusters[0], expected) {
+		t.Fatalf("expected %#v but got %#v", expected, clusters)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	d.cancel = cancel
+	go d.watch(ctx)
+	defer func() {
+		_ = d.Cleanup()
+	}()
+
+	// the primary is removed in the middle of the failover, the cluster is kept
+	etcd.delete("/pggat/main/primary")
+	select {
+	case cluster := <-d.Added():
+		t.Fatalf("unexpected cluster %#v", cluster)
+	case id := <-d.Removed():
+		t.Fatalf("unexpected removal of %s", id)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	etcd.put("/pggat/main/primary", "10.0.0.2:5432")
+	select {
+	case cluster := <-d.Added():
+		if cluster.Primary.Address != "10.0.0.2:5432" {
+			t.Fatalf("expected pg2 to be the primary but got %s", cluster.Primary.Address)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for failover")
+	}
+
+	for _, key := range []string{"/pggat/main/primary", "/pggat/main/replicas/pg2", "/pggat/main/databases/app", "/pggat/main/users/app", "/pggat/main/unknown"} {
+		etcd.delete(key)
+	}
+	select {
+	case id := <-d.Removed():
+		if id != "main" {
+			t.Fatalf("expected main to be removed but got %s", id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for removal")
+	}
+}
+
+func TestDiscovererAuth(t *testing.T) {
+	etcd := &standIn{
+		revision: 1,
+		kvs: map[string]string{
+			"/pggat/main/primary": "10.0.0.1:5432",
+		},
+		changed: make(chan struct{}),
+		auth:    true,
+	}
+	server := httptest.NewServer(etcd)
+	defer server.Close()
+
+	d := &Discoverer{
+		Config: Config{
+			Endpoints: []string{server.URL},
+			Username:  "pggat",
+			Password:  "secret",
+			Prefix:    "/pggat/",
+			Timeout:   caddy.Duration(5 * time.Second),
+		},
+		clusters: make(map[string]discovery.Cluster),
+		added:    make(chan discovery.Cluster, 200),
+		removed:  make(chan string, 200),
+		log:      zap.NewNop(),
+	}
+
+	issued := func() int {
+		etcd.mu.Lock()
+		defer etcd.mu.Unlock()
+		return etcd.issued
+	}
+
+	for i := 0; i < 3; i++ {
+		if _, err := d.Clusters(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := issued(); n != 1 {
+		t.Fatalf("expected the token to be reused but %d were issued", n)
+	}
+
+	// an expired token is replaced
+	etcd.expire()
+	if _, err := d.Clusters(); err != nil {
+		t.Fatal(err)
+	}
+	if n := issued(); n != 3 {
+		t.Fatalf("expected a new token after expiry but %d were issued", n)
+	}
+}
+
+func TestRangeEnd(t *testing.T) {
+	tests := []struct {
+		prefix   string
+		expected string
+	}{
+		{prefix: "/pggat/", expected: "/pggat0"},
+		{prefix: "a\xff", expected: "b"},
+		{prefix: "\xff", expected: "\x00"},
+	}
+	for _, test := range tests {
+		if got := string(rangeEnd([]byte(test.prefix))); got != test.expected {
+			t.Errorf("expected range end of %q to be %q but got %q", test.prefix, test.expected, got)
+		}
+	}
+}
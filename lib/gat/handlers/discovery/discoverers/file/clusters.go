@@ -0,0 +1,105 @@
+package file
+
+import (
+	"fmt"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+type User struct {
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+}
+
+type Node struct {
+	Address  string `json:"address"`
+	Priority int    `json:"priority,omitempty"`
+	Weight   int    `json:"weight,omitempty"`
+}
+
+type Cluster struct {
+	ID string `json:"id"`
+
+	Primary  Node            `json:"primary"`
+	Replicas map[string]Node `json:"replicas,omitempty"`
+
+	Databases []string `json:"databases"`
+	Users     []User   `json:"users"`
+}
+
+// File is the format of the cluster definitions file
+type File struct {
+	Clusters []Cluster `json:"clusters"`
+}
+
+func (T *Cluster) discovery() discovery.Cluster {
+	c := discovery.Cluster{
+		ID: T.ID,
+		Primary: discovery.Node{
+			Address:  T.Primary.Address,
+			Priority: T.Primary.Priority,
+			Weight:   T.Primary.Weight,
+		},
+		Replicas:  make(map[string]discovery.Node, len(T.Replicas)),
+		Databases: T.Databases,
+		Users:     make([]discovery.User, 0, len(T.Users)),
+	}
+	for id, replica := range T.Replicas {
+		c.Replicas[id] = discovery.Node{
+			Address:  replica.Address,
+			Priority: replica.Priority,
+			Weight:   replica.Weight,
+		}
+	}
+	for _, user := range T.Users {
+		c.Users = append(c.Users, discovery.User{
+			Username: user.Username,
+			Password: user.Password,
+		})
+	}
+	return c
+}
+
+// Parse reads cluster definitions in JSON or YAML
+func Parse(data []byte) ([]discovery.Cluster, error) {
+	var file File
+	if err := yaml.UnmarshalStrict(data, &file); err != nil {
+		return nil, err
+	}
+
+	res := make([]discovery.Cluster, 0, len(file.Clusters))
+	ids := make(map[string]struct{}, len(file.Clusters))
+	for i := range file.Clusters {
+		cluster := &file.Clusters[i]
+		if cluster.ID == "" {
+			return nil, fmt.Errorf("cluster %d: missing id", i)
+		}
+		if _, ok := ids[cluster.ID]; ok {
+			return nil, fmt.Errorf("cluster %s: duplicate id", cluster.ID)
+		}
+		ids[cluster.ID] = struct{}{}
+		if cluster.Primary.Address == "" {
+			return nil, fmt.Errorf("cluster %s: missing primary address", cluster.ID)
+		}
+		for id, replica := range cluster.Replicas {
+			if replica.Address == "" {
+				return nil, fmt.Errorf("cluster %s: replica %s: missing address", cluster.ID, id)
+			}
+		}
+
+		res = append(res, cluster.discovery())
+	}
+	return res, nil
+}
+
+// Load reads cluster definitions from a file
+func Load(path string) ([]discovery.Cluster, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
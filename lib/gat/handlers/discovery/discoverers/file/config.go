@@ -0,0 +1,6 @@
+package file
+
+type Config struct {
+	// Path is the JSON or YAML file with the cluster definitions. It is watched for changes
+	Path string `json:"path"`
+}
@@ -0,0 +1,187 @@
+package file
+
+import (
+	"fmt"
+	"path/filepath"
+	"reflect"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+// reloadDelay is how long to wait for writes to settle before reloading the file
+const reloadDelay = 100 * time.Millisecond
+
+func init() {
+	caddy.RegisterModule((*Discoverer)(nil))
+}
+
+// Discoverer reads clusters from a file and emits changes whenever it is written
+type Discoverer struct {
+	Config
+
+	path    string
+	watcher *fsnotify.Watcher
+
+	// clusters are the clusters last emitted, by id
+	clusters map[string]discovery.Cluster
+
+	added   chan discovery.Cluster
+	removed chan string
+
+	done chan struct{}
+
+	log *zap.Logger
+}
+
+func (T *Discoverer) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.discovery.discoverers.file",
+		New: func() caddy.Module {
+			return new(Discoverer)
+		},
+	}
+}
+
+func (T *Discoverer) Provision(ctx caddy.Context) error {
+	T.log = ctx.Logger().With(zap.String("discoverer", "file"))
+
+	if T.Path == "" {
+		return fmt.Errorf("missing path")
+	}
+
+	var err error
+	T.path, err = filepath.Abs(T.Path)
+	if err != nil {
+		return err
+	}
+
+	clusters, err := Load(T.path)
+	if err != nil {
+		return fmt.Errorf("loading clusters: %v", err)
+	}
+	T.clusters = make(map[string]discovery.Cluster, len(clusters))
+	for _, cluster := range clusters {
+		T.clusters[cluster.ID] = cluster
+	}
+
+	// watch the directory instead of the file, config management usually replaces the file instead of writing to it
+	T.watcher, err = fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	if err = T.watcher.Add(filepath.Dir(T.path)); err != nil {
+		_ = T.watcher.Close()
+		return err
+	}
+
+	T.added = make(chan discovery.Cluster, 200)
+	T.removed = make(chan string, 200)
+	T.done = make(chan struct{})
+
+	go T.watch(T.done)
+
+	return nil
+}
+
+func (T *Discoverer) Cleanup() error {
+	if T.done != nil {
+		close(T.done)
+		T.done = nil
+	}
+	if T.watcher != nil {
+		return T.watcher.Close()
+	}
+	return nil
+}
+
+func (T *Discoverer) watch(done <-chan struct{}) {
+	var reload <-chan time.Time
+	for {
+		select {
+		case <-done:
+			return
+		case event, ok := <-T.watcher.Events:
+			if !ok {
+				return
+			}
+			if filepath.Clean(event.Name) != T.path {
+				continue
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename|fsnotify.Remove) == 0 {
+				continue
+			}
+			reload = time.After(reloadDelay)
+		case err, ok := <-T.watcher.Errors:
+			if !ok {
+				return
+			}
+			T.log.Warn("error watching file", zap.Error(err))
+		case <-reload:
+			reload = nil
+			if !T.reload(done) {
+				return
+			}
+		}
+	}
+}
+
+// reload emits the differences between the file and the clusters last emitted. It returns false if the discoverer was
+// closed while emitting
+func (T *Discoverer) reload(done <-chan struct{}) bool {
+	clusters, err := Load(T.path)
+	if err != nil {
+		// keep the previous clusters, the file is probably being replaced
+		T.log.Warn("failed to load clusters", zap.Error(err))
+		return true
+	}
+
+	next := make(map[string]discovery.Cluster, len(clusters))
+	for _, cluster := range clusters {
+		next[cluster.ID] = cluster
+
+		if prev, ok := T.clusters[cluster.ID]; ok && reflect.DeepEqual(prev, cluster) {
+			continue
+		}
+		select {
+		case T.added <- cluster:
+		case <-done:
+			return false
+		}
+	}
+
+	for id := range T.clusters {
+		if _, ok := next[id]; ok {
+			continue
+		}
+		select {
+		case T.removed <- id:
+		case <-done:
+			return false
+		}
+	}
+
+	T.clusters = next
+	return true
+}
+
+func (T *Discoverer) Clusters() ([]discovery.Cluster, error) {
+	return Load(T.path)
+}
+
+func (T *Discoverer) Added() <-chan discovery.Cluster {
+	return T.added
+}
+
+func (T *Discoverer) Removed() <-chan string {
+	return T.removed
+}
+
+var _ discovery.Discoverer = (*Discoverer)(nil)
+var _ caddy.Module = (*Discoverer)(nil)
+var _ caddy.Provisioner = (*Discoverer)(nil)
+var _ caddy.CleanerUpper = (*Discoverer)(nil)
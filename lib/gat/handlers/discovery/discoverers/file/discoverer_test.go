@@ -0,0 +1,174 @@
+package file
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+const clustersYAML = `
+clusters:
+  - id: main
+    primary:
+      address: 10.0.0.1:5432
+    replicas:
+      main-1:
+        address: 10.0.0.2:5432
+        priority: 1
+    databases: [app]
+    users:
+      - username: app
+        password: secret
+  - id: other
+    primary:
+      address: 10.0.1.1:5432
+    databases: [other]
+    users:
+      - username: other
+`
+
+func TestParse(t *testing.T) {
+	clusters, err := Parse([]byte(clustersYAML))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []discovery.Cluster{
+		{
+			ID: "main",
+			Primary: discovery.Node{
+				Address: "10.0.0.1:5432",
+			},
+			Replicas: map[string]discovery.Node{
+				"main-1": {
+					Address:  "10.0.0.2:5432",
+					Priority: 1,
+				},
+			},
+			Databases: []string{"app"},
+			Users: []discovery.User{
+				{
+					Username: "app",
+					Password: "secret",
+				},
+			},
+		},
+		{
+			ID: "other",
+			Primary: discovery.Node{
+				Address: "10.0.1.1:5432",
+			},
+			Replicas:  map[string]discovery.Node{},
+			Databases: []string{"other"},
+			Users: []discovery.User{
+				{
+					Username: "other",
+				},
+			},
+		},
+	}
+	if !reflect.DeepEqual(clusters, expected) {
+		t.Fatalf("expected %#v but got %#v", expected, clusters)
+	}
+
+	json, err := Parse([]byte(`{"clusters":[{"id":"main","primary":{"address":"10.0.0.1:5432"},"databases":["app"],"users":[{"username":"app","password":"secret"}]}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(json) != 1 || json[0].Primary.Address != "10.0.0.1:5432" {
+		t.Fatalf("unexpected clusters %#v", json)
+	}
+
+	for _, invalid := range []string{
+		`clusters: [{primary: {address: a}}]`,
+		`clusters: [{id: a}]`,
+		`clusters: [{id: a, primary: {address: a}}, {id: a, primary: {address: b}}]`,
+		`clusters: [{id: a, primary: {address: a}, replicas: {b: {}}}]`,
+		`clusters: [{id: a, primary: {address: a}, unknown: true}]`,
+	} {
+		if _, err = Parse([]byte(invalid)); err == nil {
+			t.Errorf("expected error parsing %q", invalid)
+		}
+	}
+}
+
+func TestDiscovererWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "clusters.yaml")
+	if err := os.WriteFile(path, []byte(clustersYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		t.Skip("unable to watch files:", err)
+	}
+	if err = watcher.Add(dir); err != nil {
+		t.Fatal(err)
+	}
+
+	clusters, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := &Discoverer{
+		path:     path,
+		watcher:  watcher,
+		clusters: make(map[string]discovery.Cluster),
+		added:    make(chan discovery.Cluster, 200),
+		removed:  make(chan string, 200),
+		done:     make(chan struct{}),
+		log:      zap.NewNop(),
+	}
+	for _, cluster := range clusters {
+		d.clusters[cluster.ID] = cluster
+	}
+	go d.watch(d.done)
+	defer func() {
+		_ = d.Cleanup()
+	}()
+
+	// replace the file like config management would, changing main and removing other
+	next := []byte(`
+clusters:
+  - id: main
+    primary:
+      address: 10.0.0.2:5432
+    databases: [app]
+    users:
+      - username: app
+        password: secret
+`)
+	tmp := filepath.Join(dir, "clusters.yaml.tmp")
+	if err = os.WriteFile(tmp, next, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err = os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+
+	timeout := time.After(5 * time.Second)
+	select {
+	case cluster := <-d.Added():
+		if cluster.ID != "main" || cluster.Primary.Address != "10.0.0.2:5432" {
+			t.Fatalf("unexpected cluster %#v", cluster)
+		}
+	case <-timeout:
+		t.Fatal("timed out waiting for added cluster")
+	}
+	select {
+	case id := <-d.Removed():
+		if id != "other" {
+			t.Fatalf("expected other to be removed but got %s", id)
+		}
+	case <-timeout:
+		t.Fatal("timed out waiting for removed cluster")
+	}
+}
@@ -0,0 +1,48 @@
+package k8s_labels
+
+import (
+	"gfx.cafe/gfx/pggat/lib/k8s"
+)
+
+const (
+	KindServices = "services"
+	KindPods     = "pods"
+)
+
+type Config struct {
+	// Namespace configures namespace and label filtering for discovery
+	// Namespace field: if empty, watches all namespaces
+	// Labels field: only namespaces matching ALL specified labels will be discovered
+	Namespace k8s.NamespaceMatcher `json:"namespace"`
+
+	// Kind is what to discover, services or pods (default: services)
+	Kind string `json:"kind,omitempty"`
+
+	// Selector is a label selector for the services or pods, like app=postgres
+	Selector string `json:"selector,omitempty"`
+
+	// ClusterLabel groups services or pods into clusters. Objects without it are ignored (default: cluster-name)
+	ClusterLabel string `json:"cluster_label,omitempty"`
+
+	// RoleLabel is the label with the role of each object (default: role)
+	RoleLabel string `json:"role_label,omitempty"`
+	// PrimaryRoles and ReplicaRoles are the values of RoleLabel for primaries and replicas (default: primary and master,
+	// replica)
+	PrimaryRoles []string `json:"primary_roles,omitempty"`
+	ReplicaRoles []string `json:"replica_roles,omitempty"`
+
+	// ClusterDomain is the Kubernetes cluster domain (default: cluster.local)
+	ClusterDomain string `json:"cluster_domain,omitempty"`
+	// Port is the PostgreSQL port of pods, and of services without a port named postgres or postgresql (default: 5432)
+	Port int `json:"port,omitempty"`
+
+	// SecretsAnnotation is the annotation with a comma separated list of secrets in the same namespace to read credentials
+	// from (default: pggat.io/secrets)
+	SecretsAnnotation string `json:"secrets_annotation,omitempty"`
+	// Secrets are secret names to read credentials from, for objects without the annotation. {cluster} is replaced with
+	// the value of ClusterLabel
+	Secrets []string `json:"secrets,omitempty"`
+
+	// Databases are added to every cluster, on top of the dbname of each secret
+	Databases []string `json:"databases,omitempty"`
+}
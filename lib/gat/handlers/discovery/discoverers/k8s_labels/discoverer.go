@@ -0,0 +1,460 @@
+package k8s_labels
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/caddyserver/caddy/v2"
+	"go.uber.org/zap"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/tools/cache"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+func init() {
+	caddy.RegisterModule((*Discoverer)(nil))
+}
+
+// Discoverer discovers clusters from labeled services or pods, for clusters which aren't managed by an operator with
+// its own discoverer
+type Discoverer struct {
+	Config
+
+	k8sClient kubernetes.Interface
+
+	informer cache.SharedIndexInformer
+	stopCh   chan struct{}
+
+	// clusters are the clusters last emitted, by id
+	clusters map[string]discovery.Cluster
+	mu       sync.Mutex
+
+	added   chan discovery.Cluster
+	removed chan string
+
+	log *zap.Logger
+}
+
+func (d *Discoverer) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.discovery.discoverers.k8s_labels",
+		New: func() caddy.Module {
+			return new(Discoverer)
+		},
+	}
+}
+
+func (d *Discoverer) Provision(ctx caddy.Context) error {
+	d.log = ctx.Logger().With(zap.String("discoverer", "k8s_labels"))
+
+	restConfig, err := rest.InClusterConfig()
+	if err != nil {
+		return fmt.Errorf("failed to get in-cluster config: %w", err)
+	}
+
+	client, err := kubernetes.NewForConfig(restConfig)
+	if err != nil {
+		return fmt.Errorf("failed to create Kubernetes client: %w", err)
+	}
+
+	return d.start(ctx, client)
+}
+
+func (d *Discoverer) setDefaults() {
+	if d.Kind == "" {
+		d.Kind = KindServices
+	}
+	if d.ClusterLabel == "" {
+		d.ClusterLabel = "cluster-name"
+	}
+	if d.RoleLabel == "" {
+		d.RoleLabel = "role"
+	}
+	if len(d.PrimaryRoles) == 0 {
+		d.PrimaryRoles = []string{"primary", "master"}
+	}
+	if len(d.ReplicaRoles) == 0 {
+		d.ReplicaRoles = []string{"replica"}
+	}
+	if d.ClusterDomain == "" {
+		d.ClusterDomain = "cluster.local"
+	}
+	if d.Port == 0 {
+		d.Port = 5432
+	}
+	if d.SecretsAnnotation == "" {
+		d.SecretsAnnotation = discovery.AnnotationPrefix + "secrets"
+	}
+}
+
+func (d *Discoverer) start(ctx context.Context, client kubernetes.Interface) error {
+	d.setDefaults()
+
+	d.k8sClient = client
+
+	// only objects with the cluster label
+	selector := d.ClusterLabel
+	if d.Selector != "" {
+		selector = d.Selector + "," + selector
+	}
+
+	factory := informers.NewSharedInformerFactoryWithOptions(
+		client,
+		0, // No resync
+		informers.WithNamespace(d.Namespace.Namespace),
+		informers.WithTweakListOptions(func(opts *metav1.ListOptions) {
+			opts.LabelSelector = selector
+		}),
+	)
+
+	switch d.Kind {
+	case KindServices:
+		d.informer = factory.Core().V1().Services().Informer()
+	case KindPods:
+		d.informer = factory.Core().V1().Pods().Informer()
+	default:
+		return fmt.Errorf("unknown kind: %s", d.Kind)
+	}
+
+	d.clusters = make(map[string]discovery.Cluster)
+	d.added = make(chan discovery.Cluster, 200)
+	d.removed = make(chan string, 200)
+	d.stopCh = make(chan struct{})
+
+	_, err := d.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			d.handleEvent(obj)
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			// the cluster label may have changed
+			d.handleEvent(oldObj)
+			d.handleEvent(newObj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			d.handleEvent(obj)
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to add event handler: %w", err)
+	}
+
+	go d.informer.Run(d.stopCh)
+
+	if !cache.WaitForCacheSync(ctx.Done(), d.informer.HasSynced) {
+		return fmt.Errorf("failed to sync cache")
+	}
+
+	return nil
+}
+
+func (d *Discoverer) Cleanup() error {
+	if d.stopCh != nil {
+		close(d.stopCh)
+	}
+	return nil
+}
+
+// namespaceMatches checks if a namespace matches the label filter requirements
+func (d *Discoverer) namespaceMatches(ctx context.Context, namespaceName string) bool {
+	if !d.Namespace.MatchesNamespace(namespaceName) {
+		return false
+	}
+	if len(d.Namespace.Labels) == 0 {
+		return true
+	}
+
+	ns, err := d.k8sClient.CoreV1().Namespaces().Get(ctx, namespaceName, metav1.GetOptions{})
+	if err != nil {
+		d.log.Error("failed to get namespace", zap.String("namespace", namespaceName), zap.Error(err))
+		return false
+	}
+
+	return d.Namespace.MatchesNamespaceLabels(ns.Labels)
+}
+
+func clusterID(namespace, name string) string {
+	return namespace + "/" + name
+}
+
+func (d *Discoverer) handleEvent(obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	object, ok := obj.(metav1.Object)
+	if !ok {
+		return
+	}
+
+	name, ok := object.GetLabels()[d.ClusterLabel]
+	if !ok {
+		return
+	}
+
+	ctx := context.Background()
+	if !d.namespaceMatches(ctx, object.GetNamespace()) {
+		return
+	}
+
+	id := clusterID(object.GetNamespace(), name)
+	objects := d.objects()[id]
+
+	d.mu.Lock()
+	_, known := d.clusters[id]
+	if len(objects) == 0 {
+		delete(d.clusters, id)
+	}
+	d.mu.Unlock()
+
+	if len(objects) == 0 {
+		if known {
+			d.emitRemoved(id)
+		}
+		return
+	}
+
+	cluster, ok := d.build(ctx, object.GetNamespace(), name, objects)
+	if !ok {
+		// no primary in the middle of a failover, keep the previous members
+		return
+	}
+
+	d.mu.Lock()
+	prev, ok := d.clusters[id]
+	if ok && reflect.DeepEqual(prev, cluster) {
+		d.mu.Unlock()
+		return
+	}
+	d.clusters[id] = cluster
+	d.mu.Unlock()
+
+	d.emitAdded(cluster)
+}
+
+func (d *Discoverer) emitAdded(cluster discovery.Cluster) {
+	select {
+	case d.added <- cluster:
+	case <-d.stopCh:
+	}
+}
+
+func (d *Discoverer) emitRemoved(id string) {
+	select {
+	case d.removed <- id:
+	case <-d.stopCh:
+	}
+}
+
+// objects returns the objects in the informer's cache by cluster id
+func (d *Discoverer) objects() map[string][]metav1.Object {
+	objects := make(map[string][]metav1.Object)
+	for _, item := range d.informer.GetStore().List() {
+		object, ok := item.(metav1.Object)
+		if !ok {
+			continue
+		}
+		name, ok := object.GetLabels()[d.ClusterLabel]
+		if !ok {
+			continue
+		}
+		id := clusterID(object.GetNamespace(), name)
+		objects[id] = append(objects[id], object)
+	}
+	return objects
+}
+
+// address returns the address of a service or a ready pod
+func (d *Discoverer) address(object metav1.Object) (string, bool) {
+	switch obj := object.(type) {
+	case *corev1.Service:
+		port := d.Port
+		if len(obj.Spec.Ports) == 1 {
+			port = int(obj.Spec.Ports[0].Port)
+		}
+		for _, p := range obj.Spec.Ports {
+			if p.Name == "postgres" || p.Name == "postgresql" {
+				port = int(p.Port)
+			}
+		}
+		host := fmt.Sprintf("%s.%s.svc.%s", obj.Name, obj.Namespace, d.ClusterDomain)
+		return net.JoinHostPort(host, strconv.Itoa(port)), true
+	case *corev1.Pod:
+		if obj.DeletionTimestamp != nil || obj.Status.Phase != corev1.PodRunning || obj.Status.PodIP == "" {
+			return "", false
+		}
+		for _, condition := range obj.Status.Conditions {
+			if condition.Type == corev1.PodReady && condition.Status == corev1.ConditionTrue {
+				return net.JoinHostPort(obj.Status.PodIP, strconv.Itoa(d.Port)), true
+			}
+		}
+		return "", false
+	default:
+		return "", false
+	}
+}
+
+// build builds the cluster from its objects. It returns false if there is no primary
+func (d *Discoverer) build(ctx context.Context, namespace, name string, objects []metav1.Object) (discovery.Cluster, bool) {
+	sort.Slice(objects, func(i, j int) bool {
+		return objects[i].GetName() < objects[j].GetName()
+	})
+
+	cluster := discovery.Cluster{
+		ID:       clusterID(namespace, name),
+		Replicas: make(map[string]discovery.Node),
+	}
+
+	// overrides are read from the annotations of every object of the cluster
+	annotations := make(map[string]string)
+
+	secrets := make(map[string]struct{})
+	for _, object := range objects {
+		for key, value := range object.GetAnnotations() {
+			if key != d.SecretsAnnotation && strings.HasPrefix(key, discovery.OverridesPrefix) {
+				annotations[key] = value
+			}
+		}
+
+		if annotation, ok := object.GetAnnotations()[d.SecretsAnnotation]; ok {
+			for _, secret := range strings.Split(annotation, ",") {
+				if secret = strings.TrimSpace(secret); secret != "" {
+					secrets[secret] = struct{}{}
+				}
+			}
+		}
+
+		address, ok := d.address(object)
+		if !ok {
+			continue
+		}
+
+		role := object.GetLabels()[d.RoleLabel]
+		switch {
+		case slices.Contains(d.PrimaryRoles, role):
+			if cluster.Primary.Address == "" {
+				cluster.Primary.Address = address
+			}
+		case slices.Contains(d.ReplicaRoles, role):
+			cluster.Replicas[object.GetName()] = discovery.Node{
+				Address: address,
+			}
+		}
+	}
+	if cluster.Primary.Address == "" {
+		return discovery.Cluster{}, false
+	}
+
+	if len(secrets) == 0 {
+		for _, secret := range d.Secrets {
+			secrets[strings.ReplaceAll(secret, "{cluster}", name)] = struct{}{}
+		}
+	}
+
+	databases := slices.Clone(d.Databases)
+	users := make(map[string]string)
+	for secretName := range secrets {
+		secret, err := d.k8sClient.CoreV1().Secrets(namespace).Get(ctx, secretName, metav1.GetOptions{})
+		if err != nil {
+			d.log.Warn("failed to get secret",
+				zap.String("secret", secretName),
+				zap.String("cluster", cluster.ID),
+				zap.Error(err))
+			continue
+		}
+
+		username, ok := secret.Data["username"]
+		if !ok {
+			username, ok = secret.Data["user"]
+		}
+		if ok {
+			users[string(username)] = string(secret.Data["password"])
+		}
+		if dbname, ok := secret.Data["dbname"]; ok {
+			databases = append(databases, string(dbname))
+		}
+	}
+
+	sort.Strings(databases)
+	cluster.Databases = slices.Compact(databases)
+
+	cluster.Users = make([]discovery.User, 0, len(users))
+	for username, password := range users {
+		cluster.Users = append(cluster.Users, discovery.User{
+			Username: username,
+			Password: password,
+		})
+	}
+	sort.Slice(cluster.Users, func(i, j int) bool {
+		return cluster.Users[i].Username < cluster.Users[j].Username
+	})
+
+	overrides, err := discovery.ParseOverrides(discovery.OverridesPrefix, annotations)
+	if err != nil {
+		d.log.Warn("invalid overrides",
+			zap.String("cluster", cluster.ID),
+			zap.Error(err))
+	}
+	cluster.Overrides = overrides
+
+	return cluster, true
+}
+
+func (d *Discoverer) Clusters() ([]discovery.Cluster, error) {
+	ctx := context.Background()
+
+	next := make(map[string]discovery.Cluster)
+	for id, objects := range d.objects() {
+		namespace, name, _ := strings.Cut(id, "/")
+		if !d.namespaceMatches(ctx, namespace) {
+			continue
+		}
+
+		cluster, ok := d.build(ctx, namespace, name, objects)
+		if !ok {
+			d.mu.Lock()
+			cluster, ok = d.clusters[id]
+			d.mu.Unlock()
+			if !ok {
+				continue
+			}
+		}
+		next[id] = cluster
+	}
+
+	d.mu.Lock()
+	d.clusters = next
+	d.mu.Unlock()
+
+	clusters := make([]discovery.Cluster, 0, len(next))
+	for _, cluster := range next {
+		clusters = append(clusters, cluster)
+	}
+	return clusters, nil
+}
+
+func (d *Discoverer) Added() <-chan discovery.Cluster {
+	return d.added
+}
+
+func (d *Discoverer) Removed() <-chan string {
+	return d.removed
+}
+
+// Interface assertions
+var (
+	_ discovery.Discoverer = (*Discoverer)(nil)
+	_ caddy.Module         = (*Discoverer)(nil)
+	_ caddy.Provisioner    = (*Discoverer)(nil)
+	_ caddy.CleanerUpper   = (*Discoverer)(nil)
+)
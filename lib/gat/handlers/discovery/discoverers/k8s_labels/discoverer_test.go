@@ -0,0 +1,228 @@
+package k8s_labels
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zaptest/observer"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+	"gfx.cafe/gfx/pggat/lib/k8s"
+)
+
+func service(name, role string, annotations map[string]string) *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name,
+			Namespace: "db",
+			Labels: map[string]string{
+				"app":          "postgres",
+				"cluster-name": "main",
+				"role":         role,
+			},
+			Annotations: annotations,
+		},
+		Spec: corev1.ServiceSpec{
+			Ports: []corev1.ServicePort{
+				{Name: "metrics", Port: 9187},
+				{Name: "postgres", Port: 5433},
+			},
+		},
+	}
+}
+
+func TestDiscoverer(t *testing.T) {
+	core, logs := observer.New(zap.WarnLevel)
+	client := fake.NewClientset(
+		service("main-rw", "primary", map[string]string{
+			"pggat.io/secrets":   "main-app, main-admin",
+			"pggat.io/pool-mode": "session",
+		}),
+		service("main-ro", "replica", nil),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "main-app", Namespace: "db"},
+			Data: map[string][]byte{
+				"username": []byte("app"),
+				"password": []byte("secret"),
+				"dbname":   []byte("app"),
+			},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "main-admin", Namespace: "db"},
+			Data: map[string][]byte{
+				"user":     []byte("admin"),
+				"password": []byte("hunter2"),
+			},
+		},
+	)
+
+	d := &Discoverer{
+		Config: Config{
+			Namespace: k8s.NamespaceMatcher{
+				Namespace: "db",
+			},
+			Selector:  "app=postgres",
+			Databases: []string{"postgres"},
+		},
+		log: zap.New(core),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.start(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = d.Cleanup()
+	}()
+
+	expected := discovery.Cluster{
+		ID: "db/main",
+		Primary: discovery.Node{
+			Address: "main-rw.db.svc.cluster.local:5433",
+		},
+		Replicas: map[string]discovery.Node{
+			"main-ro": {Address: "main-ro.db.svc.cluster.local:5433"},
+		},
+		Databases: []string{"app", "postgres"},
+		Users: []discovery.User{
+			{Username: "admin", Password: "hunter2"},
+			{Username: "app", Password: "secret"},
+		},
+		Overrides: discovery.Overrides{
+			PoolMode: "session",
+		},
+	}
+
+	clusters, err := d.Clusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(clusters) != 1 || !reflect.DeepEqual(clusters[0], expected) {
+		t.Fatalf("expected %#v but got %#v", expected, clusters)
+	}
+	// the secrets annotation shares the prefix of the overrides but isn't one
+	if n := logs.FilterMessage("invalid overrides").Len(); n != 0 {
+		t.Fatalf("expected no invalid overrides but got %d warnings", n)
+	}
+
+	// drain the events from the initial sync
+	for len(d.Added()) > 0 {
+		<-d.Added()
+	}
+
+	// a new replica is added
+	if _, err = client.CoreV1().Services("db").Create(ctx, service("main-ro2", "replica", nil), metav1.CreateOptions{}); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case cluster := <-d.Added():
+		if _, ok := cluster.Replicas["main-ro2"]; !ok || len(cluster.Replicas) != 2 {
+			t.Fatalf("unexpected replicas %#v", cluster.Replicas)
+		}
+	case <-ctx.Done():
+		t.Fatal("timed out waiting for replica")
+	}
+
+	// every service is deleted
+	for _, name := range []string{"main-rw", "main-ro", "main-ro2"} {
+		if err = client.CoreV1().Services("db").Delete(ctx, name, metav1.DeleteOptions{}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for {
+		select {
+		case <-d.Added():
+			continue
+		case id := <-d.Removed():
+			if id != "db/main" {
+				t.Fatalf("expected db/main to be removed but got %s", id)
+			}
+		case <-ctx.Done():
+			t.Fatal("timed out waiting for removal")
+		}
+		break
+	}
+}
+
+func TestDiscovererPods(t *testing.T) {
+	ready := corev1.PodStatus{
+		Phase: corev1.PodRunning,
+		PodIP: "10.0.0.1",
+		Conditions: []corev1.PodCondition{
+			{Type: corev1.PodReady, Status: corev1.ConditionTrue},
+		},
+	}
+	pod := func(name, role, ip string, status corev1.PodStatus) *corev1.Pod {
+		status.PodIP = ip
+		return &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: "db",
+				Labels: map[string]string{
+					"postgres-operator.crunchydata.com/cluster": "hippo",
+					"postgres-operator.crunchydata.com/role":    role,
+				},
+			},
+			Status: status,
+		}
+	}
+
+	client := fake.NewClientset(
+		pod("hippo-0", "master", "10.0.0.1", ready),
+		pod("hippo-1", "replica", "10.0.0.2", ready),
+		pod("hippo-2", "replica", "10.0.0.3", corev1.PodStatus{Phase: corev1.PodPending}),
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: "hippo-pguser-hippo", Namespace: "db"},
+			Data: map[string][]byte{
+				"user":     []byte("hippo"),
+				"password": []byte("secret"),
+				"dbname":   []byte("hippo"),
+			},
+		},
+	)
+
+	d := &Discoverer{
+		Config: Config{
+			Kind:         KindPods,
+			ClusterLabel: "postgres-operator.crunchydata.com/cluster",
+			RoleLabel:    "postgres-operator.crunchydata.com/role",
+			Secrets:      []string{"{cluster}-pguser-{cluster}"},
+		},
+		log: zap.NewNop(),
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := d.start(ctx, client); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = d.Cleanup()
+	}()
+
+	clusters, err := d.Clusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := discovery.Cluster{
+		ID: "db/hippo",
+		Primary: discovery.Node{
+			Address: "10.0.0.1:5432",
+		},
+		Replicas: map[string]discovery.Node{
+			"hippo-1": {Address: "10.0.0.2:5432"},
+		},
+		Databases: []string{"hippo"},
+		Users:     []discovery.User{{Username: "hippo", Password: "secret"}},
+	}
+	if len(clusters) != 1 || !reflect.DeepEqual(clusters[0], expected) {
+		t.Fatalf("expected %#v but got %#v", expected, clusters)
+	}
+}
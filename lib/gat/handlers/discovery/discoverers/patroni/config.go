@@ -0,0 +1,34 @@
+package patroni
+
+import "github.com/caddyserver/caddy/v2"
+
+type User struct {
+	Username string `json:"username"`
+	Password string `json:"password,omitempty"`
+}
+
+// Scope is a Patroni cluster, named after the Patroni setting
+type Scope struct {
+	ID string `json:"id"`
+
+	// Endpoints are the Patroni REST APIs of the members, like http://10.0.0.1:8008. They are tried in order until one
+	// responds
+	Endpoints []string `json:"endpoints"`
+
+	// Databases and Users aren't known by Patroni, so they have to be configured
+	Databases []string `json:"databases"`
+	Users     []User   `json:"users"`
+}
+
+type Config struct {
+	// Scopes are the Patroni clusters to discover
+	Scopes []Scope `json:"scopes"`
+
+	// PollInterval is how often the members of each cluster are checked for failovers
+	PollInterval caddy.Duration `json:"poll_interval,omitempty"`
+	// Timeout is the timeout of each request to the REST API
+	Timeout caddy.Duration `json:"timeout,omitempty"`
+
+	// MaxLag is the max replication lag in bytes for a replica to receive reads. 0 = unlimited
+	MaxLag int64 `json:"max_lag,omitempty"`
+}
@@ -0,0 +1,212 @@
+package patroni
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+func init() {
+	caddy.RegisterModule((*Discoverer)(nil))
+}
+
+// Discoverer polls the Patroni REST API of each cluster and emits the cluster whenever its members change
+type Discoverer struct {
+	Config
+
+	client http.Client
+
+	// clusters are the clusters last emitted, by id
+	clusters map[string]discovery.Cluster
+	mu       sync.Mutex
+
+	added chan discovery.Cluster
+
+	done chan struct{}
+
+	log *zap.Logger
+}
+
+func (T *Discoverer) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.discovery.discoverers.patroni",
+		New: func() caddy.Module {
+			return new(Discoverer)
+		},
+	}
+}
+
+func (T *Discoverer) Provision(ctx caddy.Context) error {
+	T.log = ctx.Logger().With(zap.String("discoverer", "patroni"))
+
+	if T.PollInterval == 0 {
+		T.PollInterval = caddy.Duration(5 * time.Second)
+	}
+	if T.Timeout == 0 {
+		T.Timeout = caddy.Duration(5 * time.Second)
+	}
+
+	ids := make(map[string]struct{}, len(T.Scopes))
+	for _, scope := range T.Scopes {
+		if scope.ID == "" {
+			return errors.New("scope missing id")
+		}
+		if _, ok := ids[scope.ID]; ok {
+			return fmt.Errorf("duplicate scope %s", scope.ID)
+		}
+		ids[scope.ID] = struct{}{}
+		if len(scope.Endpoints) == 0 {
+			return fmt.Errorf("scope %s: missing endpoints", scope.ID)
+		}
+	}
+
+	T.client.Timeout = time.Duration(T.Timeout)
+	T.clusters = make(map[string]discovery.Cluster, len(T.Scopes))
+	T.added = make(chan discovery.Cluster, 200)
+	T.done = make(chan struct{})
+
+	go T.poll(T.done)
+
+	return nil
+}
+
+func (T *Discoverer) Cleanup() error {
+	if T.done != nil {
+		close(T.done)
+		T.done = nil
+	}
+	return nil
+}
+
+func (T *Discoverer) status(ctx context.Context, endpoint string) (Status, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(endpoint, "/")+"/cluster", nil)
+	if err != nil {
+		return Status{}, err
+	}
+
+	resp, err := T.client.Do(req)
+	if err != nil {
+		return Status{}, err
+	}
+	defer func() {
+		_ = resp.Body.Close()
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		return Status{}, fmt.Errorf("unexpected status: %s", resp.Status)
+	}
+
+	var status Status
+	if err = json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return Status{}, err
+	}
+	return status, nil
+}
+
+// lookup asks each endpoint of the scope in order until one responds with a leader
+func (T *Discoverer) lookup(ctx context.Context, scope *Scope) (discovery.Cluster, error) {
+	var errs []error
+	for _, endpoint := range scope.Endpoints {
+		status, err := T.status(ctx, endpoint)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", endpoint, err))
+			continue
+		}
+
+		c, ok := status.cluster(scope, T.MaxLag)
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: no leader", endpoint))
+			continue
+		}
+		return c, nil
+	}
+
+	return discovery.Cluster{}, fmt.Errorf("scope %s: %w", scope.ID, errors.Join(errs...))
+}
+
+// update looks up every scope, and returns the ones which changed since they were last emitted. Clusters which
+// can't be looked up keep their previous members
+func (T *Discoverer) update(ctx context.Context) []discovery.Cluster {
+	var changed []discovery.Cluster
+	for i := range T.Scopes {
+		c, err := T.lookup(ctx, &T.Scopes[i])
+		if err != nil {
+			T.log.Warn("failed to look up cluster", zap.Error(err))
+			continue
+		}
+
+		T.mu.Lock()
+		prev, ok := T.clusters[c.ID]
+		if !ok || !reflect.DeepEqual(prev, c) {
+			T.clusters[c.ID] = c
+			changed = append(changed, c)
+		}
+		T.mu.Unlock()
+	}
+	return changed
+}
+
+func (T *Discoverer) poll(done <-chan struct{}) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		<-done
+		cancel()
+	}()
+
+	ticker := time.NewTicker(time.Duration(T.PollInterval))
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+		}
+
+		for _, cluster := range T.update(ctx) {
+			select {
+			case T.added <- cluster:
+			case <-done:
+				return
+			}
+		}
+	}
+}
+
+func (T *Discoverer) Clusters() ([]discovery.Cluster, error) {
+	T.update(context.Background())
+
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	res := make([]discovery.Cluster, 0, len(T.clusters))
+	for _, cluster := range T.clusters {
+		res = append(res, cluster)
+	}
+	return res, nil
+}
+
+func (T *Discoverer) Added() <-chan discovery.Cluster {
+	return T.added
+}
+
+func (T *Discoverer) Removed() <-chan string {
+	return nil
+}
+
+var _ discovery.Discoverer = (*Discoverer)(nil)
+var _ caddy.Module = (*Discoverer)(nil)
+var _ caddy.Provisioner = (*Discoverer)(nil)
+var _ caddy.CleanerUpper = (*Discoverer)(nil)
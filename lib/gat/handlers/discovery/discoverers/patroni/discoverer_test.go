@@ -0,0 +1,143 @@
+package patroni
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+type standIn struct {
+	status Status
+	mu     sync.Mutex
+}
+
+func (T *standIn) set(status Status) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+	T.status = status
+}
+
+func (T *standIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.URL.Path != "/cluster" {
+		http.NotFound(w, r)
+		return
+	}
+
+	T.mu.Lock()
+	defer T.mu.Unlock()
+	_ = json.NewEncoder(w).Encode(T.status)
+}
+
+func TestDiscoverer(t *testing.T) {
+	patroni := new(standIn)
+	patroni.set(Status{
+		Scope: "main",
+		Members: []Member{
+			{Name: "pg1", Role: roleLeader, State: stateRunning, Host: "10.0.0.1", Port: 5432},
+			{Name: "pg2", Role: "replica", State: stateStreaming, Host: "10.0.0.2", Port: 5432, Lag: json.RawMessage("0")},
+			{Name: "pg3", Role: "replica", State: stateStreaming, Host: "10.0.0.3", Port: 5432, Lag: json.RawMessage("0"), Tags: Tags{NoFailover: true}},
+			{Name: "pg4", Role: "replica", State: stateStreaming, Host: "10.0.0.4", Port: 5432, Lag: json.RawMessage("0"), Tags: Tags{NoLoadBalance: true}},
+			{Name: "pg5", Role: "replica", State: stateStreaming, Host: "10.0.0.5", Port: 5432, Lag: json.RawMessage("1048576")},
+			{Name: "pg6", Role: "replica", State: stateStreaming, Host: "10.0.0.6", Port: 5432, Lag: json.RawMessage(`"unknown"`)},
+			{Name: "pg7", Role: "replica", State: "stopped", Host: "10.0.0.7", Port: 5432},
+		},
+	})
+	server := httptest.NewServer(patroni)
+	defer server.Close()
+
+	d := &Discoverer{
+		Config: Config{
+			Scopes: []Scope{
+				{
+					ID:        "main",
+					Endpoints: []string{"http://127.0.0.1:1", server.URL},
+					Databases: []string{"app"},
+					Users:     []User{{Username: "app", Password: "secret"}},
+				},
+			},
+			PollInterval: caddy.Duration(10 * time.Millisecond),
+			Timeout:      caddy.Duration(time.Second),
+			MaxLag:       1024,
+		},
+		clusters: make(map[string]discovery.Cluster),
+		added:    make(chan discovery.Cluster, 200),
+		done:     make(chan struct{}),
+		log:      zap.NewNop(),
+	}
+	d.client.Timeout = time.Duration(d.Timeout)
+
+	clusters, err := d.Clusters()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := discovery.Cluster{
+		ID: "main",
+		Primary: discovery.Node{
+			Address: "10.0.0.1:5432",
+		},
+		Replicas: map[string]discovery.Node{
+			"pg2": {Address: "10.0.0.2:5432"},
+			"pg3": {Address: "10.0.0.3:5432", Priority: nofailoverPriority},
+		},
+		Databases: []string{"app"},
+		Users:     []discovery.User{{Username: "app", Password: "secret"}},
+	}
+	if len(clusters) != 1 || !reflect.DeepEqual(clusters[0], expected) {
+		t.Fatalf("expected %#v but got %#v", expected, clusters)
+	}
+
+	go d.poll(d.done)
+	defer func() {
+		_ = d.Cleanup()
+	}()
+
+	// no leader during the failover, the previous members are kept
+	patroni.set(Status{
+		Scope: "main",
+		Members: []Member{
+			{Name: "pg1", Role: "replica", State: "stopped", Host: "10.0.0.1", Port: 5432},
+			{Name: "pg2", Role: "replica", State: stateRunning, Host: "10.0.0.2", Port: 5432},
+		},
+	})
+	select {
+	case cluster := <-d.Added():
+		t.Fatalf("unexpected cluster %#v", cluster)
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	// pg2 was promoted
+	patroni.set(Status{
+		Scope: "main",
+		Members: []Member{
+			{Name: "pg1", Role: "replica", State: stateStreaming, Host: "10.0.0.1", Port: 5432, Lag: json.RawMessage("0")},
+			{Name: "pg2", Role: roleLeader, State: stateRunning, Host: "10.0.0.2", Port: 5432},
+		},
+	})
+	select {
+	case cluster := <-d.Added():
+		if cluster.Primary.Address != "10.0.0.2:5432" {
+			t.Fatalf("expected pg2 to be the primary but got %s", cluster.Primary.Address)
+		}
+		if !reflect.DeepEqual(cluster.Replicas, map[string]discovery.Node{"pg1": {Address: "10.0.0.1:5432"}}) {
+			t.Fatalf("unexpected replicas %#v", cluster.Replicas)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("timed out waiting for failover")
+	}
+
+	// unchanged clusters are not emitted again
+	select {
+	case cluster := <-d.Added():
+		t.Fatalf("unexpected cluster %#v", cluster)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
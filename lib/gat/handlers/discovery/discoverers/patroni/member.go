@@ -0,0 +1,124 @@
+package patroni
+
+import (
+	"encoding/json"
+	"net"
+	"strconv"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+const (
+	roleLeader        = "leader"
+	roleStandbyLeader = "standby_leader"
+
+	stateRunning   = "running"
+	stateStreaming = "streaming"
+)
+
+// nofailoverPriority deprioritizes replicas which can never be promoted, they are usually delayed or backup members
+const nofailoverPriority = 1
+
+type Tags struct {
+	NoFailover    bool `json:"nofailover,omitempty"`
+	NoLoadBalance bool `json:"noloadbalance,omitempty"`
+}
+
+type Member struct {
+	Name  string `json:"name"`
+	Role  string `json:"role"`
+	State string `json:"state"`
+	Host  string `json:"host"`
+	Port  int    `json:"port"`
+	Tags  Tags   `json:"tags"`
+
+	// Lag is the replication lag in bytes. Patroni reports "unknown" if it can't be determined
+	Lag json.RawMessage `json:"lag,omitempty"`
+}
+
+func (T *Member) address() string {
+	return net.JoinHostPort(T.Host, strconv.Itoa(T.Port))
+}
+
+// lag returns the replication lag in bytes, or false if it is unknown
+func (T *Member) lag() (int64, bool) {
+	if len(T.Lag) == 0 {
+		return 0, true
+	}
+	var lag int64
+	if err := json.Unmarshal(T.Lag, &lag); err != nil {
+		return 0, false
+	}
+	return lag, true
+}
+
+// Status is the response of the /cluster endpoint
+type Status struct {
+	Scope   string   `json:"scope"`
+	Members []Member `json:"members"`
+}
+
+// leader returns the member accepting writes. For standby clusters, this is the standby leader
+func (T *Status) leader() (Member, bool) {
+	for _, member := range T.Members {
+		if (member.Role == roleLeader || member.Role == roleStandbyLeader) && member.State == stateRunning {
+			return member, true
+		}
+	}
+	return Member{}, false
+}
+
+// cluster builds the discovery.Cluster from the members. It returns false if the cluster has no leader, like in the
+// middle of a failover
+func (T *Status) cluster(config *Scope, maxLag int64) (discovery.Cluster, bool) {
+	leader, ok := T.leader()
+	if !ok {
+		return discovery.Cluster{}, false
+	}
+
+	c := discovery.Cluster{
+		ID: config.ID,
+		Primary: discovery.Node{
+			Address: leader.address(),
+		},
+		Replicas:  make(map[string]discovery.Node),
+		Databases: config.Databases,
+		Users:     make([]discovery.User, 0, len(config.Users)),
+	}
+
+	for _, member := range T.Members {
+		if member.Name == leader.Name {
+			continue
+		}
+		if member.State != stateRunning && member.State != stateStreaming {
+			continue
+		}
+		if member.Tags.NoLoadBalance {
+			continue
+		}
+		if maxLag != 0 {
+			lag, ok := member.lag()
+			if !ok || lag > maxLag {
+				continue
+			}
+		}
+
+		var priority int
+		if member.Tags.NoFailover {
+			priority = nofailoverPriority
+		}
+		c.Replicas[member.Name] = discovery.Node{
+			Address:  member.address(),
+			Priority: priority,
+		}
+	}
+
+	for _, user := range config.Users {
+		c.Users = append(c.Users, discovery.User{
+			Username: user.Username,
+			Password: user.Password,
+		})
+	}
+
+	return c, true
+}
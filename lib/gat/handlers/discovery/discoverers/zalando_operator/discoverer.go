@@ -287,6 +287,16 @@ func (T *Discoverer) postgresqlToCluster(cluster acidv1.Postgresql) (discovery.C
 	for database := range cluster.Spec.Databases {
 		c.Databases = append(c.Databases, database)
 	}
+
+	overrides, err := discovery.ParseOverrides(discovery.OverridesPrefix, cluster.Annotations)
+	if err != nil {
+		T.log.Warn("invalid overrides",
+			zap.String("cluster", cluster.Name),
+			zap.String("namespace", cluster.Namespace),
+			zap.Error(err))
+	}
+	c.Overrides = overrides
+
 	return c, nil
 }
 
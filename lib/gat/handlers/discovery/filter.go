@@ -0,0 +1,8 @@
+package discovery
+
+// Filter drops or transforms clusters from the discoverer before they are used. Filters must not modify the slices
+// or maps of cluster in place, discoverers may still hold them.
+type Filter interface {
+	// Filter returns the filtered cluster, or false if it should be dropped
+	Filter(cluster Cluster) (Cluster, bool)
+}
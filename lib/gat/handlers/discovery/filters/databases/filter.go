@@ -0,0 +1,40 @@
+package databases
+
+import (
+	"github.com/caddyserver/caddy/v2"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/filters"
+)
+
+func init() {
+	caddy.RegisterModule((*Filter)(nil))
+}
+
+// Filter removes databases which aren't allowed from clusters
+type Filter struct {
+	filters.List
+}
+
+func (T *Filter) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.discovery.filters.databases",
+		New: func() caddy.Module {
+			return new(Filter)
+		},
+	}
+}
+
+func (T *Filter) Filter(cluster discovery.Cluster) (discovery.Cluster, bool) {
+	databases := make([]string, 0, len(cluster.Databases))
+	for _, database := range cluster.Databases {
+		if T.Allows(database) {
+			databases = append(databases, database)
+		}
+	}
+	cluster.Databases = databases
+	return cluster, true
+}
+
+var _ discovery.Filter = (*Filter)(nil)
+var _ caddy.Module = (*Filter)(nil)
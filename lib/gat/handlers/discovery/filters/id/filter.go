@@ -0,0 +1,47 @@
+package id
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/caddyserver/caddy/v2"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+func init() {
+	caddy.RegisterModule((*Filter)(nil))
+}
+
+// Filter keeps only clusters whose ID matches a regular expression
+type Filter struct {
+	Pattern string `json:"pattern"`
+
+	pattern *regexp.Regexp
+}
+
+func (T *Filter) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.discovery.filters.id",
+		New: func() caddy.Module {
+			return new(Filter)
+		},
+	}
+}
+
+func (T *Filter) Provision(ctx caddy.Context) error {
+	var err error
+	T.pattern, err = regexp.Compile(T.Pattern)
+	if err != nil {
+		return fmt.Errorf("invalid pattern: %v", err)
+	}
+	return nil
+}
+
+func (T *Filter) Filter(cluster discovery.Cluster) (discovery.Cluster, bool) {
+	return cluster, T.pattern.MatchString(cluster.ID)
+}
+
+var _ discovery.Filter = (*Filter)(nil)
+var _ caddy.Module = (*Filter)(nil)
+var _ caddy.Provisioner = (*Filter)(nil)
@@ -0,0 +1,27 @@
+package filters
+
+import "gfx.cafe/gfx/pggat/lib/util/strutil"
+
+// List is an allow and deny list of glob patterns
+type List struct {
+	// Allow keeps only names matching one of the patterns. Empty allows everything
+	Allow []strutil.Matcher `json:"allow,omitempty"`
+	// Deny drops names matching one of the patterns, even if they are allowed
+	Deny []strutil.Matcher `json:"deny,omitempty"`
+}
+
+func matchesAny(matchers []strutil.Matcher, name string) bool {
+	for _, matcher := range matchers {
+		if matcher.Matches(name) {
+			return true
+		}
+	}
+	return false
+}
+
+func (T *List) Allows(name string) bool {
+	if len(T.Allow) != 0 && !matchesAny(T.Allow, name) {
+		return false
+	}
+	return !matchesAny(T.Deny, name)
+}
@@ -0,0 +1,31 @@
+package filters
+
+import (
+	"testing"
+
+	"gfx.cafe/gfx/pggat/lib/util/strutil"
+)
+
+func TestList(t *testing.T) {
+	list := List{
+		Allow: []strutil.Matcher{"app_*", "postgres"},
+		Deny:  []strutil.Matcher{"postgres", "app_admin"},
+	}
+
+	cases := map[string]bool{
+		"app_read":  true,
+		"app_admin": false,
+		"postgres":  false,
+		"other":     false,
+	}
+	for name, expected := range cases {
+		if allowed := list.Allows(name); allowed != expected {
+			t.Errorf("expected %s allowed=%v", name, expected)
+		}
+	}
+
+	var empty List
+	if !empty.Allows("anything") {
+		t.Error("expected an empty list to allow everything")
+	}
+}
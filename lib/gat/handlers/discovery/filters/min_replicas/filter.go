@@ -0,0 +1,33 @@
+package min_replicas
+
+import (
+	"github.com/caddyserver/caddy/v2"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+func init() {
+	caddy.RegisterModule((*Filter)(nil))
+}
+
+// Filter drops clusters with fewer replicas than Min. Clusters which lose replicas are removed until they have enough
+// again
+type Filter struct {
+	Min int `json:"min"`
+}
+
+func (T *Filter) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.discovery.filters.min_replicas",
+		New: func() caddy.Module {
+			return new(Filter)
+		},
+	}
+}
+
+func (T *Filter) Filter(cluster discovery.Cluster) (discovery.Cluster, bool) {
+	return cluster, len(cluster.Replicas) >= T.Min
+}
+
+var _ discovery.Filter = (*Filter)(nil)
+var _ caddy.Module = (*Filter)(nil)
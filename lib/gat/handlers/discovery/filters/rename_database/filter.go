@@ -0,0 +1,59 @@
+package rename_database
+
+import (
+	"github.com/caddyserver/caddy/v2"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+	"gfx.cafe/gfx/pggat/lib/util/maps"
+)
+
+func init() {
+	caddy.RegisterModule((*Filter)(nil))
+}
+
+// Filter renames a database. Clients connect with the new name, servers are still dialed with the old one
+type Filter struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+func (T *Filter) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.discovery.filters.rename_database",
+		New: func() caddy.Module {
+			return new(Filter)
+		},
+	}
+}
+
+func (T *Filter) Filter(cluster discovery.Cluster) (discovery.Cluster, bool) {
+	databases := make([]string, 0, len(cluster.Databases))
+	renamed := false
+	for _, database := range cluster.Databases {
+		if database == T.From {
+			database = T.To
+			renamed = true
+		}
+		databases = append(databases, database)
+	}
+	if !renamed {
+		return cluster, true
+	}
+
+	// the database may have already been renamed by an earlier filter
+	server := cluster.Overrides.ServerDatabase(T.From)
+
+	serverDatabases := maps.Clone(cluster.Overrides.ServerDatabases)
+	if serverDatabases == nil {
+		serverDatabases = make(map[string]string)
+	}
+	delete(serverDatabases, T.From)
+	serverDatabases[T.To] = server
+
+	cluster.Databases = databases
+	cluster.Overrides.ServerDatabases = serverDatabases
+	return cluster, true
+}
+
+var _ discovery.Filter = (*Filter)(nil)
+var _ caddy.Module = (*Filter)(nil)
@@ -0,0 +1,40 @@
+package rename_database
+
+import (
+	"reflect"
+	"slices"
+	"testing"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+)
+
+func TestFilter(t *testing.T) {
+	cluster := discovery.Cluster{
+		ID:        "main",
+		Databases: []string{"postgres", "app_production"},
+	}
+
+	first := Filter{From: "app_production", To: "app_v1"}
+	second := Filter{From: "app_v1", To: "app"}
+
+	renamed, ok := first.Filter(cluster)
+	if !ok {
+		t.Fatal("expected cluster to be kept")
+	}
+	renamed, _ = second.Filter(renamed)
+
+	if !slices.Equal(renamed.Databases, []string{"postgres", "app"}) {
+		t.Fatalf("unexpected databases %v", renamed.Databases)
+	}
+	if !reflect.DeepEqual(renamed.Overrides.ServerDatabases, map[string]string{"app": "app_production"}) {
+		t.Fatalf("unexpected server databases %v", renamed.Overrides.ServerDatabases)
+	}
+	if renamed.Overrides.ServerDatabase("postgres") != "postgres" {
+		t.Fatal("expected other databases to keep their name")
+	}
+
+	// the discoverer's cluster is left alone
+	if !slices.Equal(cluster.Databases, []string{"postgres", "app_production"}) {
+		t.Fatalf("expected original cluster to be unchanged but got %v", cluster.Databases)
+	}
+}
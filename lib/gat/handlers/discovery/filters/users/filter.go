@@ -0,0 +1,40 @@
+package users
+
+import (
+	"github.com/caddyserver/caddy/v2"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/filters"
+)
+
+func init() {
+	caddy.RegisterModule((*Filter)(nil))
+}
+
+// Filter removes users which aren't allowed from clusters
+type Filter struct {
+	filters.List
+}
+
+func (T *Filter) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.discovery.filters.users",
+		New: func() caddy.Module {
+			return new(Filter)
+		},
+	}
+}
+
+func (T *Filter) Filter(cluster discovery.Cluster) (discovery.Cluster, bool) {
+	users := make([]discovery.User, 0, len(cluster.Users))
+	for _, user := range cluster.Users {
+		if T.Allows(user.Username) {
+			users = append(users, user)
+		}
+	}
+	cluster.Users = users
+	return cluster, true
+}
+
+var _ discovery.Filter = (*Filter)(nil)
+var _ caddy.Module = (*Filter)(nil)
@@ -4,6 +4,8 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+	"reflect"
+	"sort"
 	"sync"
 	"time"
 
@@ -17,6 +19,7 @@ import (
 	"gfx.cafe/gfx/pggat/lib/gat"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
 	"gfx.cafe/gfx/pggat/lib/gat/metrics"
+	"gfx.cafe/gfx/pggat/lib/instrumentation/prom"
 	"gfx.cafe/gfx/pggat/lib/util/maps"
 	"gfx.cafe/gfx/pggat/lib/util/slices"
 	"gfx.cafe/gfx/pggat/lib/util/strutil"
@@ -26,6 +29,9 @@ func init() {
 	caddy.RegisterModule((*Module)(nil))
 }
 
+// drainPollInterval is how often a draining pool is checked for remaining clients
+const drainPollInterval = 100 * time.Millisecond
+
 type poolAndCredentials struct {
 	pool  pool.Pool
 	creds auth.Credentials
@@ -35,8 +41,10 @@ type Module struct {
 	Config
 
 	discoverer Discoverer
+	filters    []Filter
 
 	poolFactory pool.PoolFactory
+	poolModes   map[string]pool.PoolFactory
 
 	sslConfig *tls.Config
 
@@ -47,13 +55,26 @@ type Module struct {
 	// this is fine to have no locking because it is only accessed by discoverLoop
 	clusters map[string]Cluster
 	creds    map[User]auth.Credentials
+	// pending are clusters waiting for the debounce period before being applied
+	pending map[string]pendingCluster
+	// removing are clusters waiting for the grace period before being removed
+	removing map[string]time.Time
+
+	pools maps.TwoKey[string, string, poolAndCredentials]
+	// draining are removed pools waiting for their clients to leave before being closed
+	draining map[pool.Pool]struct{}
+	poolsMu  sync.RWMutex
 
-	pools   maps.TwoKey[string, string, poolAndCredentials]
-	poolsMu sync.RWMutex
+	labels prom.DiscoveryLabels
 
 	log *zap.Logger
 }
 
+type pendingCluster struct {
+	cluster Cluster
+	at      time.Time
+}
+
 func (*Module) CaddyModule() caddy.ModuleInfo {
 	return caddy.ModuleInfo{
 		ID: "pggat.handlers.discovery",
@@ -72,6 +93,18 @@ func (T *Module) Provision(ctx caddy.Context) error {
 			return fmt.Errorf("loading discoverer module: %v", err)
 		}
 		T.discoverer = val.(Discoverer)
+		if m, ok := val.(caddy.Module); ok {
+			T.labels.Discoverer = m.CaddyModule().ID.Name()
+		}
+	}
+	if T.Filters != nil {
+		val, err := ctx.LoadModule(T, "Filters")
+		if err != nil {
+			return fmt.Errorf("loading filter modules: %v", err)
+		}
+		for _, filter := range val.([]any) {
+			T.filters = append(T.filters, filter.(Filter))
+		}
 	}
 	if T.Pool != nil {
 		val, err := ctx.LoadModule(T, "Pool")
@@ -80,6 +113,16 @@ func (T *Module) Provision(ctx caddy.Context) error {
 		}
 		T.poolFactory = val.(pool.PoolFactory)
 	}
+	if T.PoolModes != nil {
+		val, err := ctx.LoadModule(T, "PoolModes")
+		if err != nil {
+			return fmt.Errorf("loading pool modes: %v", err)
+		}
+		T.poolModes = make(map[string]pool.PoolFactory)
+		for mode, factory := range val.(map[string]any) {
+			T.poolModes[mode] = factory.(pool.PoolFactory)
+		}
+	}
 	if T.ServerSSL != nil {
 		val, err := ctx.LoadModule(T, "ServerSSL")
 		if err != nil {
@@ -120,30 +163,61 @@ func (T *Module) Cleanup() error {
 		T.pools.Delete(user, database)
 		return true
 	})
+	for p := range T.draining {
+		p.Close(context.Background())
+	}
+	prom.Discovery.Draining(T.labels).Sub(float64(len(T.draining)))
+	T.draining = nil
 	return nil
 }
 
+// excludeUsers removes the users excluded by the cluster's overrides
+func excludeUsers(cluster Cluster) Cluster {
+	if len(cluster.Overrides.ExcludeUsers) == 0 {
+		return cluster
+	}
+
+	users := make([]User, 0, len(cluster.Users))
+	for _, user := range cluster.Users {
+		if !cluster.Overrides.Excluded(user.Username) {
+			users = append(users, user)
+		}
+	}
+	cluster.Users = users
+	return cluster
+}
+
 func (T *Module) added(ctx context.Context, cluster Cluster) {
 	if prev, ok := T.clusters[cluster.ID]; ok {
 		T.updated(ctx, prev, cluster)
 		return
 	}
+	cluster = excludeUsers(cluster)
 	if T.clusters == nil {
 		T.clusters = make(map[string]Cluster)
 	}
 	T.clusters[cluster.ID] = cluster
 
 	for _, user := range cluster.Users {
-		T.addUser(ctx, cluster.Primary, cluster.Replicas, cluster.Databases, user)
+		T.addUser(ctx, cluster.Overrides, cluster.Primary, cluster.Replicas, cluster.Databases, user)
 	}
 }
 
 func (T *Module) updated(ctx context.Context, prev, next Cluster) {
+	next = excludeUsers(next)
+
+	if !reflect.DeepEqual(prev.Overrides, next.Overrides) {
+		// overrides change every pool and recipe of the cluster, rebuild it
+		T.removed(ctx, prev.ID)
+		T.added(ctx, next)
+		return
+	}
+
 	T.clusters[next.ID] = next
 
 	// primary endpoints
 	if prev.Primary != next.Primary {
-		T.replacePrimary(ctx, prev.Users, prev.Databases, next.Primary)
+		T.replacePrimary(ctx, next.Overrides, prev.Users, prev.Databases, next.Primary)
 	}
 
 	// replica endpoints
@@ -151,17 +225,17 @@ func (T *Module) updated(ctx context.Context, prev, next Cluster) {
 	case len(prev.Replicas) != 0 && len(next.Replicas) == 0:
 		T.removeReplicas(ctx, prev.Replicas, prev.Users, prev.Databases)
 	case len(prev.Replicas) == 0 && len(next.Replicas) != 0:
-		T.addReplicas(ctx, next.Replicas, prev.Users, prev.Databases)
+		T.addReplicas(ctx, next.Overrides, next.Replicas, prev.Users, prev.Databases)
 	default:
 		// change # of replicas
 
 		for id, nextReplica := range next.Replicas {
 			prevReplica, ok := prev.Replicas[id]
 			if !ok {
-				T.addReplica(ctx, prev.Users, prev.Databases, id, nextReplica)
+				T.addReplica(ctx, next.Overrides, prev.Users, prev.Databases, id, nextReplica)
 			} else if prevReplica != nextReplica {
 				// don't need to remove, add will replace the recipe atomically
-				T.addReplica(ctx, prev.Users, prev.Databases, id, nextReplica)
+				T.addReplica(ctx, next.Overrides, prev.Users, prev.Databases, id, nextReplica)
 			}
 		}
 		for id := range prev.Replicas {
@@ -188,10 +262,10 @@ func (T *Module) updated(ctx context.Context, prev, next Cluster) {
 		}
 
 		if !ok {
-			T.addUser(ctx, next.Primary, next.Replicas, prev.Databases, nextUser)
+			T.addUser(ctx, next.Overrides, next.Primary, next.Replicas, prev.Databases, nextUser)
 		} else if nextUser.Password != prevUser.Password {
 			T.removeUser(ctx, next.Replicas, prev.Databases, nextUser.Username)
-			T.addUser(ctx, next.Primary, next.Replicas, prev.Databases, nextUser)
+			T.addUser(ctx, next.Overrides, next.Primary, next.Replicas, prev.Databases, nextUser)
 		}
 	}
 outer:
@@ -207,7 +281,7 @@ outer:
 
 	for _, nextDatabase := range next.Databases {
 		if !slices.Contains(prev.Databases, nextDatabase) {
-			T.addDatabase(ctx, next.Primary, next.Replicas, next.Users, nextDatabase)
+			T.addDatabase(ctx, next.Overrides, next.Primary, next.Replicas, next.Users, nextDatabase)
 		}
 	}
 	for _, prevDatabase := range prev.Databases {
@@ -217,23 +291,54 @@ outer:
 	}
 }
 
-func (T *Module) addPrimaryNode(ctx context.Context, user User, database string, primary Node) {
-	p := T.getOrAddPool(ctx, user, database)
+// recipe builds the recipe for a node, applying the cluster's overrides over the config
+func (T *Module) recipe(overrides Overrides, p poolAndCredentials, user User, database string, node Node) pool.Recipe {
+	sslMode := T.ServerSSLMode
+	if overrides.SSLMode != "" {
+		sslMode = overrides.SSLMode
+	}
+
+	parameters := T.serverStartupParameters
+	if len(overrides.Parameters) != 0 {
+		parameters = maps.Clone(T.serverStartupParameters)
+		if parameters == nil {
+			parameters = make(map[strutil.CIString]string, len(overrides.Parameters))
+		}
+		for key, value := range overrides.Parameters {
+			parameters[strutil.MakeCIString(key)] = value
+		}
+	}
 
-	d := pool.Recipe{
+	minConnections := T.ServerMinConnections
+	if overrides.MinConnections != nil {
+		minConnections = *overrides.MinConnections
+	}
+	maxConnections := T.ServerMaxConnections
+	if overrides.MaxConnections != nil {
+		maxConnections = *overrides.MaxConnections
+	}
+
+	return pool.Recipe{
 		Dialer: pool.Dialer{
-			Address:     primary.Address,
+			Address:     node.Address,
 			Username:    user.Username,
 			Credentials: p.creds,
-			Database:    database,
-			SSLMode:     T.ServerSSLMode,
+			Database:    overrides.ServerDatabase(database),
+			SSLMode:     sslMode,
 			SSLConfig:   T.sslConfig,
-			Parameters:  T.serverStartupParameters,
+			Parameters:  parameters,
 		},
-		Priority:       primary.Priority,
-		MinConnections: T.ServerMinConnections,
-		MaxConnections: T.ServerMaxConnections,
+		Priority:       node.Priority,
+		Weight:         node.Weight,
+		MinConnections: minConnections,
+		MaxConnections: maxConnections,
 	}
+}
+
+func (T *Module) addPrimaryNode(ctx context.Context, overrides Overrides, user User, database string, primary Node) {
+	p := T.getOrAddPool(ctx, overrides, user, database)
+
+	d := T.recipe(overrides, p, user, database, primary)
 	p.pool.AddRecipe(ctx, "primary", &d)
 }
 
@@ -241,46 +346,20 @@ func (T *Module) removePrimaryNode(username, database string) {
 	T.removePool(username, database)
 }
 
-func (T *Module) addReplicaNodes(ctx context.Context, user User, database string, replicas map[string]Node) {
-	p := T.getOrAddPool(ctx, user, database)
+func (T *Module) addReplicaNodes(ctx context.Context, overrides Overrides, user User, database string, replicas map[string]Node) {
+	p := T.getOrAddPool(ctx, overrides, user, database)
 
 	if rp, ok := p.pool.(pool.ReplicaPool); ok {
 		for id, replica := range replicas {
-			d := pool.Recipe{
-				Dialer: pool.Dialer{
-					Address:     replica.Address,
-					Username:    user.Username,
-					Credentials: p.creds,
-					Database:    database,
-					SSLMode:     T.ServerSSLMode,
-					SSLConfig:   T.sslConfig,
-					Parameters:  T.serverStartupParameters,
-				},
-				Priority:       replica.Priority,
-				MinConnections: T.ServerMinConnections,
-				MaxConnections: T.ServerMaxConnections,
-			}
+			d := T.recipe(overrides, p, user, database, replica)
 			rp.AddReplicaRecipe(ctx, id, &d)
 		}
 		return
 	}
 
-	rp := T.getOrAddReplicaPool(ctx, user, database)
+	rp := T.getOrAddReplicaPool(ctx, overrides, user, database)
 	for id, replica := range replicas {
-		d := pool.Recipe{
-			Dialer: pool.Dialer{
-				Address:     replica.Address,
-				Username:    user.Username,
-				Credentials: p.creds,
-				Database:    database,
-				SSLMode:     T.ServerSSLMode,
-				SSLConfig:   T.sslConfig,
-				Parameters:  T.serverStartupParameters,
-			},
-			Priority:       replica.Priority,
-			MinConnections: T.ServerMinConnections,
-			MaxConnections: T.ServerMaxConnections,
-		}
+		d := T.recipe(overrides, p, user, database, replica)
 		rp.pool.AddRecipe(ctx, id, &d)
 	}
 }
@@ -303,30 +382,17 @@ func (T *Module) removeReplicaNodes(ctx context.Context, username string, databa
 	T.removeReplicaPool(username, database)
 }
 
-func (T *Module) addReplicaNode(ctx context.Context, user User, database string, id string, replica Node) {
-	p := T.getOrAddPool(ctx, user, database)
+func (T *Module) addReplicaNode(ctx context.Context, overrides Overrides, user User, database string, id string, replica Node) {
+	p := T.getOrAddPool(ctx, overrides, user, database)
 
-	d := pool.Recipe{
-		Dialer: pool.Dialer{
-			Address:     replica.Address,
-			Username:    user.Username,
-			Credentials: p.creds,
-			Database:    database,
-			SSLMode:     T.ServerSSLMode,
-			SSLConfig:   T.sslConfig,
-			Parameters:  T.serverStartupParameters,
-		},
-		Priority:       replica.Priority,
-		MinConnections: T.ServerMinConnections,
-		MaxConnections: T.ServerMaxConnections,
-	}
+	d := T.recipe(overrides, p, user, database, replica)
 
 	if rp, ok := p.pool.(pool.ReplicaPool); ok {
 		rp.AddReplicaRecipe(ctx, id, &d)
 		return
 	}
 
-	rp := T.getOrAddReplicaPool(ctx, user, database)
+	rp := T.getOrAddReplicaPool(ctx, overrides, user, database)
 	rp.pool.AddRecipe(ctx, id, &d)
 }
 
@@ -351,19 +417,19 @@ func (T *Module) removeReplicaNode(ctx context.Context, username string, databas
 }
 
 // replacePrimary replaces the primary endpoint.
-func (T *Module) replacePrimary(ctx context.Context, users []User, databases []string, primary Node) {
+func (T *Module) replacePrimary(ctx context.Context, overrides Overrides, users []User, databases []string, primary Node) {
 	for _, user := range users {
 		for _, database := range databases {
-			T.addPrimaryNode(ctx, user, database, primary)
+			T.addPrimaryNode(ctx, overrides, user, database, primary)
 		}
 	}
 }
 
 // addReplicas adds multiple replicas. Other replicas must not exist.
-func (T *Module) addReplicas(ctx context.Context, replicas map[string]Node, users []User, databases []string) {
+func (T *Module) addReplicas(ctx context.Context, overrides Overrides, replicas map[string]Node, users []User, databases []string) {
 	for _, user := range users {
 		for _, database := range databases {
-			T.addReplicaNodes(ctx, user, database, replicas)
+			T.addReplicaNodes(ctx, overrides, user, database, replicas)
 		}
 	}
 }
@@ -378,10 +444,10 @@ func (T *Module) removeReplicas(ctx context.Context, replicas map[string]Node, u
 }
 
 // addReplica adds a single replica.
-func (T *Module) addReplica(ctx context.Context, users []User, databases []string, id string, replica Node) {
+func (T *Module) addReplica(ctx context.Context, overrides Overrides, users []User, databases []string, id string, replica Node) {
 	for _, user := range users {
 		for _, database := range databases {
-			T.addReplicaNode(ctx, user, database, id, replica)
+			T.addReplicaNode(ctx, overrides, user, database, id, replica)
 		}
 	}
 }
@@ -396,10 +462,10 @@ func (T *Module) removeReplica(ctx context.Context, users []User, databases []st
 }
 
 // addUser adds a new user.
-func (T *Module) addUser(ctx context.Context, primary Node, replicas map[string]Node, databases []string, user User) {
+func (T *Module) addUser(ctx context.Context, overrides Overrides, primary Node, replicas map[string]Node, databases []string, user User) {
 	for _, database := range databases {
-		T.addPrimaryNode(ctx, user, database, primary)
-		T.addReplicaNodes(ctx, user, database, replicas)
+		T.addPrimaryNode(ctx, overrides, user, database, primary)
+		T.addReplicaNodes(ctx, overrides, user, database, replicas)
 	}
 }
 
@@ -412,10 +478,10 @@ func (T *Module) removeUser(ctx context.Context, replicas map[string]Node, datab
 }
 
 // addDatabase adds a new database.
-func (T *Module) addDatabase(ctx context.Context, primary Node, replicas map[string]Node, users []User, database string) {
+func (T *Module) addDatabase(ctx context.Context, overrides Overrides, primary Node, replicas map[string]Node, users []User, database string) {
 	for _, user := range users {
-		T.addPrimaryNode(ctx, user, database, primary)
-		T.addReplicaNodes(ctx, user, database, replicas)
+		T.addPrimaryNode(ctx, overrides, user, database, primary)
+		T.addReplicaNodes(ctx, overrides, user, database, replicas)
 	}
 }
 
@@ -439,32 +505,204 @@ func (T *Module) removed(ctx context.Context, id string) {
 	}
 }
 
+// filter applies the filters to cluster. Returns false if the cluster was dropped
+func (T *Module) filter(cluster Cluster) (Cluster, bool) {
+	for _, filter := range T.filters {
+		var ok bool
+		cluster, ok = filter.Filter(cluster)
+		if !ok {
+			return cluster, false
+		}
+	}
+	return cluster, true
+}
+
+// set adds or updates a cluster, canceling its removal. Returns what changed and whether the cluster is new
+func (T *Module) set(ctx context.Context, cluster Cluster) (clusterDiff, bool) {
+	T.cancelRemoval(cluster.ID)
+
+	prev, ok := T.clusters[cluster.ID]
+	if !ok {
+		T.added(ctx, cluster)
+		prom.Discovery.Added(T.labels).Inc()
+		prom.Discovery.Clusters(T.labels).Set(float64(len(T.clusters)))
+		return clusterDiff{ID: cluster.ID}, true
+	}
+
+	diff := diffCluster(prev, excludeUsers(cluster))
+	T.updated(ctx, prev, cluster)
+	if !diff.Empty() {
+		prom.Discovery.Changed(T.labels).Inc()
+	}
+	return diff, false
+}
+
+// apply sets a cluster from a discoverer event and logs what changed
+func (T *Module) apply(ctx context.Context, cluster Cluster) {
+	diff, added := T.set(ctx, cluster)
+	switch {
+	case added:
+		T.log.Info("added cluster", zap.String("cluster", cluster.ID))
+	case !diff.Empty():
+		T.log.Info("updated cluster", zap.Object("diff", &diff))
+	}
+}
+
+// debounce applies the cluster once it has stopped changing for the debounce period
+func (T *Module) debounce(ctx context.Context, cluster Cluster) {
+	// the cluster is back, keep its pools while waiting
+	T.cancelRemoval(cluster.ID)
+
+	if T.Debounce == 0 {
+		T.apply(ctx, cluster)
+		return
+	}
+
+	if T.pending == nil {
+		T.pending = make(map[string]pendingCluster)
+	}
+	T.pending[cluster.ID] = pendingCluster{
+		cluster: cluster,
+		at:      time.Now().Add(time.Duration(T.Debounce)),
+	}
+}
+
+// remove removes a cluster now
+func (T *Module) remove(ctx context.Context, id string) {
+	if _, ok := T.clusters[id]; !ok {
+		return
+	}
+	T.removed(ctx, id)
+	prom.Discovery.Removed(T.labels).Inc()
+	prom.Discovery.Clusters(T.labels).Set(float64(len(T.clusters)))
+	T.log.Info("removed cluster", zap.String("cluster", id))
+}
+
+// scheduleRemoval removes a cluster once the grace period has passed. Returns false if the cluster is unknown or
+// already being removed
+func (T *Module) scheduleRemoval(ctx context.Context, id string) bool {
+	delete(T.pending, id)
+
+	if _, ok := T.clusters[id]; !ok {
+		return false
+	}
+	if _, ok := T.removing[id]; ok {
+		return false
+	}
+
+	if T.RemoveGracePeriod == 0 {
+		T.remove(ctx, id)
+		return true
+	}
+
+	if T.removing == nil {
+		T.removing = make(map[string]time.Time)
+	}
+	T.removing[id] = time.Now().Add(time.Duration(T.RemoveGracePeriod))
+	T.log.Info("cluster disappeared, removing after grace period", zap.String("cluster", id), zap.Duration("grace_period", time.Duration(T.RemoveGracePeriod)))
+	return true
+}
+
+func (T *Module) cancelRemoval(id string) {
+	if _, ok := T.removing[id]; !ok {
+		return
+	}
+	delete(T.removing, id)
+	T.log.Info("cluster reappeared, canceled removal", zap.String("cluster", id))
+}
+
+// flush applies pending clusters and removals which are due
+func (T *Module) flush(ctx context.Context, now time.Time) {
+	for id, p := range T.pending {
+		if p.at.After(now) {
+			continue
+		}
+		delete(T.pending, id)
+		T.apply(ctx, p.cluster)
+	}
+	for id, at := range T.removing {
+		if at.After(now) {
+			continue
+		}
+		delete(T.removing, id)
+		T.remove(ctx, id)
+	}
+}
+
+// deadline returns when the next pending cluster or removal is due
+func (T *Module) deadline() (time.Time, bool) {
+	var next time.Time
+	for _, p := range T.pending {
+		if next.IsZero() || p.at.Before(next) {
+			next = p.at
+		}
+	}
+	for _, at := range T.removing {
+		if next.IsZero() || at.Before(next) {
+			next = at
+		}
+	}
+	return next, !next.IsZero()
+}
+
 func (T *Module) reconcile(ctx context.Context) error {
-	clusters, err := T.discoverer.Clusters()
+	discovered, err := T.discoverer.Clusters()
 	if err != nil {
 		return err
 	}
 
-	for _, cluster := range clusters {
-		prev, ok := T.clusters[cluster.ID]
+	present := make(map[string]struct{}, len(discovered))
+	var added, removed []string
+	var changed []*clusterDiff
+
+	for _, cluster := range discovered {
+		cluster, ok := T.filter(cluster)
 		if !ok {
-			T.added(ctx, cluster)
-		} else {
-			T.updated(ctx, prev, cluster)
+			continue
+		}
+		present[cluster.ID] = struct{}{}
+
+		// the discoverer's current view supersedes any pending event
+		delete(T.pending, cluster.ID)
+
+		diff, isNew := T.set(ctx, cluster)
+		if isNew {
+			added = append(added, cluster.ID)
+		} else if !diff.Empty() {
+			changed = append(changed, &diff)
 		}
 	}
 
 	// remove old clusters
-outer:
+	for id := range T.pending {
+		if _, ok := present[id]; !ok {
+			delete(T.pending, id)
+		}
+	}
 	for id := range T.clusters {
-		for _, cluster := range clusters {
-			if cluster.ID == id {
-				continue outer
-			}
+		if _, ok := present[id]; ok {
+			continue
+		}
+		if T.scheduleRemoval(ctx, id) {
+			removed = append(removed, id)
 		}
-		T.removed(ctx, id)
 	}
 
+	sort.Strings(added)
+	sort.Strings(removed)
+
+	level := zap.DebugLevel
+	if len(added) != 0 || len(removed) != 0 || len(changed) != 0 {
+		level = zap.InfoLevel
+	}
+	T.log.Log(
+		level,
+		"reconciled clusters",
+		zap.Strings("added", added),
+		zap.Strings("removed", removed),
+		zap.Objects("changed", changed),
+	)
+
 	return nil
 }
 
@@ -476,12 +714,30 @@ func (T *Module) discoverLoop(ctx context.Context) {
 
 		reconcile = r.C
 	}
+
+	wake := time.NewTimer(0)
+	wake.Stop()
+	defer wake.Stop()
+
 	for {
+		var due <-chan time.Time
+		if next, ok := T.deadline(); ok {
+			wake.Reset(time.Until(next))
+			due = wake.C
+		}
+
 		select {
 		case cluster := <-T.discoverer.Added():
-			T.added(ctx, cluster)
+			if filtered, ok := T.filter(cluster); ok {
+				T.debounce(ctx, filtered)
+			} else {
+				// the cluster may have been allowed before it changed
+				T.scheduleRemoval(ctx, cluster.ID)
+			}
 		case id := <-T.discoverer.Removed():
-			T.removed(ctx, id)
+			T.scheduleRemoval(ctx, id)
+		case now := <-due:
+			T.flush(ctx, now)
 		case <-reconcile:
 			err := T.reconcile(ctx)
 			if err != nil {
@@ -514,16 +770,25 @@ func (T *Module) getCreds(user User) auth.Credentials {
 	return creds
 }
 
-func (T *Module) getOrAddPool(ctx context.Context, user User, database string) poolAndCredentials {
+func (T *Module) getOrAddPool(ctx context.Context, overrides Overrides, user User, database string) poolAndCredentials {
 	T.poolsMu.Lock()
 	defer T.poolsMu.Unlock()
 	if old, ok := T.pools.Load(user.Username, database); ok {
 		return old
 	}
 
+	factory := T.poolFactory
+	if overrides.PoolMode != "" {
+		if f, ok := T.poolModes[overrides.PoolMode]; ok {
+			factory = f
+		} else {
+			T.log.Warn("unknown pool mode, using the default pool", zap.String("pool_mode", overrides.PoolMode))
+		}
+	}
+
 	creds := T.getCreds(user)
 	p := poolAndCredentials{
-		pool:  T.poolFactory.NewPool(ctx),
+		pool:  factory.NewPool(ctx),
 		creds: creds,
 	}
 	T.pools.Store(user.Username, database, p)
@@ -531,8 +796,8 @@ func (T *Module) getOrAddPool(ctx context.Context, user User, database string) p
 	return p
 }
 
-func (T *Module) getOrAddReplicaPool(ctx context.Context, user User, database string) poolAndCredentials {
-	return T.getOrAddPool(ctx, T.toReplicaUser(user), database)
+func (T *Module) getOrAddReplicaPool(ctx context.Context, overrides Overrides, user User, database string) poolAndCredentials {
+	return T.getOrAddPool(ctx, overrides, T.toReplicaUser(user), database)
 }
 
 func (T *Module) getPool(user, database string) (poolAndCredentials, bool) {
@@ -552,9 +817,74 @@ func (T *Module) removePool(user, database string) {
 	if !ok {
 		return
 	}
-	p.pool.Close(context.Background())
-	T.log.Info("removed pool", zap.String("user", user), zap.String("database", database))
 	T.pools.Delete(user, database)
+
+	if T.DrainTimeout == 0 {
+		p.pool.Close(context.Background())
+		T.log.Info("removed pool", zap.String("user", user), zap.String("database", database))
+		return
+	}
+
+	if T.draining == nil {
+		T.draining = make(map[pool.Pool]struct{})
+	}
+	T.draining[p.pool] = struct{}{}
+	prom.Discovery.Draining(T.labels).Inc()
+	T.log.Info("draining pool", zap.String("user", user), zap.String("database", database))
+
+	go T.drain(p.pool, user, database)
+}
+
+func (T *Module) isDraining(p pool.Pool) bool {
+	T.poolsMu.RLock()
+	defer T.poolsMu.RUnlock()
+	_, ok := T.draining[p]
+	return ok
+}
+
+// waitForClients waits until p has no clients or the drain timeout passes. Returns the number of clients left
+func (T *Module) waitForClients(p pool.Pool) int {
+	timeout := time.NewTimer(time.Duration(T.DrainTimeout))
+	defer timeout.Stop()
+	poll := time.NewTicker(drainPollInterval)
+	defer poll.Stop()
+
+	for {
+		var m metrics.Pool
+		p.ReadMetrics(context.Background(), &m)
+		if len(m.Clients) == 0 {
+			return 0
+		}
+
+		select {
+		case <-timeout.C:
+			return len(m.Clients)
+		case <-poll.C:
+			if !T.isDraining(p) {
+				return 0
+			}
+		}
+	}
+}
+
+// drain closes a removed pool once its clients have left
+func (T *Module) drain(p pool.Pool, user, database string) {
+	if clients := T.waitForClients(p); clients != 0 {
+		T.log.Warn("timed out draining pool, closing it", zap.String("user", user), zap.String("database", database), zap.Int("clients", clients))
+	}
+
+	T.poolsMu.Lock()
+	_, ok := T.draining[p]
+	delete(T.draining, p)
+	T.poolsMu.Unlock()
+	if !ok {
+		// already closed by Cleanup
+		return
+	}
+
+	p.Close(context.Background())
+	prom.Discovery.Draining(T.labels).Dec()
+	T.log.Info("removed pool", zap.String("user", user), zap.String("database", database))
 }
 
 func (T *Module) removeReplicaPool(user, database string) {
@@ -568,6 +898,9 @@ func (T *Module) ReadMetrics(ctx context.Context, metrics *metrics.Handler) {
 		p.pool.ReadMetrics(ctx, &metrics.Pool)
 		return true
 	})
+	for p := range T.draining {
+		p.ReadMetrics(ctx, &metrics.Pool)
+	}
 }
 
 func (T *Module) Handle(next gat.Router) gat.Router {
@@ -590,6 +923,9 @@ func (T *Module) Cancel(ctx context.Context, key fed.BackendKey) {
 		p.pool.Cancel(ctx, key)
 		return true
 	})
+	for p := range T.draining {
+		p.Cancel(ctx, key)
+	}
 }
 
 var _ gat.Handler = (*Module)(nil)
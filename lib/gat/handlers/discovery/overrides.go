@@ -0,0 +1,143 @@
+package discovery
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gfx.cafe/gfx/pggat/lib/bouncer"
+)
+
+// AnnotationPrefix is the prefix of every Kubernetes annotation read by pggat
+const AnnotationPrefix = "pggat.io/"
+
+// OverridesPrefix is the prefix of Kubernetes annotations with per-cluster overrides, like pggat.io/max-connections
+const OverridesPrefix = AnnotationPrefix
+
+// OverridesTagPrefix is the prefix of cloud provider tags with per-cluster overrides, like pggat:max-connections:50
+const OverridesTagPrefix = "pggat:"
+
+// Overrides are per-cluster settings which take precedence over the discovery config. Zero values use the config
+type Overrides struct {
+	// MinConnections overrides server_min_connections
+	MinConnections *int
+	// MaxConnections overrides server_max_connections
+	MaxConnections *int
+
+	// SSLMode overrides server_ssl_mode
+	SSLMode bouncer.SSLMode
+
+	// Parameters are set over server_startup_parameters
+	Parameters map[string]string
+
+	// PoolMode picks one of the config's pool_modes instead of the default pool
+	PoolMode string
+
+	// ExcludeUsers don't get a pool
+	ExcludeUsers []string
+
+	// ServerDatabases are the names on the server of databases which clients connect to under another name
+	ServerDatabases map[string]string
+}
+
+// ParseOverrides reads overrides from key value pairs such as annotations. Keys without prefix are ignored. Invalid
+// values are skipped and returned as an error, the rest are still applied
+//
+//	<prefix>min-connections: 1
+//	<prefix>max-connections: 50
+//	<prefix>ssl-mode: require
+//	<prefix>pool-mode: session
+//	<prefix>exclude-users: postgres,standby
+//	<prefix>parameter.<name>: value
+func ParseOverrides(prefix string, values map[string]string) (Overrides, error) {
+	var overrides Overrides
+	var errs []error
+
+	for key, value := range values {
+		name, ok := strings.CutPrefix(key, prefix)
+		if !ok {
+			continue
+		}
+
+		switch name {
+		case "min-connections", "max-connections":
+			v, err := strconv.Atoi(value)
+			if err != nil || v < 0 {
+				errs = append(errs, fmt.Errorf("invalid %s: %q", key, value))
+				continue
+			}
+			if name == "min-connections" {
+				overrides.MinConnections = &v
+			} else {
+				overrides.MaxConnections = &v
+			}
+		case "ssl-mode":
+			mode := bouncer.SSLMode(value)
+			switch mode {
+			case bouncer.SSLModeDisable, bouncer.SSLModeAllow, bouncer.SSLModePrefer, bouncer.SSLModeRequire,
+				bouncer.SSLModeVerifyCa, bouncer.SSLModeVerifyFull:
+				overrides.SSLMode = mode
+			default:
+				errs = append(errs, fmt.Errorf("invalid %s: %q", key, value))
+			}
+		case "pool-mode":
+			overrides.PoolMode = value
+		case "exclude-users":
+			for _, user := range strings.Split(value, ",") {
+				if user = strings.TrimSpace(user); user != "" {
+					overrides.ExcludeUsers = append(overrides.ExcludeUsers, user)
+				}
+			}
+			slices.Sort(overrides.ExcludeUsers)
+		default:
+			parameter, ok := strings.CutPrefix(name, "parameter.")
+			if !ok || parameter == "" {
+				errs = append(errs, fmt.Errorf("unknown override %s", key))
+				continue
+			}
+			if overrides.Parameters == nil {
+				overrides.Parameters = make(map[string]string)
+			}
+			overrides.Parameters[parameter] = value
+		}
+	}
+
+	return overrides, errors.Join(errs...)
+}
+
+// ParseOverrideTags reads overrides from tags like <prefix>max-connections:50, for providers which only have tags.
+// Repeated tags are joined, so <prefix>exclude-users:a and <prefix>exclude-users:b exclude both users
+func ParseOverrideTags(prefix string, tags []string) (Overrides, error) {
+	values := make(map[string]string)
+	for _, tag := range tags {
+		name, ok := strings.CutPrefix(tag, prefix)
+		if !ok {
+			continue
+		}
+		key, value, ok := strings.Cut(name, ":")
+		if !ok {
+			continue
+		}
+		if prev, ok := values[prefix+key]; ok {
+			value = prev + "," + value
+		}
+		values[prefix+key] = value
+	}
+
+	return ParseOverrides(prefix, values)
+}
+
+// ServerDatabase returns the name of database on the server
+func (T *Overrides) ServerDatabase(database string) string {
+	if name, ok := T.ServerDatabases[database]; ok {
+		return name
+	}
+	return database
+}
+
+// Excluded returns whether the user shouldn't get a pool
+func (T *Overrides) Excluded(username string) bool {
+	return slices.Contains(T.ExcludeUsers, username)
+}
@@ -0,0 +1,185 @@
+package discovery
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/bouncer"
+	"gfx.cafe/gfx/pggat/lib/fed"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
+	"gfx.cafe/gfx/pggat/lib/gat/metrics"
+	"gfx.cafe/gfx/pggat/lib/util/strutil"
+)
+
+func TestParseOverrides(t *testing.T) {
+	overrides, err := ParseOverrides(OverridesPrefix, map[string]string{
+		"pggat.io/min-connections":       "1",
+		"pggat.io/max-connections":       "20",
+		"pggat.io/ssl-mode":              "require",
+		"pggat.io/pool-mode":             "session",
+		"pggat.io/exclude-users":         "standby, postgres",
+		"pggat.io/parameter.search_path": "app",
+		"example.com/other":              "ignored",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	one, twenty := 1, 20
+	expected := Overrides{
+		MinConnections: &one,
+		MaxConnections: &twenty,
+		SSLMode:        bouncer.SSLModeRequire,
+		Parameters:     map[string]string{"search_path": "app"},
+		PoolMode:       "session",
+		ExcludeUsers:   []string{"postgres", "standby"},
+	}
+	if !reflect.DeepEqual(overrides, expected) {
+		t.Fatalf("expected %+v but got %+v", expected, overrides)
+	}
+
+	overrides, err = ParseOverrides(OverridesPrefix, map[string]string{
+		"pggat.io/max-connections": "lots",
+		"pggat.io/pool-mode":       "session",
+	})
+	if err == nil {
+		t.Fatal("expected an error for an invalid value")
+	}
+	if overrides.MaxConnections != nil || overrides.PoolMode != "session" {
+		t.Fatalf("expected only the valid override to be applied but got %+v", overrides)
+	}
+}
+
+func TestParseOverrideTags(t *testing.T) {
+	overrides, err := ParseOverrideTags(OverridesTagPrefix, []string{
+		"production",
+		"pggat:max-connections:20",
+		"pggat:exclude-users:doadmin",
+		"pggat:exclude-users:analytics",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	twenty := 20
+	expected := Overrides{
+		MaxConnections: &twenty,
+		ExcludeUsers:   []string{"analytics", "doadmin"},
+	}
+	if !reflect.DeepEqual(overrides, expected) {
+		t.Fatalf("expected %+v but got %+v", expected, overrides)
+	}
+}
+
+type testPool struct {
+	mode    string
+	recipes map[string]*pool.Recipe
+}
+
+func (T *testPool) AddRecipe(_ context.Context, name string, recipe *pool.Recipe) {
+	T.recipes[name] = recipe
+}
+
+func (T *testPool) RemoveRecipe(_ context.Context, name string) {
+	delete(T.recipes, name)
+}
+
+func (T *testPool) PrimaryRecipe() (*pool.Recipe, bool) {
+	return nil, false
+}
+
+func (T *testPool) Serve(context.Context, *fed.Conn) error {
+	return nil
+}
+
+func (T *testPool) Cancel(context.Context, fed.BackendKey) {}
+
+func (T *testPool) ReadMetrics(context.Context, *metrics.Pool) {}
+
+func (T *testPool) Close(context.Context) {}
+
+type testPoolFactory string
+
+func (T testPoolFactory) NewPool(context.Context) pool.Pool {
+	return &testPool{
+		mode:    string(T),
+		recipes: make(map[string]*pool.Recipe),
+	}
+}
+
+func TestModuleOverrides(t *testing.T) {
+	ctx := context.Background()
+
+	m := Module{
+		Config: Config{
+			ServerSSLMode:        bouncer.SSLModePrefer,
+			ServerMaxConnections: 100,
+		},
+		poolFactory: testPoolFactory("transaction"),
+		poolModes: map[string]pool.PoolFactory{
+			"session": testPoolFactory("session"),
+		},
+		serverStartupParameters: map[strutil.CIString]string{
+			strutil.MakeCIString("application_name"): "pggat",
+		},
+		log: zap.NewNop(),
+	}
+
+	cluster := Cluster{
+		ID:        "main",
+		Primary:   Node{Address: "10.0.0.1:5432"},
+		Databases: []string{"app"},
+		Users: []User{
+			{Username: "app", Password: "secret"},
+			{Username: "postgres", Password: "secret"},
+		},
+	}
+	m.added(ctx, cluster)
+
+	p, ok := m.getPool("app", "app")
+	if !ok {
+		t.Fatal("expected pool for app")
+	}
+	if mode := p.pool.(*testPool).mode; mode != "transaction" {
+		t.Fatalf("expected default pool but got %s", mode)
+	}
+	if _, ok = m.getPool("postgres", "app"); !ok {
+		t.Fatal("expected pool for postgres")
+	}
+
+	twenty := 20
+	cluster.Overrides = Overrides{
+		MaxConnections: &twenty,
+		SSLMode:        bouncer.SSLModeRequire,
+		Parameters:     map[string]string{"search_path": "app"},
+		PoolMode:       "session",
+		ExcludeUsers:   []string{"postgres"},
+	}
+	m.updated(ctx, m.clusters[cluster.ID], cluster)
+
+	if _, ok = m.getPool("postgres", "app"); ok {
+		t.Fatal("expected excluded user to have no pool")
+	}
+	p, ok = m.getPool("app", "app")
+	if !ok {
+		t.Fatal("expected pool for app")
+	}
+	tp := p.pool.(*testPool)
+	if tp.mode != "session" {
+		t.Fatalf("expected session pool but got %s", tp.mode)
+	}
+	recipe := tp.recipes["primary"]
+	if recipe.MaxConnections != 20 || recipe.SSLMode != bouncer.SSLModeRequire {
+		t.Fatalf("expected overrides to be applied to recipe but got %+v", recipe)
+	}
+	if recipe.Parameters[strutil.MakeCIString("search_path")] != "app" ||
+		recipe.Parameters[strutil.MakeCIString("application_name")] != "pggat" {
+		t.Fatalf("expected parameters to be merged but got %v", recipe.Parameters)
+	}
+	if len(m.serverStartupParameters) != 1 {
+		t.Fatal("expected config parameters to be left alone")
+	}
+}
@@ -0,0 +1,132 @@
+package discovery
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+	"go.uber.org/zap"
+)
+
+func TestDiffCluster(t *testing.T) {
+	prev := Cluster{
+		ID:      "main",
+		Primary: Node{Address: "10.0.0.1:5432"},
+		Replicas: map[string]Node{
+			"a": {Address: "10.0.0.2:5432"},
+			"b": {Address: "10.0.0.3:5432"},
+		},
+		Databases: []string{"app", "old"},
+		Users: []User{
+			{Username: "app", Password: "secret"},
+			{Username: "gone", Password: "secret"},
+		},
+	}
+
+	diff := diffCluster(prev, prev)
+	if !diff.Empty() {
+		t.Fatalf("expected no changes but got %+v", diff)
+	}
+
+	next := Cluster{
+		ID:      "main",
+		Primary: Node{Address: "10.0.0.2:5432"},
+		Replicas: map[string]Node{
+			"b": {Address: "10.0.0.4:5432"},
+			"c": {Address: "10.0.0.5:5432"},
+		},
+		Databases: []string{"app", "new"},
+		Users: []User{
+			{Username: "app", Password: "rotated"},
+			{Username: "reporting", Password: "secret"},
+		},
+	}
+
+	expected := clusterDiff{
+		ID:               "main",
+		Primary:          true,
+		AddedReplicas:    []string{"c"},
+		RemovedReplicas:  []string{"a"},
+		ChangedReplicas:  []string{"b"},
+		AddedUsers:       []string{"reporting"},
+		RemovedUsers:     []string{"gone"},
+		ChangedUsers:     []string{"app"},
+		AddedDatabases:   []string{"new"},
+		RemovedDatabases: []string{"old"},
+	}
+	diff = diffCluster(prev, next)
+	if !reflect.DeepEqual(diff, expected) {
+		t.Fatalf("expected %+v but got %+v", expected, diff)
+	}
+}
+
+func TestModuleDebounce(t *testing.T) {
+	ctx := context.Background()
+
+	m := Module{
+		Config: Config{
+			Debounce:          caddy.Duration(time.Second),
+			RemoveGracePeriod: caddy.Duration(time.Minute),
+		},
+		poolFactory: testPoolFactory("transaction"),
+		log:         zap.NewNop(),
+	}
+
+	cluster := Cluster{
+		ID:        "main",
+		Primary:   Node{Address: "10.0.0.1:5432"},
+		Databases: []string{"app"},
+		Users:     []User{{Username: "app", Password: "secret"}},
+	}
+
+	// flapping events are only applied once they settle
+	m.debounce(ctx, cluster)
+	cluster.Primary = Node{Address: "10.0.0.2:5432"}
+	m.debounce(ctx, cluster)
+	if _, ok := m.getPool("app", "app"); ok {
+		t.Fatal("expected cluster to wait for the debounce period")
+	}
+
+	deadline, ok := m.deadline()
+	if !ok {
+		t.Fatal("expected a pending deadline")
+	}
+	m.flush(ctx, deadline)
+
+	p, ok := m.getPool("app", "app")
+	if !ok {
+		t.Fatal("expected pool after the debounce period")
+	}
+	if address := p.pool.(*testPool).recipes["primary"].Address; address != "10.0.0.2:5432" {
+		t.Fatalf("expected the latest primary but got %s", address)
+	}
+
+	// a removal which is followed by an add keeps the pool
+	if !m.scheduleRemoval(ctx, cluster.ID) {
+		t.Fatal("expected removal to be scheduled")
+	}
+	m.debounce(ctx, cluster)
+	if _, ok = m.removing[cluster.ID]; ok {
+		t.Fatal("expected removal to be canceled")
+	}
+	m.flush(ctx, time.Now().Add(time.Hour))
+	if _, ok = m.getPool("app", "app"); !ok {
+		t.Fatal("expected pool to survive a flapping removal")
+	}
+
+	// a removal which sticks removes the pool after the grace period
+	m.scheduleRemoval(ctx, cluster.ID)
+	m.flush(ctx, time.Now())
+	if _, ok = m.getPool("app", "app"); !ok {
+		t.Fatal("expected pool to be kept during the grace period")
+	}
+	m.flush(ctx, time.Now().Add(time.Hour))
+	if _, ok = m.getPool("app", "app"); ok {
+		t.Fatal("expected pool to be removed after the grace period")
+	}
+	if _, ok = m.clusters[cluster.ID]; ok {
+		t.Fatal("expected cluster to be removed after the grace period")
+	}
+}
@@ -0,0 +1,71 @@
+package gssapi
+
+import (
+	"context"
+
+	"github.com/caddyserver/caddy/v2"
+
+	"gfx.cafe/gfx/pggat/lib/auth/credentials"
+	"gfx.cafe/gfx/pggat/lib/auth/gss"
+	"gfx.cafe/gfx/pggat/lib/bouncer/frontends/v0"
+	"gfx.cafe/gfx/pggat/lib/fed"
+	"gfx.cafe/gfx/pggat/lib/gat"
+)
+
+func init() {
+	caddy.RegisterModule((*Module)(nil))
+}
+
+// Module authenticates clients with kerberos. The client principal must match the user it connects as. Clients which
+// negotiated GSSAPI encryption are not asked for another token.
+type Module struct {
+	// Keytab is the path of the keytab with the service keys
+	Keytab string `json:"keytab"`
+	// Principal is the service principal to accept tickets for. If empty, any principal in the keytab is accepted
+	Principal string `json:"principal,omitempty"`
+
+	// IncludeRealm compares the whole principal (name@REALM) to the user instead of only the name
+	IncludeRealm bool `json:"include_realm,omitempty"`
+	// Realm is the realm principals must be from. Optional
+	Realm string `json:"realm,omitempty"`
+
+	acceptor *gss.Acceptor
+}
+
+func (T *Module) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.gssapi",
+		New: func() caddy.Module {
+			return new(Module)
+		},
+	}
+}
+
+func (T *Module) Provision(ctx caddy.Context) error {
+	var err error
+	T.acceptor, err = gss.LoadAcceptor(T.Keytab, T.Principal)
+	return err
+}
+
+func (T *Module) Handle(next gat.Router) gat.Router {
+	return gat.RouterFunc(func(ctx context.Context, conn *fed.Conn) error {
+		if err := frontends.Authenticate(
+			ctx,
+			conn,
+			credentials.GSS{
+				Acceptor:     T.acceptor,
+				Username:     conn.User,
+				IncludeRealm: T.IncludeRealm,
+				Realm:        T.Realm,
+			},
+		); err != nil {
+			return err
+		}
+
+		return next.Route(ctx, conn)
+	})
+}
+
+var _ gat.Handler = (*Module)(nil)
+var _ caddy.Module = (*Module)(nil)
+var _ caddy.Provisioner = (*Module)(nil)
@@ -127,6 +127,7 @@ type PgBouncer struct {
 	ServerTLSProtocols      []TLSProtocol      `ini:"server_tls_protocols"`
 	ServerTLSCiphers        []TLSCipher        `ini:"server_tls_ciphers"`
 	QueryTimeout            float64            `ini:"query_timeout"`
+	MaxPreparedStatements   int                `ini:"max_prepared_statements"`
 	QueryWaitTimeout        float64            `ini:"query_wait_timeout"`
 	CancelWaitTimeout       float64            `ini:"cancel_wait_timeout"`
 	ClientIdleTimeout       float64            `ini:"client_idle_timeout"`
@@ -213,6 +213,10 @@ func (T *Module) tryCreate(ctx context.Context, user, database string) (poolAndC
 	config.TrackedParameters = trackedParameters
 	config.ServerResetQueryTimeout = caddy.Duration(T.Config.PgBouncer.ServerResetQueryTimeout * float64(time.Second))
 	config.ServerIdleTimeout = caddy.Duration(T.Config.PgBouncer.ServerIdleTimeout * float64(time.Second))
+	config.ClientIdleTimeout = caddy.Duration(T.Config.PgBouncer.ClientIdleTimeout * float64(time.Second))
+	config.IdleTransactionTimeout = caddy.Duration(T.Config.PgBouncer.IdleTransactionTimeout * float64(time.Second))
+	config.QueryTimeout = caddy.Duration(T.Config.PgBouncer.QueryTimeout * float64(time.Second))
+	config.MaxPreparedStatements = T.Config.PgBouncer.MaxPreparedStatements
 	config.ServerReconnectInitialTime = serverLoginRetry
 	config.ServerReconnectMaxTime = serverLoginRetry
 	config.Logger = T.log
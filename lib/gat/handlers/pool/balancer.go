@@ -0,0 +1,47 @@
+package pool
+
+import (
+	"math"
+	"time"
+)
+
+// Candidate is a recipe which a Balancer may pick to open the next server with
+type Candidate struct {
+	Name string
+
+	// Priority is the recipe's priority. Lower is better, recipes with a higher priority are backups for the others
+	Priority int
+	// Weight is the recipe's share of new servers compared to other recipes of the same priority, for balancers that
+	// support it
+	Weight int
+	// Penalty is the sum of the critic scores of the recipe. Lower is better
+	Penalty int
+
+	// Conns is the number of servers open with the recipe
+	Conns int
+	// Active is the number of servers open with the recipe that are currently in use by a client
+	Active int
+	// Latency is a moving average of how long it takes to connect with the recipe
+	Latency time.Duration
+}
+
+// Tier is the group a candidate is balanced in. Balancers spread servers over the candidates of the lowest tier, and
+// only fall back to the others in order of tier. Candidates which critics failed to score are in the last tier
+func (T Candidate) Tier() int {
+	if T.Penalty == math.MaxInt {
+		return math.MaxInt
+	}
+	return T.Priority
+}
+
+type Balancer interface {
+	// Balance orders candidates from most to least preferred. Candidates are tried in order until one can be used
+	Balance(candidates []Candidate)
+	// Chosen is called with the name of the candidate a server was opened with after Balance
+	Chosen(name string)
+}
+
+// BalancerFactory creates a Balancer for each spool, so balancers which keep state don't share it between pools
+type BalancerFactory interface {
+	NewBalancer() Balancer
+}
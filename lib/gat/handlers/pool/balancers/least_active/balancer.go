@@ -0,0 +1,54 @@
+package least_active
+
+import (
+	"sort"
+
+	"github.com/caddyserver/caddy/v2"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
+)
+
+func init() {
+	caddy.RegisterModule((*Balancer)(nil))
+}
+
+// Balancer prefers the recipe with the fewest servers in use, then the recipe with the fewest servers open, then the
+// recipe with the lowest penalty. Recipes of a higher tier are only tried once none of the lowest can be used
+type Balancer struct{}
+
+func (T *Balancer) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.pool.balancers.least_active",
+		New: func() caddy.Module {
+			return new(Balancer)
+		},
+	}
+}
+
+func (T *Balancer) NewBalancer() pool.Balancer {
+	// stateless, so it can be shared
+	return T
+}
+
+func (T *Balancer) Balance(candidates []pool.Candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a := candidates[i]
+		b := candidates[j]
+		if a.Tier() != b.Tier() {
+			return a.Tier() < b.Tier()
+		}
+		if a.Active != b.Active {
+			return a.Active < b.Active
+		}
+		if a.Conns != b.Conns {
+			return a.Conns < b.Conns
+		}
+		return a.Penalty < b.Penalty
+	})
+}
+
+func (T *Balancer) Chosen(string) {}
+
+var _ pool.Balancer = (*Balancer)(nil)
+var _ pool.BalancerFactory = (*Balancer)(nil)
+var _ caddy.Module = (*Balancer)(nil)
@@ -0,0 +1,82 @@
+package power_of_two
+
+import (
+	"math/rand"
+	"sort"
+
+	"github.com/caddyserver/caddy/v2"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
+)
+
+func init() {
+	caddy.RegisterModule((*Balancer)(nil))
+}
+
+// Balancer picks two random recipes of the lowest tier and prefers the one with the lower latency, or the lower
+// penalty if they are as fast. This spreads servers across all recipes while still favoring faster ones, without
+// herding onto whichever recipe is currently fastest
+type Balancer struct{}
+
+func (T *Balancer) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.pool.balancers.power_of_two",
+		New: func() caddy.Module {
+			return new(Balancer)
+		},
+	}
+}
+
+func (T *Balancer) NewBalancer() pool.Balancer {
+	// stateless, so it can be shared
+	return T
+}
+
+func less(a, b pool.Candidate) bool {
+	if a.Tier() != b.Tier() {
+		return a.Tier() < b.Tier()
+	}
+	if a.Latency != b.Latency {
+		return a.Latency < b.Latency
+	}
+	return a.Penalty < b.Penalty
+}
+
+func (T *Balancer) Balance(candidates []pool.Candidate) {
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return less(candidates[i], candidates[j])
+	})
+
+	// the choices are made from the lowest tier
+	n := 1
+	for n < len(candidates) && candidates[n].Tier() == candidates[0].Tier() {
+		n++
+	}
+	if n < 2 {
+		return
+	}
+
+	i := rand.Intn(n)
+	j := rand.Intn(n - 1)
+	if j >= i {
+		j++
+	}
+	if j < i {
+		i, j = j, i
+	}
+
+	// candidates are sorted, so i is the better choice
+	candidates[0], candidates[i] = candidates[i], candidates[0]
+	candidates[1], candidates[j] = candidates[j], candidates[1]
+
+	// the rest are tried in order if both choices can't be used
+	sort.SliceStable(candidates[2:], func(i, j int) bool {
+		return less(candidates[2+i], candidates[2+j])
+	})
+}
+
+func (T *Balancer) Chosen(string) {}
+
+var _ pool.Balancer = (*Balancer)(nil)
+var _ pool.BalancerFactory = (*Balancer)(nil)
+var _ caddy.Module = (*Balancer)(nil)
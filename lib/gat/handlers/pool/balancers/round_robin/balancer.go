@@ -0,0 +1,98 @@
+package round_robin
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/caddyserver/caddy/v2"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
+)
+
+func init() {
+	caddy.RegisterModule((*Balancer)(nil))
+}
+
+// Balancer is a smooth weighted round-robin balancer. Each recipe of the lowest tier gets a share of new servers
+// proportional to its weight, recipes with a weight of 0 or less have a weight of 1. The other tiers are only tried once
+// none of the lowest can be used
+type Balancer struct {
+	current map[string]int
+	total   int
+	mu      sync.Mutex
+}
+
+func (T *Balancer) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.pool.balancers.round_robin",
+		New: func() caddy.Module {
+			return new(Balancer)
+		},
+	}
+}
+
+func weight(candidate pool.Candidate) int {
+	if candidate.Weight <= 0 {
+		return 1
+	}
+	return candidate.Weight
+}
+
+func (T *Balancer) NewBalancer() pool.Balancer {
+	// each pool needs its own rotation
+	return new(Balancer)
+}
+
+func (T *Balancer) Balance(candidates []pool.Candidate) {
+	if len(candidates) == 0 {
+		return
+	}
+
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	tier := candidates[0].Tier()
+	for _, candidate := range candidates[1:] {
+		tier = min(tier, candidate.Tier())
+	}
+
+	// only the lowest tier takes part in the rotation. this also forgets recipes that are gone
+	current := make(map[string]int, len(candidates))
+	total := 0
+	for _, candidate := range candidates {
+		if candidate.Tier() != tier {
+			continue
+		}
+		w := weight(candidate)
+		current[candidate.Name] = T.current[candidate.Name] + w
+		total += w
+	}
+	T.current = current
+	T.total = total
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a := candidates[i]
+		b := candidates[j]
+		if a.Tier() != b.Tier() {
+			return a.Tier() < b.Tier()
+		}
+		if current[a.Name] != current[b.Name] {
+			return current[a.Name] > current[b.Name]
+		}
+		return a.Penalty < b.Penalty
+	})
+}
+
+func (T *Balancer) Chosen(name string) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	// backups which were used because the whole tier is unavailable don't take part in the rotation
+	if _, ok := T.current[name]; ok {
+		T.current[name] -= T.total
+	}
+}
+
+var _ pool.Balancer = (*Balancer)(nil)
+var _ pool.BalancerFactory = (*Balancer)(nil)
+var _ caddy.Module = (*Balancer)(nil)
@@ -0,0 +1,93 @@
+package round_robin
+
+import (
+	"math"
+	"testing"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
+)
+
+func next(b pool.Balancer, candidates ...pool.Candidate) string {
+	b.Balance(candidates)
+	b.Chosen(candidates[0].Name)
+	return candidates[0].Name
+}
+
+func expectPicks(t *testing.T, expected, picks []string) {
+	t.Helper()
+	for i := range expected {
+		if picks[i] != expected[i] {
+			t.Fatalf("expected %v but got %v", expected, picks)
+		}
+	}
+}
+
+func TestBalancerSharedModule(t *testing.T) {
+	// one module is loaded per pool factory and shared by every pool it creates
+	module := new(Balancer)
+	a := module.NewBalancer()
+	b := module.NewBalancer()
+
+	var picks []string
+	for i := 0; i < 6; i++ {
+		picks = append(picks, next(
+			a,
+			pool.Candidate{Name: "primary", Weight: 2},
+			pool.Candidate{Name: "replica", Weight: 1},
+		))
+
+		// another pool with recipes of the same names must not disturb the rotation
+		next(b, pool.Candidate{Name: "primary", Weight: 1})
+		next(b, pool.Candidate{Name: "replica", Weight: 5}, pool.Candidate{Name: "other", Weight: 1})
+	}
+
+	expectPicks(t, []string{"primary", "replica", "primary", "primary", "replica", "primary"}, picks)
+}
+
+func TestBalancerPriority(t *testing.T) {
+	b := new(Balancer).NewBalancer()
+
+	// backups are never picked while the others can be used, no matter their weight
+	var picks []string
+	for i := 0; i < 4; i++ {
+		picks = append(picks, next(
+			b,
+			pool.Candidate{Name: "backup", Priority: 1, Weight: 10},
+			pool.Candidate{Name: "a", Weight: 1},
+			pool.Candidate{Name: "b", Weight: 1},
+		))
+	}
+	expectPicks(t, []string{"a", "b", "a", "b"}, picks)
+
+	// recipes which failed to be scored are tried last
+	candidates := []pool.Candidate{
+		{Name: "a", Penalty: math.MaxInt},
+		{Name: "backup", Priority: 1},
+	}
+	b.Balance(candidates)
+	if candidates[0].Name != "backup" {
+		t.Fatalf("expected backup to be tried first but got %v", candidates)
+	}
+}
+
+func TestBalancerSkipped(t *testing.T) {
+	b := new(Balancer).NewBalancer()
+
+	var picks []string
+	for i := 0; i < 6; i++ {
+		candidates := []pool.Candidate{
+			{Name: "a", Weight: 2},
+			{Name: "b", Weight: 1},
+		}
+		b.Balance(candidates)
+
+		// the first choice couldn't be used the first time, so only the second is charged
+		chosen := candidates[0].Name
+		if i == 0 {
+			chosen = candidates[1].Name
+		}
+		b.Chosen(chosen)
+		picks = append(picks, chosen)
+	}
+	expectPicks(t, []string{"b", "a", "a", "a", "b", "a"}, picks)
+}
@@ -0,0 +1,70 @@
+package role
+
+import (
+	"context"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
+	"gfx.cafe/gfx/pggat/lib/gsql"
+)
+
+func init() {
+	caddy.RegisterModule((*Critic)(nil))
+}
+
+// Critic checks pg_is_in_recovery() on each server. Servers in recovery are penalized, and hybrid pools move recipes
+// between their primary and replica servers when a server changes role
+type Critic struct {
+	// Penalty is the score of servers in recovery
+	Penalty  int            `json:"penalty"`
+	Validity caddy.Duration `json:"validity"`
+}
+
+func NewCritic() *Critic {
+	return &Critic{
+		Penalty:  1000,
+		Validity: caddy.Duration(time.Second * 5),
+	}
+}
+
+func (T *Critic) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.pool.critics.role",
+		New: func() caddy.Module {
+			return NewCritic()
+		},
+	}
+}
+
+type recoveryQueryResult struct {
+	InRecovery bool `sql:"0"`
+}
+
+const recoveryQuery = `SELECT pg_is_in_recovery();`
+
+func (T *Critic) Primary(ctx context.Context, conn *fed.Conn) (bool, time.Duration, error) {
+	var result recoveryQueryResult
+	if err := gsql.Query(ctx, conn, []any{&result}, recoveryQuery); err != nil {
+		return false, time.Duration(T.Validity), err
+	}
+
+	return !result.InRecovery, time.Duration(T.Validity), nil
+}
+
+func (T *Critic) Taste(ctx context.Context, conn *fed.Conn) (int, time.Duration, error) {
+	primary, validity, err := T.Primary(ctx, conn)
+	if err != nil {
+		return 0, validity, err
+	}
+
+	if primary {
+		return 0, validity, nil
+	}
+	return T.Penalty, validity, nil
+}
+
+var _ pool.RoleCritic = (*Critic)(nil)
+var _ caddy.Module = (*Critic)(nil)
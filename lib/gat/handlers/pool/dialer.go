@@ -4,9 +4,11 @@ import (
 	"context"
 	"crypto/tls"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"strings"
+	"time"
 
 	"github.com/caddyserver/caddy/v2"
 
@@ -20,12 +22,24 @@ import (
 	"gfx.cafe/gfx/pggat/lib/util/strutil"
 )
 
+// DefaultConnectTimeout is the ConnectTimeout of dialers which don't set one
+const DefaultConnectTimeout = 10 * time.Second
+
 type Dialer struct {
+	// Address is a host:port pair or unix socket path. Multiple comma separated hosts are tried in order until one
+	// is suitable for TargetSessionAttrs
 	Address  string          `json:"address"`
 	SSLMode  bouncer.SSLMode `json:"ssl_mode"`
 	Username string          `json:"username"`
 	Database string          `json:"database"`
 
+	TargetSessionAttrs TargetSessionAttrs `json:"target_session_attrs,omitempty"`
+	LoadBalanceHosts   LoadBalanceHosts   `json:"load_balance_hosts,omitempty"`
+
+	// ConnectTimeout is how long each host has to accept the connection, authenticate, and pass the
+	// TargetSessionAttrs check. Defaults to DefaultConnectTimeout
+	ConnectTimeout caddy.Duration `json:"connect_timeout,omitempty"`
+
 	RawSSL        json.RawMessage   `json:"ssl,omitempty" caddy:"namespace=pggat.ssl.clients inline_key=provider"`
 	RawPassword   string            `json:"password"`
 	RawParameters map[string]string `json:"parameters,omitempty"`
@@ -36,6 +50,13 @@ type Dialer struct {
 }
 
 func (T *Dialer) Provision(ctx caddy.Context) error {
+	if !T.TargetSessionAttrs.Valid() {
+		return fmt.Errorf("invalid target_session_attrs: %s", T.TargetSessionAttrs)
+	}
+	if !T.LoadBalanceHosts.Valid() {
+		return fmt.Errorf("invalid load_balance_hosts: %s", T.LoadBalanceHosts)
+	}
+
 	if T.RawSSL != nil {
 		val, err := ctx.LoadModule(T, "RawSSL")
 		if err != nil {
@@ -54,24 +75,43 @@ func (T *Dialer) Provision(ctx caddy.Context) error {
 	return nil
 }
 
-func (T *Dialer) dial() (net.Conn, error) {
-	if strings.HasPrefix(T.Address, "/") {
-		return net.Dial("unix", T.Address)
+func dial(ctx context.Context, host string) (net.Conn, error) {
+	var dialer net.Dialer
+	if strings.HasPrefix(host, "/") {
+		return dialer.DialContext(ctx, "unix", host)
 	} else {
-		return net.Dial("tcp", T.Address)
+		return dialer.DialContext(ctx, "tcp", host)
 	}
 }
 
-func (T *Dialer) Dial() (*fed.Conn, error) {
-	c, err := T.dial()
+func (T *Dialer) connectTimeout() time.Duration {
+	if T.ConnectTimeout == 0 {
+		return DefaultConnectTimeout
+	}
+	return time.Duration(T.ConnectTimeout)
+}
+
+func (T *Dialer) dialHost(ctx context.Context, host string, attrs TargetSessionAttrs) (*fed.Conn, error) {
+	ctx, cancel := context.WithTimeout(ctx, T.connectTimeout())
+	defer cancel()
+
+	c, err := dial(ctx, host)
 	if err != nil {
 		return nil, err
 	}
+	// the deadline also covers writes and the ssl handshake, which don't take a context
+	if deadline, ok := ctx.Deadline(); ok {
+		if err = c.SetDeadline(deadline); err != nil {
+			_ = c.Close()
+			return nil, err
+		}
+	}
+
 	conn := fed.NewConn(netconncodec.NewCodec(c))
 	conn.User = T.Username
 	conn.Database = T.Database
 	err = backends.Accept(
-		context.Background(),
+		ctx,
 		conn,
 		T.SSLMode,
 		T.SSLConfig,
@@ -81,14 +121,78 @@ func (T *Dialer) Dial() (*fed.Conn, error) {
 		T.Parameters,
 	)
 	if err != nil {
+		_ = conn.Close(context.Background())
+		return nil, err
+	}
+
+	ok, err := attrs.Check(ctx, conn)
+	if err != nil || !ok {
+		_ = conn.Close(context.Background())
+		if err == nil {
+			err = ErrUnsuitableHost{
+				Host:               host,
+				TargetSessionAttrs: attrs,
+			}
+		}
 		return nil, err
 	}
+
+	if err = c.SetDeadline(time.Time{}); err != nil {
+		_ = conn.Close(context.Background())
+		return nil, err
+	}
+
 	conn.Ready = true
 	return conn, nil
 }
 
-func (T *Dialer) Cancel(ctx context.Context, key fed.BackendKey) {
-	c, err := T.dial()
+func (T *Dialer) dialHosts(ctx context.Context, hosts []string, attrs TargetSessionAttrs) (*fed.Conn, error) {
+	if len(hosts) == 1 {
+		return T.dialHost(ctx, hosts[0], attrs)
+	}
+
+	var errs []error
+	for _, host := range hosts {
+		conn, err := T.dialHost(ctx, host, attrs)
+		if err == nil {
+			return conn, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", host, err))
+	}
+	return nil, errors.Join(errs...)
+}
+
+// Dial connects to the first suitable host
+func (T *Dialer) Dial() (*fed.Conn, error) {
+	return T.DialContext(context.Background())
+}
+
+// DialContext connects to the first suitable host. Each host is given up to ConnectTimeout
+func (T *Dialer) DialContext(ctx context.Context) (*fed.Conn, error) {
+	hosts := T.hosts()
+
+	if T.TargetSessionAttrs == TargetSessionAttrsPreferStandby {
+		conn, err := T.dialHosts(ctx, hosts, TargetSessionAttrsStandby)
+		if err == nil {
+			return conn, nil
+		}
+		return T.dialHosts(ctx, hosts, TargetSessionAttrsAny)
+	}
+
+	return T.dialHosts(ctx, hosts, T.TargetSessionAttrs)
+}
+
+// Cancel cancels the current query of server
+func (T *Dialer) Cancel(ctx context.Context, server *fed.Conn) {
+	var c net.Conn
+	var err error
+	if addr := server.RemoteAddr(); addr != nil && strings.Contains(T.Address, ",") {
+		// send the cancel to the host server was dialed on
+		var dialer net.Dialer
+		c, err = dialer.DialContext(ctx, addr.Network(), addr.String())
+	} else {
+		c, err = dial(ctx, T.Address)
+	}
 	if err != nil {
 		return
 	}
@@ -96,7 +200,7 @@ func (T *Dialer) Cancel(ctx context.Context, key fed.BackendKey) {
 	defer func() {
 		_ = conn.Close(ctx)
 	}()
-	if err = backends.Cancel(ctx, conn, key); err != nil {
+	if err = backends.Cancel(ctx, conn, server.BackendKey); err != nil {
 		return
 	}
 
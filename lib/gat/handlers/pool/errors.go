@@ -1,7 +1,31 @@
 package pool
 
-import "errors"
+import (
+	"errors"
+
+	"gfx.cafe/gfx/pggat/lib/perror"
+)
 
 var (
 	ErrFailedToAcquirePeer = errors.New("failed to acquire peer (try increasing client_acquire_timeout?)")
+	ErrPoolSaturated       = perror.New(
+		perror.FATAL,
+		perror.TooManyConnections,
+		"pool saturated, too many clients are waiting for a server",
+	)
+	ErrReplicationNotPooled = perror.New(
+		perror.FATAL,
+		perror.FeatureNotSupported,
+		"replication connections can't be pooled, route them to a replication handler",
+	)
+	ErrNoPrimary = perror.New(
+		perror.FATAL,
+		perror.CannotConnectNow,
+		"pool has no primary server",
+	)
+	ErrClientIdleTimeout = perror.New(
+		perror.FATAL,
+		perror.IdleSessionTimeout,
+		"terminating connection due to idle-session timeout",
+	)
 )
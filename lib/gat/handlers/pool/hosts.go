@@ -0,0 +1,110 @@
+package pool
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"strings"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	"gfx.cafe/gfx/pggat/lib/gsql"
+)
+
+// TargetSessionAttrs decides which hosts of a multi-host address are suitable, like libpq's target_session_attrs
+type TargetSessionAttrs string
+
+const (
+	TargetSessionAttrsAny           TargetSessionAttrs = "any"
+	TargetSessionAttrsReadWrite     TargetSessionAttrs = "read-write"
+	TargetSessionAttrsReadOnly      TargetSessionAttrs = "read-only"
+	TargetSessionAttrsPrimary       TargetSessionAttrs = "primary"
+	TargetSessionAttrsStandby       TargetSessionAttrs = "standby"
+	TargetSessionAttrsPreferStandby TargetSessionAttrs = "prefer-standby"
+)
+
+func (T TargetSessionAttrs) Valid() bool {
+	switch T {
+	case "", TargetSessionAttrsAny, TargetSessionAttrsReadWrite, TargetSessionAttrsReadOnly,
+		TargetSessionAttrsPrimary, TargetSessionAttrsStandby, TargetSessionAttrsPreferStandby:
+		return true
+	default:
+		return false
+	}
+}
+
+type readOnlyQueryResult struct {
+	ReadOnly string `sql:"0"`
+}
+
+type inRecoveryQueryResult struct {
+	InRecovery bool `sql:"0"`
+}
+
+// Check returns whether the server conn is suitable
+func (T TargetSessionAttrs) Check(ctx context.Context, conn *fed.Conn) (bool, error) {
+	switch T {
+	case TargetSessionAttrsReadWrite, TargetSessionAttrsReadOnly:
+		var result readOnlyQueryResult
+		if err := gsql.Query(ctx, conn, []any{&result}, "SHOW transaction_read_only"); err != nil {
+			return false, err
+		}
+		return (result.ReadOnly == "on") == (T == TargetSessionAttrsReadOnly), nil
+	case TargetSessionAttrsPrimary, TargetSessionAttrsStandby:
+		var result inRecoveryQueryResult
+		if err := gsql.Query(ctx, conn, []any{&result}, "SELECT pg_is_in_recovery()"); err != nil {
+			return false, err
+		}
+		return result.InRecovery == (T == TargetSessionAttrsStandby), nil
+	default:
+		return true, nil
+	}
+}
+
+// LoadBalanceHosts decides the order hosts of a multi-host address are tried in, like libpq's load_balance_hosts
+type LoadBalanceHosts string
+
+const (
+	// LoadBalanceHostsDisable tries hosts in the order they are listed
+	LoadBalanceHostsDisable LoadBalanceHosts = "disable"
+	// LoadBalanceHostsRandom tries hosts in a random order
+	LoadBalanceHostsRandom LoadBalanceHosts = "random"
+)
+
+func (T LoadBalanceHosts) Valid() bool {
+	switch T {
+	case "", LoadBalanceHostsDisable, LoadBalanceHostsRandom:
+		return true
+	default:
+		return false
+	}
+}
+
+// ErrUnsuitableHost is returned when a host doesn't match target_session_attrs
+type ErrUnsuitableHost struct {
+	Host               string
+	TargetSessionAttrs TargetSessionAttrs
+}
+
+func (T ErrUnsuitableHost) Error() string {
+	return fmt.Sprintf("host %s is not suitable for target_session_attrs=%s", T.Host, T.TargetSessionAttrs)
+}
+
+// Hosts splits a libpq-style comma separated host list. Each host is a host:port pair or a unix socket path
+func Hosts(address string) []string {
+	hosts := strings.Split(address, ",")
+	for i, host := range hosts {
+		hosts[i] = strings.TrimSpace(host)
+	}
+	return hosts
+}
+
+// hosts returns the hosts of the dialer in the order they should be tried
+func (T *Dialer) hosts() []string {
+	hosts := Hosts(T.Address)
+	if T.LoadBalanceHosts == LoadBalanceHostsRandom {
+		rand.Shuffle(len(hosts), func(i, j int) {
+			hosts[i], hosts[j] = hosts[j], hosts[i]
+		})
+	}
+	return hosts
+}
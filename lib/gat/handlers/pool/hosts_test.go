@@ -0,0 +1,105 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/caddyserver/caddy/v2"
+)
+
+func TestHosts(t *testing.T) {
+	hosts := Hosts("h1:5432, h2:5432,/var/run/postgresql/.s.PGSQL.5432")
+	expected := []string{"h1:5432", "h2:5432", "/var/run/postgresql/.s.PGSQL.5432"}
+	if !slices.Equal(hosts, expected) {
+		t.Fatalf("expected %v but got %v", expected, hosts)
+	}
+
+	d := Dialer{
+		Address:          "h1:5432,h2:5432,h3:5432",
+		LoadBalanceHosts: LoadBalanceHostsRandom,
+	}
+	shuffled := d.hosts()
+	slices.Sort(shuffled)
+	if !slices.Equal(shuffled, []string{"h1:5432", "h2:5432", "h3:5432"}) {
+		t.Fatalf("expected every host once but got %v", shuffled)
+	}
+}
+
+func unusedAddress(t *testing.T) string {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	address := l.Addr().String()
+	if err = l.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return address
+}
+
+func TestDialerTriesEveryHost(t *testing.T) {
+	a := unusedAddress(t)
+	b := unusedAddress(t)
+
+	d := Dialer{
+		Address: a + "," + b,
+	}
+	_, err := d.Dial()
+	if err == nil {
+		t.Fatal("expected dial to fail")
+	}
+	if !strings.Contains(err.Error(), a) || !strings.Contains(err.Error(), b) {
+		t.Fatalf("expected error to mention both hosts but got %v", err)
+	}
+}
+
+func TestDialerTimeout(t *testing.T) {
+	// a server which accepts connections but never responds
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer func() {
+				_ = c.Close()
+			}()
+		}
+	}()
+
+	d := Dialer{
+		Address:        l.Addr().String() + "," + unusedAddress(t),
+		ConnectTimeout: caddy.Duration(100 * time.Millisecond),
+	}
+
+	done := make(chan error, 1)
+	go func() {
+		_, err := d.Dial()
+		done <- err
+	}()
+
+	select {
+	case err = <-done:
+		if err == nil {
+			t.Fatal("expected dial to fail")
+		}
+		if !errors.Is(err, context.DeadlineExceeded) && !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Fatalf("expected the first host to time out but got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected dial to time out")
+	}
+}
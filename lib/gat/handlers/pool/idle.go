@@ -0,0 +1,28 @@
+package pool
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+)
+
+// ReadIdlePacket reads the next packet from a client that is not in a transaction. If timeout is not 0 and the client
+// doesn't send anything in time, ErrClientIdleTimeout is returned.
+func ReadIdlePacket(ctx context.Context, conn *fed.Conn, timeout time.Duration) (fed.Packet, error) {
+	if timeout == 0 {
+		return conn.ReadPacket(ctx, true)
+	}
+
+	packet, err := conn.ReadPacket(fed.WithReadDeadline(ctx, time.Now().Add(timeout)), true)
+	if err != nil {
+		var netErr net.Error
+		if errors.As(err, &netErr) && netErr.Timeout() {
+			return nil, ErrClientIdleTimeout
+		}
+		return nil, err
+	}
+	return packet, nil
+}
@@ -0,0 +1,133 @@
+package notify
+
+import (
+	"strings"
+)
+
+type CommandType int
+
+const (
+	CommandListen CommandType = iota
+	CommandUnlisten
+	CommandUnlistenAll
+)
+
+// Command is a LISTEN or UNLISTEN statement
+type Command struct {
+	Type    CommandType
+	Channel string
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func trimSpace(s string) string {
+	for len(s) > 0 && isSpace(s[0]) {
+		s = s[1:]
+	}
+	for len(s) > 0 && isSpace(s[len(s)-1]) {
+		s = s[:len(s)-1]
+	}
+	return s
+}
+
+func isIdentStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isIdentChar(c byte) bool {
+	return isIdentStart(c) || c == '$' || (c >= '0' && c <= '9')
+}
+
+// parseIdentifier reads a quoted or unquoted identifier from the start of s and returns it normalized the way postgres
+// does, along with the rest of s
+func parseIdentifier(s string) (string, string, bool) {
+	if len(s) == 0 {
+		return "", "", false
+	}
+
+	if s[0] == '"' {
+		var b strings.Builder
+		for i := 1; i < len(s); i++ {
+			if s[i] != '"' {
+				b.WriteByte(s[i])
+				continue
+			}
+			if i+1 < len(s) && s[i+1] == '"' {
+				b.WriteByte('"')
+				i++
+				continue
+			}
+			if b.Len() == 0 {
+				// zero length identifiers are not allowed
+				return "", "", false
+			}
+			return b.String(), s[i+1:], true
+		}
+		return "", "", false
+	}
+
+	if !isIdentStart(s[0]) {
+		return "", "", false
+	}
+	i := 1
+	for i < len(s) && isIdentChar(s[i]) {
+		i++
+	}
+	// postgres only folds ascii letters
+	ident := strings.Map(func(r rune) rune {
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return r
+	}, s[:i])
+	return ident, s[i:], true
+}
+
+// ParseCommand parses query if it is a single LISTEN or UNLISTEN statement
+func ParseCommand(query string) (Command, bool) {
+	query = trimSpace(query)
+	query = trimSpace(strings.TrimSuffix(query, ";"))
+
+	i := 0
+	for i < len(query) && (query[i] >= 'a' && query[i] <= 'z' || query[i] >= 'A' && query[i] <= 'Z') {
+		i++
+	}
+	keyword := query[:i]
+	rest := trimSpace(query[i:])
+	if rest == "" || (len(rest) == len(query[i:]) && rest[0] != '"' && rest[0] != '*') {
+		// the keyword must be followed by whitespace, a quoted identifier, or *
+		return Command{}, false
+	}
+
+	var command Command
+	switch {
+	case strings.EqualFold(keyword, "listen"):
+		command.Type = CommandListen
+	case strings.EqualFold(keyword, "unlisten"):
+		command.Type = CommandUnlisten
+		if rest == "*" {
+			command.Type = CommandUnlistenAll
+			return command, true
+		}
+	default:
+		return Command{}, false
+	}
+
+	channel, rest, ok := parseIdentifier(rest)
+	if !ok {
+		return Command{}, false
+	}
+	if trimSpace(rest) != "" {
+		return Command{}, false
+	}
+	command.Channel = channel
+
+	return command, true
+}
+
+// QuoteIdentifier quotes a channel name so it can be used in a LISTEN or UNLISTEN statement
+func QuoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
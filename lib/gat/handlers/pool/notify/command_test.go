@@ -0,0 +1,40 @@
+package notify
+
+import (
+	"testing"
+)
+
+func TestParseCommand(t *testing.T) {
+	cases := []struct {
+		query   string
+		command Command
+		ok      bool
+	}{
+		{query: "LISTEN foo", command: Command{Type: CommandListen, Channel: "foo"}, ok: true},
+		{query: "  listen Foo_Bar1; ", command: Command{Type: CommandListen, Channel: "foo_bar1"}, ok: true},
+		{query: `LISTEN "Foo ""Bar"""`, command: Command{Type: CommandListen, Channel: `Foo "Bar"`}, ok: true},
+		{query: `listen"foo"`, command: Command{Type: CommandListen, Channel: "foo"}, ok: true},
+		{query: "UNLISTEN foo;", command: Command{Type: CommandUnlisten, Channel: "foo"}, ok: true},
+		{query: "unlisten *", command: Command{Type: CommandUnlistenAll}, ok: true},
+		{query: "UNLISTEN*;", command: Command{Type: CommandUnlistenAll}, ok: true},
+		{query: "LISTEN", ok: false},
+		{query: "LISTEN_foo", ok: false},
+		{query: "LISTEN foo; SELECT 1", ok: false},
+		{query: "LISTEN foo bar", ok: false},
+		{query: `LISTEN ""`, ok: false},
+		{query: "LISTEN *", ok: false},
+		{query: "NOTIFY foo", ok: false},
+		{query: "SELECT 'LISTEN foo'", ok: false},
+	}
+
+	for _, c := range cases {
+		command, ok := ParseCommand(c.query)
+		if ok != c.ok {
+			t.Errorf("%q: expected ok=%v but got %v", c.query, c.ok, ok)
+			continue
+		}
+		if command != c.command {
+			t.Errorf("%q: expected %+v but got %+v", c.query, c.command, command)
+		}
+	}
+}
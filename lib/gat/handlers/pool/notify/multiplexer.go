@@ -0,0 +1,422 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
+	"gfx.cafe/gfx/pggat/lib/perror"
+)
+
+// reconnectInterval is how long to wait between attempts to reopen the listener connection
+const reconnectInterval = time.Second
+
+var (
+	ErrClosed    = errors.New("notification multiplexer is closed")
+	ErrNoRecipes = errors.New("no recipe available for the notification listener")
+)
+
+// channel is a channel the listener connection listens to
+type channel struct {
+	subscribers map[*Subscriber]struct{}
+
+	// listening is closed once the first LISTEN completes, err is its result
+	listening chan struct{}
+	err       error
+}
+
+// Multiplexer shares one dedicated LISTEN connection between all clients of a pool. The connection listens to the union
+// of the channels clients subscribed to, and notifications are fanned out to the subscribers of each channel.
+type Multiplexer struct {
+	logger *zap.Logger
+
+	recipes  map[string]*pool.Recipe
+	channels map[string]*channel
+
+	conn       *fed.Conn
+	connRecipe string
+	// pending holds the result of each query sent on conn, in order
+	pending []chan error
+	closed  bool
+	mu      sync.Mutex
+}
+
+func NewMultiplexer(logger *zap.Logger) *Multiplexer {
+	if logger == nil {
+		logger = zap.NewNop()
+	}
+	return &Multiplexer{
+		logger:   logger,
+		recipes:  make(map[string]*pool.Recipe),
+		channels: make(map[string]*channel),
+	}
+}
+
+func (T *Multiplexer) AddRecipe(name string, recipe *pool.Recipe) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	old, ok := T.recipes[name]
+	T.recipes[name] = recipe
+	if ok && old != recipe && T.connRecipe == name && T.conn != nil {
+		// reopen with the new recipe
+		_ = T.conn.Close(context.Background())
+	}
+}
+
+func (T *Multiplexer) RemoveRecipe(name string) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	delete(T.recipes, name)
+	if T.connRecipe == name && T.conn != nil {
+		// reopen with another recipe
+		_ = T.conn.Close(context.Background())
+	}
+}
+
+// connect opens the listener connection and listens to all channels. T.mu must be held.
+func (T *Multiplexer) connect() error {
+	if T.closed {
+		return ErrClosed
+	}
+	if T.conn != nil {
+		return nil
+	}
+
+	names := make([]string, 0, len(T.recipes))
+	for name := range T.recipes {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		a, b := T.recipes[names[i]], T.recipes[names[j]]
+		if a.Priority != b.Priority {
+			return a.Priority < b.Priority
+		}
+		return names[i] < names[j]
+	})
+
+	err := ErrNoRecipes
+	for _, name := range names {
+		recipe := T.recipes[name]
+		if !recipe.Allocate() {
+			continue
+		}
+
+		var conn *fed.Conn
+		conn, err = recipe.Dial()
+		if err != nil {
+			recipe.Free()
+			continue
+		}
+
+		T.conn = conn
+		T.connRecipe = name
+		go T.read(conn, recipe)
+
+		for channel := range T.channels {
+			if _, err = T.send("LISTEN " + QuoteIdentifier(channel)); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	return err
+}
+
+// send writes a query to the listener connection and returns a channel which receives its result. T.mu must be held.
+func (T *Multiplexer) send(query string) (chan error, error) {
+	p := packets.Query(query)
+	if err := T.conn.WritePacket(context.Background(), &p); err != nil {
+		_ = T.conn.Close(context.Background())
+		return nil, err
+	}
+	if err := T.conn.Flush(context.Background()); err != nil {
+		_ = T.conn.Close(context.Background())
+		return nil, err
+	}
+
+	result := make(chan error, 1)
+	T.pending = append(T.pending, result)
+	return result, nil
+}
+
+func (T *Multiplexer) read(conn *fed.Conn, recipe *pool.Recipe) {
+	ctx := context.Background()
+
+	var queryErr error
+	for {
+		packet, err := conn.ReadPacket(ctx, true)
+		if err != nil {
+			T.disconnected(conn, recipe, err)
+			return
+		}
+
+		switch packet.Type() {
+		case packets.TypeNotificationResponse:
+			var p packets.NotificationResponse
+			if err = fed.ToConcrete(&p, packet); err != nil {
+				T.disconnected(conn, recipe, err)
+				return
+			}
+			T.dispatch(&p)
+		case packets.TypeMarkiplierResponse:
+			var p packets.MarkiplierResponse
+			if err = fed.ToConcrete(&p, packet); err != nil {
+				T.disconnected(conn, recipe, err)
+				return
+			}
+			queryErr = perror.FromPacket(&p)
+		case packets.TypeReadyForQuery:
+			T.complete(queryErr)
+			queryErr = nil
+		}
+	}
+}
+
+func (T *Multiplexer) complete(err error) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	if len(T.pending) == 0 {
+		return
+	}
+	T.pending[0] <- err
+	T.pending = T.pending[1:]
+}
+
+func (T *Multiplexer) dispatch(packet *packets.NotificationResponse) {
+	T.mu.Lock()
+	var subscribers []*Subscriber
+	if ch, ok := T.channels[packet.Channel]; ok {
+		subscribers = make([]*Subscriber, 0, len(ch.subscribers))
+		for subscriber := range ch.subscribers {
+			subscribers = append(subscribers, subscriber)
+		}
+	}
+	T.mu.Unlock()
+
+	for _, subscriber := range subscribers {
+		subscriber.notify(packet)
+	}
+}
+
+func (T *Multiplexer) disconnected(conn *fed.Conn, recipe *pool.Recipe, err error) {
+	_ = conn.Close(context.Background())
+	recipe.Free()
+
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	if T.conn != conn {
+		return
+	}
+	T.conn = nil
+	T.connRecipe = ""
+	for _, result := range T.pending {
+		result <- err
+	}
+	T.pending = nil
+
+	if T.closed {
+		return
+	}
+
+	T.logger.Warn("notification listener disconnected", zap.Error(err))
+
+	if len(T.channels) > 0 {
+		go T.reconnect()
+	}
+}
+
+func (T *Multiplexer) reconnect() {
+	for {
+		time.Sleep(reconnectInterval)
+
+		T.mu.Lock()
+		if T.closed || T.conn != nil || len(T.channels) == 0 {
+			T.mu.Unlock()
+			return
+		}
+		err := T.connect()
+		T.mu.Unlock()
+
+		if err == nil {
+			return
+		}
+		T.logger.Warn("failed to reconnect notification listener", zap.Error(err))
+	}
+}
+
+// settle records the result of the first LISTEN of a channel. If it failed, the channel is dropped along with every
+// subscriber waiting on it.
+func (T *Multiplexer) settle(name string, ch *channel, result chan error) {
+	err := <-result
+
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	ch.err = err
+	if err != nil && T.channels[name] == ch {
+		delete(T.channels, name)
+		for subscriber := range ch.subscribers {
+			delete(subscriber.channels, name)
+		}
+	}
+	close(ch.listening)
+}
+
+// Listen subscribes to channel. It returns once the listener connection is listening to channel.
+func (T *Multiplexer) Listen(ctx context.Context, subscriber *Subscriber, name string) error {
+	T.mu.Lock()
+
+	ch, ok := T.channels[name]
+	if !ok {
+		if err := T.connect(); err != nil {
+			T.mu.Unlock()
+			return err
+		}
+		result, err := T.send("LISTEN " + QuoteIdentifier(name))
+		if err != nil {
+			T.mu.Unlock()
+			return err
+		}
+		ch = &channel{
+			subscribers: make(map[*Subscriber]struct{}),
+			listening:   make(chan struct{}),
+		}
+		T.channels[name] = ch
+		go T.settle(name, ch, result)
+	}
+
+	// subscribers joining while the LISTEN is in flight wait for the same result
+	ch.subscribers[subscriber] = struct{}{}
+	subscriber.channels[name] = struct{}{}
+
+	T.mu.Unlock()
+
+	select {
+	case <-ch.listening:
+		return ch.err
+	case <-ctx.Done():
+		T.Unlisten(subscriber, name)
+		return ctx.Err()
+	}
+}
+
+// unlisten unsubscribes from channel. T.mu must be held.
+func (T *Multiplexer) unlisten(subscriber *Subscriber, name string) {
+	ch, ok := T.channels[name]
+	if !ok {
+		return
+	}
+	delete(ch.subscribers, subscriber)
+	delete(subscriber.channels, name)
+	if len(ch.subscribers) > 0 {
+		return
+	}
+
+	delete(T.channels, name)
+	if T.conn == nil {
+		return
+	}
+	if _, err := T.send("UNLISTEN " + QuoteIdentifier(name)); err != nil {
+		T.logger.Warn("failed to unlisten", zap.String("channel", name), zap.Error(err))
+	}
+}
+
+// Unlisten unsubscribes from channel
+func (T *Multiplexer) Unlisten(subscriber *Subscriber, channel string) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	T.unlisten(subscriber, channel)
+}
+
+// UnlistenAll unsubscribes from all channels. Call it when the client disconnects.
+func (T *Multiplexer) UnlistenAll(subscriber *Subscriber) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	for channel := range subscriber.channels {
+		T.unlisten(subscriber, channel)
+	}
+}
+
+// HandlePacket runs packet if it is a simple query with a single LISTEN or UNLISTEN statement and writes the response
+// to the client. It returns nil if the packet was handled, otherwise the packet to send to a server. Only call it
+// between transactions, from the goroutine serving the client.
+func (T *Multiplexer) HandlePacket(ctx context.Context, subscriber *Subscriber, packet fed.Packet) (fed.Packet, error) {
+	if packet.Type() != packets.TypeQuery {
+		return packet, nil
+	}
+
+	var q packets.Query
+	if err := fed.ToConcrete(&q, packet); err != nil {
+		return nil, err
+	}
+
+	command, ok := ParseCommand(string(q))
+	if !ok {
+		return &q, nil
+	}
+
+	var err error
+	var tag packets.CommandComplete
+	switch command.Type {
+	case CommandListen:
+		err = T.Listen(ctx, subscriber, command.Channel)
+		tag = "LISTEN"
+	case CommandUnlisten:
+		T.Unlisten(subscriber, command.Channel)
+		tag = "UNLISTEN"
+	case CommandUnlistenAll:
+		T.UnlistenAll(subscriber)
+		tag = "UNLISTEN"
+	}
+
+	if err != nil {
+		perr, ok := err.(perror.Error)
+		if !ok {
+			perr = perror.New(
+				perror.ERROR,
+				perror.ConnectionFailure,
+				"failed to listen: "+err.Error(),
+			)
+		}
+		if err = subscriber.conn.WritePacket(ctx, perror.ToPacket(perr)); err != nil {
+			return nil, err
+		}
+	} else {
+		if err = subscriber.conn.WritePacket(ctx, &tag); err != nil {
+			return nil, err
+		}
+	}
+
+	rfq := packets.ReadyForQuery('I')
+	if err = subscriber.conn.WritePacket(ctx, &rfq); err != nil {
+		return nil, err
+	}
+
+	return nil, nil
+}
+
+// Close closes the listener connection. Subscribers will no longer receive notifications.
+func (T *Multiplexer) Close() {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	T.closed = true
+	if T.conn != nil {
+		_ = T.conn.Close(context.Background())
+	}
+}
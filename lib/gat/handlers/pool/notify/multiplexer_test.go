@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	"gfx.cafe/gfx/pggat/lib/fed/codecs/netconncodec"
+)
+
+func TestMultiplexerListenFailed(t *testing.T) {
+	conn, server := net.Pipe()
+	defer func() {
+		_ = server.Close()
+	}()
+
+	// drain the queries sent to the listener connection
+	go func() {
+		buf := make([]byte, 1024)
+		for {
+			if _, err := server.Read(buf); err != nil {
+				return
+			}
+		}
+	}()
+
+	m := NewMultiplexer(nil)
+	m.conn = fed.NewConn(netconncodec.NewCodec(conn))
+
+	first := NewSubscriber(nil)
+	second := NewSubscriber(nil)
+
+	results := make(chan error, 2)
+	go func() {
+		results <- m.Listen(context.Background(), first, "foo")
+	}()
+	// wait until the first LISTEN is in flight
+	for {
+		m.mu.Lock()
+		pending := len(m.pending)
+		m.mu.Unlock()
+		if pending == 1 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		results <- m.Listen(context.Background(), second, "foo")
+	}()
+	// wait until the second subscriber joined
+	for {
+		m.mu.Lock()
+		joined := len(m.channels["foo"].subscribers)
+		m.mu.Unlock()
+		if joined == 2 {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+
+	denied := errors.New("permission denied")
+	m.complete(denied)
+
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-results:
+			if !errors.Is(err, denied) {
+				t.Fatalf("expected every waiter to fail with %v, got %v", denied, err)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatal("expected every waiter to receive the LISTEN result")
+		}
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.channels["foo"]; ok {
+		t.Fatal("expected the failed channel to be dropped")
+	}
+	if len(first.channels) != 0 || len(second.channels) != 0 {
+		t.Fatal("expected the failed channel to be dropped from every subscriber")
+	}
+}
@@ -0,0 +1,148 @@
+package notify
+
+import (
+	"context"
+	"sync"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+)
+
+// MaxQueuedNotifications is the max number of notifications queued for a subscriber. A client that falls further
+// behind is disconnected, so it can't hold up the listener or use unbounded memory.
+const MaxQueuedNotifications = 1024
+
+// Subscriber is a client of a Multiplexer. Notifications are written to the client while it is idle between
+// transactions and queued while it is not.
+type Subscriber struct {
+	conn *fed.Conn
+
+	// channels is guarded by the Multiplexer
+	channels map[string]struct{}
+
+	idle       bool
+	flushing   bool
+	overflowed bool
+	queue      []*packets.NotificationResponse
+	mu         sync.Mutex
+
+	// writeMu is held while writing to the client. It is never held by the Multiplexer
+	writeMu sync.Mutex
+}
+
+func NewSubscriber(conn *fed.Conn) *Subscriber {
+	return &Subscriber{
+		conn:     conn,
+		channels: make(map[string]struct{}),
+	}
+}
+
+// Idle writes the queued notifications and marks the client as idle, so notifications are written as soon as they
+// arrive until Busy is called. Only call it between transactions, from the goroutine serving the client.
+func (T *Subscriber) Idle(ctx context.Context) error {
+	T.mu.Lock()
+	T.idle = true
+	T.mu.Unlock()
+
+	return T.flush(ctx)
+}
+
+// Busy marks the client as busy. Notifications are queued until the next call to Idle. Returns once any notification
+// being written has been written, so the caller can use the client again.
+func (T *Subscriber) Busy() {
+	T.mu.Lock()
+	T.idle = false
+	T.mu.Unlock()
+
+	T.writeMu.Lock()
+	T.writeMu.Unlock()
+}
+
+// take removes the queued notifications if the client is idle
+func (T *Subscriber) take() []*packets.NotificationResponse {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	if !T.idle {
+		return nil
+	}
+
+	queue := T.queue
+	T.queue = nil
+	return queue
+}
+
+// flush writes queued notifications until the queue is empty or the client is busy
+func (T *Subscriber) flush(ctx context.Context) error {
+	T.writeMu.Lock()
+	defer T.writeMu.Unlock()
+
+	for {
+		queue := T.take()
+		if len(queue) == 0 {
+			return nil
+		}
+
+		for _, packet := range queue {
+			if err := T.conn.WriteAsync(ctx, packet); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func (T *Subscriber) notify(packet *packets.NotificationResponse) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	if T.overflowed {
+		return
+	}
+
+	if len(T.queue) >= MaxQueuedNotifications {
+		// the client isn't keeping up, drop it instead of its notifications
+		T.overflowed = true
+		T.queue = nil
+		go func() {
+			_ = T.conn.Close(context.Background())
+		}()
+		return
+	}
+
+	T.queue = append(T.queue, packet)
+	if !T.idle || T.flushing {
+		return
+	}
+
+	// write from another goroutine so a slow client doesn't hold up the others
+	T.flushing = true
+	go T.flushAsync()
+}
+
+// flushAsync is flush for notifications which arrive while the client is idle
+func (T *Subscriber) flushAsync() {
+	T.writeMu.Lock()
+	defer T.writeMu.Unlock()
+
+	for {
+		T.mu.Lock()
+		if !T.idle || len(T.queue) == 0 {
+			T.flushing = false
+			T.mu.Unlock()
+			return
+		}
+		queue := T.queue
+		T.queue = nil
+		T.mu.Unlock()
+
+		for _, packet := range queue {
+			if err := T.conn.WriteAsync(context.Background(), packet); err != nil {
+				// write errors will be noticed by the goroutine serving the client
+				T.mu.Lock()
+				T.flushing = false
+				T.mu.Unlock()
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	"gfx.cafe/gfx/pggat/lib/fed/codecs/netconncodec"
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+)
+
+func TestSubscriberSlowClient(t *testing.T) {
+	// nothing reads from the client, so writes block forever
+	client, other := net.Pipe()
+	defer func() {
+		_ = other.Close()
+	}()
+
+	subscriber := NewSubscriber(fed.NewConn(netconncodec.NewCodec(client)))
+	subscriber.idle = true
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i <= MaxQueuedNotifications+1; i++ {
+			subscriber.notify(&packets.NotificationResponse{
+				Channel: "foo",
+			})
+		}
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expected notify not to block on a slow client")
+	}
+
+	subscriber.mu.Lock()
+	overflowed := subscriber.overflowed
+	queued := len(subscriber.queue)
+	subscriber.mu.Unlock()
+	if !overflowed || queued != 0 {
+		t.Fatalf("expected the subscriber to overflow and drop its queue, overflowed=%v queued=%d", overflowed, queued)
+	}
+
+	// the client is disconnected, which unblocks the write
+	_ = other.SetReadDeadline(time.Now().Add(5 * time.Second))
+	buf := make([]byte, 1024)
+	for {
+		if _, err := other.Read(buf); err != nil {
+			break
+		}
+	}
+}
@@ -11,3 +11,12 @@ type Critic interface {
 	// Taste calculates how much conn should be penalized. Lower is better
 	Taste(ctx context.Context, conn *fed.Conn) (score int, validity time.Duration, err error)
 }
+
+// RoleCritic is a Critic that can also tell primaries and replicas apart. Pools with separate primary and replica
+// servers use it to follow failovers
+type RoleCritic interface {
+	Critic
+
+	// Primary reports whether conn accepts writes
+	Primary(ctx context.Context, conn *fed.Conn) (primary bool, validity time.Duration, err error)
+}
@@ -0,0 +1,85 @@
+package pin
+
+import (
+	"strings"
+
+	"gfx.cafe/gfx/pggat/lib/util/sqlscan"
+)
+
+// Reason is the session-level feature which caused a client to be pinned
+type Reason string
+
+const (
+	ReasonNone           Reason = ""
+	ReasonTemporaryTable Reason = "temporary table"
+	ReasonAdvisoryLock   Reason = "session advisory lock"
+	ReasonSet            Reason = "SET"
+	ReasonHoldCursor     Reason = "cursor WITH HOLD"
+	ReasonPrepare        Reason = "PREPARE"
+)
+
+func isTemp(token sqlscan.Token) bool {
+	return token.Is("TEMP") || token.Is("TEMPORARY")
+}
+
+// isAdvisoryLock returns whether name is a function that takes a session-level advisory lock. The xact variants are
+// released at the end of the transaction, so they are fine.
+func isAdvisoryLock(name string) bool {
+	switch strings.ToLower(name) {
+	case "pg_advisory_lock", "pg_advisory_lock_shared", "pg_try_advisory_lock", "pg_try_advisory_lock_shared":
+		return true
+	default:
+		return false
+	}
+}
+
+// Check returns the session-level feature statement uses, or ReasonNone
+func Check(statement sqlscan.Statement) Reason {
+	switch {
+	case statement.Is("CREATE"):
+		// CREATE [ GLOBAL | LOCAL ] { TEMPORARY | TEMP } ...
+		i := 1
+		if i < len(statement) && (statement[i].Is("GLOBAL") || statement[i].Is("LOCAL")) {
+			i++
+		}
+		if i < len(statement) && isTemp(statement[i]) {
+			return ReasonTemporaryTable
+		}
+	case statement.Is("SET"):
+		if statement.Is("SET", "LOCAL") || statement.Is("SET", "TRANSACTION") || statement.Is("SET", "CONSTRAINTS") {
+			return ReasonNone
+		}
+		return ReasonSet
+	case statement.Is("DECLARE"):
+		for i := 1; i+1 < len(statement); i++ {
+			if statement[i].Is("FOR") {
+				break
+			}
+			if statement[i].Is("WITH") && statement[i+1].Is("HOLD") {
+				return ReasonHoldCursor
+			}
+		}
+	case statement.Is("PREPARE"):
+		if !statement.Is("PREPARE", "TRANSACTION") {
+			return ReasonPrepare
+		}
+	}
+
+	for i := 0; i+1 < len(statement); i++ {
+		token, next := statement[i], statement[i+1]
+		if token.Kind == sqlscan.Word && isAdvisoryLock(token.Value) && next.Kind == sqlscan.Symbol && next.Value == "(" {
+			return ReasonAdvisoryLock
+		}
+		// SELECT ... INTO TEMP
+		if token.Is("INTO") && isTemp(next) {
+			return ReasonTemporaryTable
+		}
+	}
+
+	return ReasonNone
+}
+
+// IsDiscardAll returns whether statement resets the session
+func IsDiscardAll(statement sqlscan.Statement) bool {
+	return len(statement) == 2 && statement.Is("DISCARD", "ALL")
+}
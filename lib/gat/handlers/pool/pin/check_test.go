@@ -0,0 +1,68 @@
+package pin
+
+import (
+	"testing"
+
+	"gfx.cafe/gfx/pggat/lib/util/sqlscan"
+)
+
+func TestCheck(t *testing.T) {
+	cases := []struct {
+		query  string
+		reason Reason
+	}{
+		{"CREATE TEMP TABLE foo (id int)", ReasonTemporaryTable},
+		{"create global temporary table foo (id int)", ReasonTemporaryTable},
+		{"SELECT * INTO TEMP foo FROM bar", ReasonTemporaryTable},
+		{"CREATE TABLE temp (id int)", ReasonNone},
+		{"SELECT pg_advisory_lock(1)", ReasonAdvisoryLock},
+		{"SELECT pg_catalog.pg_try_advisory_lock_shared(1, 2)", ReasonAdvisoryLock},
+		{"SELECT pg_advisory_xact_lock(1)", ReasonNone},
+		{"SELECT 'pg_advisory_lock(1)'", ReasonNone},
+		{"SET statement_timeout = 0", ReasonSet},
+		{"SET SESSION search_path TO public", ReasonSet},
+		{"SET LOCAL statement_timeout = 0", ReasonNone},
+		{"SET TRANSACTION ISOLATION LEVEL SERIALIZABLE", ReasonNone},
+		{"UPDATE foo SET bar = 1", ReasonNone},
+		{"DECLARE c CURSOR WITH HOLD FOR SELECT 1", ReasonHoldCursor},
+		{"DECLARE c CURSOR WITHOUT HOLD FOR SELECT 1", ReasonNone},
+		{"DECLARE c CURSOR FOR SELECT 1 WITH HOLD", ReasonNone},
+		{"PREPARE q AS SELECT 1", ReasonPrepare},
+		{"PREPARE TRANSACTION 'foo'", ReasonNone},
+	}
+
+	for _, c := range cases {
+		statements := sqlscan.Split(c.query)
+		if len(statements) != 1 {
+			t.Errorf("%q: expected one statement", c.query)
+			continue
+		}
+		if reason := Check(statements[0]); reason != c.reason {
+			t.Errorf("%q: expected %q but got %q", c.query, c.reason, reason)
+		}
+	}
+}
+
+func TestTracker(t *testing.T) {
+	tracker := NewTracker()
+
+	tracker.check("SELECT 1")
+	if tracker.Reason() != ReasonNone {
+		t.Fatal("expected client not to be pinned")
+	}
+
+	tracker.check("SELECT 1; SET search_path = foo")
+	if tracker.Reason() != ReasonSet {
+		t.Fatal("expected client to be pinned")
+	}
+
+	tracker.check("SELECT pg_advisory_lock(1)")
+	if tracker.Reason() != ReasonSet {
+		t.Error("expected first reason to be kept")
+	}
+
+	tracker.check("DISCARD ALL")
+	if tracker.Reason() != ReasonNone {
+		t.Error("expected DISCARD ALL to unpin client")
+	}
+}
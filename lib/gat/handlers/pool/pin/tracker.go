@@ -0,0 +1,82 @@
+package pin
+
+import (
+	"context"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+	"gfx.cafe/gfx/pggat/lib/util/sqlscan"
+)
+
+// Tracker is a client middleware which watches queries for session-level features. Once one is used, the client is
+// pinned until it runs DISCARD ALL.
+type Tracker struct {
+	reason Reason
+	query  string
+}
+
+func NewTracker() *Tracker {
+	return new(Tracker)
+}
+
+// Reason returns why the client is pinned, or ReasonNone if it is not
+func (T *Tracker) Reason() Reason {
+	return T.reason
+}
+
+// Query returns the query which pinned the client
+func (T *Tracker) Query() string {
+	return T.query
+}
+
+func (T *Tracker) check(query string) {
+	for _, statement := range sqlscan.Split(query) {
+		if IsDiscardAll(statement) {
+			T.reason = ReasonNone
+			T.query = ""
+			continue
+		}
+		if T.reason != ReasonNone {
+			continue
+		}
+		if reason := Check(statement); reason != ReasonNone {
+			T.reason = reason
+			T.query = query
+		}
+	}
+}
+
+func (T *Tracker) PreRead(_ context.Context, _ bool) (fed.Packet, error) {
+	return nil, nil
+}
+
+func (T *Tracker) ReadPacket(_ context.Context, packet fed.Packet) (fed.Packet, error) {
+	switch packet.Type() {
+	case packets.TypeQuery:
+		var p packets.Query
+		if err := fed.ToConcrete(&p, packet); err != nil {
+			return nil, err
+		}
+		T.check(string(p))
+		return &p, nil
+	case packets.TypeParse:
+		var p packets.Parse
+		if err := fed.ToConcrete(&p, packet); err != nil {
+			return nil, err
+		}
+		T.check(p.Query)
+		return &p, nil
+	default:
+		return packet, nil
+	}
+}
+
+func (T *Tracker) WritePacket(_ context.Context, packet fed.Packet) (fed.Packet, error) {
+	return packet, nil
+}
+
+func (T *Tracker) PostWrite(_ context.Context) (fed.Packet, error) {
+	return nil, nil
+}
+
+var _ fed.Middleware = (*Tracker)(nil)
@@ -12,6 +12,8 @@ type Pool interface {
 	AddRecipe(ctx context.Context, name string, recipe *Recipe)
 	// RemoveRecipe will remove a recipe and disconnect all servers created by that recipe.
 	RemoveRecipe(ctx context.Context, name string)
+	// PrimaryRecipe returns the preferred recipe of the primary servers, or false if the pool has none.
+	PrimaryRecipe() (*Recipe, bool)
 
 	Serve(ctx context.Context, conn *fed.Conn) error
 
@@ -4,10 +4,13 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
 )
 
 type Pooler interface {
-	AddClient(id uuid.UUID)
+	// AddClient adds a client. conn may be used to decide how the client should be scheduled
+	AddClient(id uuid.UUID, conn *fed.Conn)
 	DeleteClient(client uuid.UUID)
 
 	AddServer(id uuid.UUID)
@@ -6,6 +6,7 @@ import (
 
 	"github.com/google/uuid"
 
+	"gfx.cafe/gfx/pggat/lib/fed"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
 	"gfx.cafe/gfx/pggat/lib/util/pools"
 	"gfx.cafe/gfx/pggat/lib/util/ring"
@@ -30,7 +31,7 @@ func NewPooler() *Pooler {
 	}
 }
 
-func (*Pooler) AddClient(_ uuid.UUID) {}
+func (*Pooler) AddClient(_ uuid.UUID, _ *fed.Conn) {}
 
 func (*Pooler) DeleteClient(_ uuid.UUID) {
 	// nothing to do
@@ -0,0 +1,30 @@
+package rob
+
+type GroupBy string
+
+const (
+	// GroupByUser groups clients by their user
+	GroupByUser GroupBy = "user"
+	// GroupByApplicationName groups clients by their application_name parameter
+	GroupByApplicationName GroupBy = "application_name"
+)
+
+type Group struct {
+	// Weight is the share of server time the group as a whole receives relative to other groups, no matter how many
+	// clients are in it. Clients in the group share it equally, and clients not in a group are each a group of their
+	// own with weight 1. 0 = 1
+	Weight float64 `json:"weight,omitempty"`
+
+	// MaxConnections is the max number of servers that clients in the group may hold at once.
+	// 0 = unlimited
+	MaxConnections int `json:"max_connections,omitempty"`
+}
+
+type Config struct {
+	// GroupBy controls which client property is used to look up its group. Defaults to GroupByUser
+	GroupBy GroupBy `json:"group_by,omitempty"`
+
+	// Groups maps a user or application_name (depending on GroupBy) to its scheduling options. Clients not in a group
+	// have a weight of 1 and no limit.
+	Groups map[string]Group `json:"groups,omitempty"`
+}
@@ -11,6 +11,7 @@ func init() {
 }
 
 type Factory struct {
+	Config
 }
 
 func (T *Factory) CaddyModule() caddy.ModuleInfo {
@@ -23,7 +24,7 @@ func (T *Factory) CaddyModule() caddy.ModuleInfo {
 }
 
 func (T *Factory) NewPooler() pool.Pooler {
-	return NewPooler()
+	return NewPooler(T.Config)
 }
 
 var _ pool.PoolerFactory = (*Factory)(nil)
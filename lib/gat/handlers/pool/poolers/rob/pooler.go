@@ -5,22 +5,50 @@ import (
 
 	"github.com/google/uuid"
 
+	"gfx.cafe/gfx/pggat/lib/fed"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
 	"gfx.cafe/gfx/pggat/lib/rob/schedulers/v3"
+	"gfx.cafe/gfx/pggat/lib/util/strutil"
 )
 
 type Pooler struct {
+	config Config
+
 	s schedulers.Scheduler
 }
 
-func NewPooler() *Pooler {
-	return &Pooler{
+func NewPooler(config Config) *Pooler {
+	p := &Pooler{
+		config: config,
+
 		s: schedulers.MakeScheduler(),
 	}
+	for name, group := range config.Groups {
+		p.s.SetGroup(name, group.Weight, group.MaxConnections)
+	}
+	return p
+}
+
+func (T *Pooler) group(conn *fed.Conn) string {
+	if conn == nil {
+		return ""
+	}
+
+	switch T.config.GroupBy {
+	case GroupByApplicationName:
+		return conn.InitialParameters[strutil.MakeCIString("application_name")]
+	default:
+		return conn.User
+	}
 }
 
-func (T *Pooler) AddClient(id uuid.UUID) {
-	T.s.AddUser(id)
+func (T *Pooler) AddClient(id uuid.UUID, conn *fed.Conn) {
+	if len(T.config.Groups) == 0 {
+		T.s.AddUser(id)
+		return
+	}
+
+	T.s.AddGroupUser(id, T.group(conn))
 }
 
 func (T *Pooler) DeleteClient(client uuid.UUID) {
@@ -17,6 +17,9 @@ type Client struct {
 	ID   uuid.UUID
 	Conn *fed.Conn
 
+	// admitted is whether the client passed the saturation check on its first acquire. Only new clients are rejected
+	admitted bool
+
 	txnCount atomic.Int64
 
 	lastMetricsRead time.Time
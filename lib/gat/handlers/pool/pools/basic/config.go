@@ -78,6 +78,20 @@ type Config struct {
 	// Use true for transaction pooling
 	ExtendedQuerySync bool `json:"extended_query_sync,omitempty"`
 
+	// MaxPreparedStatements is the max number of named prepared statements kept on each server. Clients preparing the
+	// same query share one statement on the server. Requires extended_query_sync
+	// 0 = disable, prepared statements are synced by name
+	MaxPreparedStatements int `json:"max_prepared_statements,omitempty"`
+
+	// PinSessionFeatures keeps a client on its server until it disconnects or runs DISCARD ALL once it uses a
+	// session-level feature: temporary tables, session advisory locks, SET without LOCAL, cursors WITH HOLD, or PREPARE.
+	// Only applies with release_after_transaction
+	PinSessionFeatures bool `json:"pin_session_features,omitempty"`
+
+	// MultiplexNotifications shares one LISTEN connection between all clients, so LISTEN keeps working when servers
+	// are released after each transaction. Requires release_after_transaction
+	MultiplexNotifications bool `json:"multiplex_notifications,omitempty"`
+
 	// PacketTracingOption enables/disables packet debug tracing for client and/or
 	// server connections
 	PacketTracingOption TracingOption `json:"packet_tracing_option,omitempty"`
@@ -92,6 +106,28 @@ type Config struct {
 	// ClientAcquireTimeout defines how long a client may be in AWAITING_SERVER state before it is disconnected
 	ClientAcquireTimeout caddy.Duration `json:"client_acquire_timeout,omitempty"`
 
+	// ClientMaxWaiting is the max number of clients that may wait for a server. Clients past this are rejected
+	// immediately instead of waiting for client_acquire_timeout
+	// 0 = unlimited
+	ClientMaxWaiting int `json:"client_max_waiting,omitempty"`
+
+	// ClientMaxWaitEstimate rejects clients immediately if their estimated wait for a server is longer
+	// 0 = disable
+	ClientMaxWaitEstimate caddy.Duration `json:"client_max_wait_estimate,omitempty"`
+
+	// ClientIdleTimeout defines how long a client may be idle outside a transaction before it is disconnected
+	// 0 = disable
+	ClientIdleTimeout caddy.Duration `json:"client_idle_timeout,omitempty"`
+
+	// IdleTransactionTimeout defines how long a client may be idle inside a transaction before it is disconnected and
+	// the transaction is aborted
+	// 0 = disable
+	IdleTransactionTimeout caddy.Duration `json:"idle_transaction_timeout,omitempty"`
+
+	// QueryTimeout defines how long a query may run before it is canceled
+	// 0 = disable
+	QueryTimeout caddy.Duration `json:"query_timeout,omitempty"`
+
 	// ServerIdleTimeout defines how long a server may be idle before it is disconnected
 	ServerIdleTimeout caddy.Duration `json:"server_idle_timeout,omitempty"`
 
@@ -109,6 +145,10 @@ type Config struct {
 	RawCritics []json.RawMessage `json:"critics,omitempty" caddy:"namespace=pggat.handlers.pool.critics inline_key=critic"`
 	Critics    []pool.Critic     `json:"-"`
 
+	// RawBalancer picks which recipe new servers are opened with. If unset, the best rated recipe is used
+	RawBalancer     json.RawMessage      `json:"balancer,omitempty" caddy:"namespace=pggat.handlers.pool.balancers inline_key=balancer"`
+	BalancerFactory pool.BalancerFactory `json:"-"`
+
 	Logger *zap.Logger `json:"-"`
 }
 
@@ -120,19 +160,24 @@ func (T Config) Spool() spool.Config {
 	}
 
 	return spool.Config{
-		PoolerFactory:        T.PoolerFactory,
-		UsePS:                T.ParameterStatusSync == ParameterStatusSyncDynamic,
-		UseEQP:               T.ExtendedQuerySync,
-		UseOtelTracing:       (T.OtelTracingOption & TracingOptionServer) != 0,
-		UsePacketTracing:     (T.PacketTracingOption & TracingOptionServer) != 0,
-		ResetQuery:           T.ServerResetQuery,
-		ResetQueryTimeout:    resetQueryTimeout,
-		AcquireTimeout:       time.Duration(T.ClientAcquireTimeout),
-		IdleTimeout:          time.Duration(T.ServerIdleTimeout),
-		ReconnectInitialTime: time.Duration(T.ServerReconnectInitialTime),
-		ReconnectMaxTime:     time.Duration(T.ServerReconnectMaxTime),
-
-		Critics: T.Critics,
+		PoolerFactory:         T.PoolerFactory,
+		UsePS:                 T.ParameterStatusSync == ParameterStatusSyncDynamic,
+		UseEQP:                T.ExtendedQuerySync,
+		MaxPreparedStatements: T.MaxPreparedStatements,
+		UseOtelTracing:        (T.OtelTracingOption & TracingOptionServer) != 0,
+		UsePacketTracing:      (T.PacketTracingOption & TracingOptionServer) != 0,
+		ResetQuery:            T.ServerResetQuery,
+		ResetQueryTimeout:     resetQueryTimeout,
+		AcquireTimeout:        time.Duration(T.ClientAcquireTimeout),
+		QueryTimeout:          time.Duration(T.QueryTimeout),
+		MaxWaiters:            T.ClientMaxWaiting,
+		MaxWaitEstimate:       time.Duration(T.ClientMaxWaitEstimate),
+		IdleTimeout:           time.Duration(T.ServerIdleTimeout),
+		ReconnectInitialTime:  time.Duration(T.ServerReconnectInitialTime),
+		ReconnectMaxTime:      time.Duration(T.ServerReconnectMaxTime),
+
+		Critics:         T.Critics,
+		BalancerFactory: T.BalancerFactory,
 
 		Logger: T.Logger,
 	}
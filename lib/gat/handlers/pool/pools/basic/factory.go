@@ -42,6 +42,15 @@ func (T *Factory) Provision(ctx caddy.Context) error {
 		}
 	}
 
+	if T.RawBalancer != nil {
+		raw, err := ctx.LoadModule(T, "RawBalancer")
+		if err != nil {
+			return fmt.Errorf("loading balancer module: %v", err)
+		}
+
+		T.BalancerFactory = raw.(pool.BalancerFactory)
+	}
+
 	raw, err := ctx.LoadModule(T, "RawPoolerFactory")
 	if err != nil {
 		return err
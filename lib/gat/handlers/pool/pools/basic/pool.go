@@ -13,6 +13,7 @@ import (
 	"go.opentelemetry.io/otel/trace"
 
 	"github.com/google/uuid"
+	"go.uber.org/zap"
 
 	"gfx.cafe/gfx/pggat/lib/bouncer/backends/v0"
 	"gfx.cafe/gfx/pggat/lib/bouncer/bouncers/v2"
@@ -21,6 +22,8 @@ import (
 	"gfx.cafe/gfx/pggat/lib/fed/middlewares/ps"
 	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool/notify"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool/pin"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool/spool"
 	"gfx.cafe/gfx/pggat/lib/gat/metrics"
 	"gfx.cafe/gfx/pggat/lib/instrumentation/prom"
@@ -31,6 +34,7 @@ type Pool struct {
 	config Config
 
 	servers spool.Pool
+	notify  *notify.Multiplexer
 
 	clients map[fed.BackendKey]*Client
 	mu      sync.RWMutex
@@ -46,16 +50,29 @@ func NewPool(ctx context.Context, config Config) *Pool {
 			attribute.String("component", "gfx.cafe/gfx/pggat/lib/gat/handlers/pool/pools/basic/pool.go"),
 		)),
 	}
+	if config.MultiplexNotifications && config.ReleaseAfterTransaction {
+		p.notify = notify.NewMultiplexer(config.Logger)
+	}
 	go p.servers.ScaleLoop(ctx)
 	return p
 }
 
 func (T *Pool) AddRecipe(ctx context.Context, name string, recipe *pool.Recipe) {
 	T.servers.AddRecipe(ctx, name, recipe)
+	if T.notify != nil {
+		T.notify.AddRecipe(name, recipe)
+	}
 }
 
 func (T *Pool) RemoveRecipe(ctx context.Context, name string) {
 	T.servers.RemoveRecipe(ctx, name)
+	if T.notify != nil {
+		T.notify.RemoveRecipe(name)
+	}
+}
+
+func (T *Pool) PrimaryRecipe() (*pool.Recipe, bool) {
+	return T.servers.Recipe()
 }
 
 func (T *Pool) SyncInitialParameters(ctx context.Context, client *Client, server *spool.Server) (err, serverErr error) {
@@ -186,6 +203,23 @@ func (T *Pool) pair(ctx context.Context, client *Client, server *spool.Server) (
 	return
 }
 
+func (T *Pool) acquire(client *Client, labels prom.OperationSimpleLabels) (*spool.Server, error) {
+	if !client.admitted {
+		if T.servers.Saturated() {
+			prom.OperationSimple.Rejected(labels).Inc()
+			return nil, pool.ErrPoolSaturated
+		}
+		client.admitted = true
+	}
+
+	// every acquiring client counts itself, so the gauge is current even while nobody else acquires
+	waiters := prom.OperationSimple.Waiters(labels)
+	waiters.Inc()
+	defer waiters.Dec()
+
+	return T.servers.Acquire(client.ID)
+}
+
 func (T *Pool) addClient(client *Client) {
 	T.mu.Lock()
 	defer T.mu.Unlock()
@@ -203,10 +237,38 @@ func (T *Pool) removeClient(client *Client) {
 	delete(T.clients, client.Conn.BackendKey)
 }
 
+func (T *Pool) pinChanged(conn *fed.Conn, reason pin.Reason, query string) {
+	if reason == pin.ReasonNone {
+		T.config.Logger.Debug(
+			"client unpinned",
+			zap.String("user", conn.User),
+			zap.String("database", conn.Database),
+		)
+		return
+	}
+
+	T.config.Logger.Info(
+		"client pinned to server for using a session-level feature",
+		zap.String("user", conn.User),
+		zap.String("database", conn.Database),
+		zap.String("reason", string(reason)),
+		zap.String("query", query),
+	)
+	prom.Pins.Pinned(prom.PinLabels{
+		Database: conn.Database,
+		User:     conn.User,
+		Reason:   string(reason),
+	}).Inc()
+}
+
 func (T *Pool) Serve(ctx context.Context, conn *fed.Conn) error {
 	ctx, span := T.tracer.Start(ctx, "Server")
 	defer span.End()
 
+	if conn.Replication != "" {
+		return pool.ServeReplication(ctx, conn, T)
+	}
+
 	// returning 2 errors is questionable
 	err := T.serve(ctx, conn)
 	if err != nil {
@@ -230,6 +292,15 @@ func (T *Pool) serve(ctx context.Context, conn *fed.Conn) error {
 			tracing.NewOtelTrace())
 	}
 
+	var tracker *pin.Tracker
+	if T.config.PinSessionFeatures && T.config.ReleaseAfterTransaction {
+		tracker = pin.NewTracker()
+		conn.Middleware = append(
+			conn.Middleware,
+			tracker,
+		)
+	}
+
 	if T.config.ParameterStatusSync == ParameterStatusSyncDynamic {
 		conn.Middleware = append(
 			conn.Middleware,
@@ -248,9 +319,20 @@ func (T *Pool) serve(ctx context.Context, conn *fed.Conn) error {
 	T.addClient(client)
 	defer T.removeClient(client)
 
-	T.servers.AddClient(client.ID)
+	T.servers.AddClient(client.ID, conn)
 	defer T.servers.RemoveClient(client.ID)
 
+	poolLabels := prom.PoolSimpleLabels{
+		Database: conn.Database,
+		User:     conn.User,
+	}
+	if T.config.ReleaseAfterTransaction {
+		poolLabels.Mode = "transaction"
+	} else {
+		poolLabels.Mode = "session"
+	}
+	opLabels := poolLabels.ToOperation()
+
 	var err, serverErr error
 
 	var server *spool.Server
@@ -268,9 +350,9 @@ func (T *Pool) serve(ctx context.Context, conn *fed.Conn) error {
 	if !client.Conn.Ready {
 		client.SetState(metrics.ConnStateAwaitingServer, nil)
 
-		server = T.servers.Acquire(client.ID)
-		if server == nil {
-			return pool.ErrFailedToAcquirePeer
+		server, err = T.acquire(client, opLabels)
+		if err != nil {
+			return err
 		}
 
 		err, serverErr = T.Pair(ctx, client, server)
@@ -290,41 +372,61 @@ func (T *Pool) serve(ctx context.Context, conn *fed.Conn) error {
 		client.Conn.Ready = true
 	}
 
-	poolLabels := prom.PoolSimpleLabels{
-		Database: conn.Database,
-		User:     conn.User,
-	}
-	{
-		if T.config.ReleaseAfterTransaction {
-			poolLabels.Mode = "transaction"
-		} else {
-			poolLabels.Mode = "session"
-		}
-		prom.PoolSimple.Accepted(poolLabels).Inc()
-		prom.PoolSimple.Current(poolLabels).Inc()
-		defer prom.PoolSimple.Current(poolLabels).Dec()
+	prom.PoolSimple.Accepted(poolLabels).Inc()
+	prom.PoolSimple.Current(poolLabels).Inc()
+	defer prom.PoolSimple.Current(poolLabels).Dec()
+
+	var subscriber *notify.Subscriber
+	if T.notify != nil {
+		subscriber = notify.NewSubscriber(conn)
+		defer T.notify.UnlistenAll(subscriber)
 	}
-	opLabels := poolLabels.ToOperation()
+
+	var pinned pin.Reason
 	for {
-		if server != nil && T.config.ReleaseAfterTransaction {
+		if tracker != nil && tracker.Reason() != pinned {
+			pinned = tracker.Reason()
+			T.pinChanged(conn, pinned, tracker.Query())
+		}
+
+		if server != nil && T.config.ReleaseAfterTransaction && pinned == pin.ReasonNone {
 			client.SetState(metrics.ConnStateIdle, nil)
 			T.servers.Release(ctx, server)
 			server = nil
 		}
 
+		if subscriber != nil {
+			if err = subscriber.Idle(ctx); err != nil {
+				return err
+			}
+		}
+
 		var packet fed.Packet
-		packet, err = client.Conn.ReadPacket(ctx, true)
+		packet, err = pool.ReadIdlePacket(ctx, client.Conn, time.Duration(T.config.ClientIdleTimeout))
+		if subscriber != nil {
+			subscriber.Busy()
+		}
 		if err != nil {
 			return err
 		}
 
+		if subscriber != nil {
+			packet, err = T.notify.HandlePacket(ctx, subscriber, packet)
+			if err != nil {
+				return err
+			}
+			if packet == nil {
+				continue
+			}
+		}
+
 		if server == nil {
 			start := time.Now()
 			client.SetState(metrics.ConnStateAwaitingServer, nil)
 
-			server = T.servers.Acquire(client.ID)
-			if server == nil {
-				return pool.ErrFailedToAcquirePeer
+			server, err = T.acquire(client, opLabels)
+			if err != nil {
+				return err
 			}
 
 			err, serverErr = T.Pair(ctx, client, server)
@@ -336,7 +438,7 @@ func (T *Pool) serve(ctx context.Context, conn *fed.Conn) error {
 		if err == nil && serverErr == nil {
 			{
 				start := time.Now()
-				err, serverErr = bouncers.Bounce(ctx, client.Conn, server.Conn, packet)
+				err, serverErr = bouncers.BounceIdleTimeout(ctx, client.Conn, server.Conn, packet, time.Duration(T.config.IdleTransactionTimeout))
 				if serverErr == nil {
 					dur := time.Since(start)
 					prom.OperationSimple.Execution(opLabels).Observe(float64(dur) / float64(time.Millisecond))
@@ -405,6 +507,9 @@ func (T *Pool) Close(ctx context.Context) {
 	defer span.End()
 
 	T.servers.Close(ctx)
+	if T.notify != nil {
+		T.notify.Close()
+	}
 }
 
 var _ pool.Pool = (*Pool)(nil)
@@ -1,33 +1,92 @@
 package hybrid
 
-import "io"
+import (
+	"io"
+	"os"
 
+	"gfx.cafe/gfx/pggat/lib/fed"
+)
+
+// Buffer is an in memory buffer which spills to a temp file once it holds more than Max bytes
 type Buffer struct {
-	buf []byte
-	pos int
+	// Max is how many bytes are kept in memory. 0 = unlimited
+	Max int
+
+	mem []byte
+	// file holds the bytes after mem once it is full
+	file     *os.File
+	fileSize int64
+	// pos is the read position across mem and file
+	pos int64
 }
 
 func (T *Buffer) Read(b []byte) (int, error) {
 	if T.Buffered() == 0 {
 		return 0, io.EOF
 	}
-	n := copy(b, T.buf[T.pos:])
-	T.pos += n
-	return n, nil
+
+	if T.pos < int64(len(T.mem)) {
+		n := copy(b, T.mem[T.pos:])
+		T.pos += int64(n)
+		return n, nil
+	}
+
+	off := T.pos - int64(len(T.mem))
+	if rem := T.fileSize - off; int64(len(b)) > rem {
+		b = b[:rem]
+	}
+	n, err := T.file.ReadAt(b, off)
+	T.pos += int64(n)
+	if err == io.EOF && n > 0 {
+		err = nil
+	}
+	return n, err
 }
 
 func (T *Buffer) Write(b []byte) (int, error) {
-	T.buf = append(T.buf, b...)
-	return len(b), nil
+	if T.file == nil && (T.Max == 0 || len(T.mem)+len(b) <= T.Max) {
+		T.mem = append(T.mem, b...)
+		return len(b), nil
+	}
+
+	if T.file == nil {
+		var err error
+		T.file, err = os.CreateTemp("", "pggat-hybrid-*")
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := T.file.WriteAt(b, T.fileSize)
+	T.fileSize += int64(n)
+	return n, err
 }
 
 func (T *Buffer) Buffered() int {
-	return len(T.buf) - T.pos
+	return int(int64(len(T.mem)) + T.fileSize - T.pos)
+}
+
+// Spilled returns whether the buffer has outgrown memory
+func (T *Buffer) Spilled() bool {
+	return T.file != nil
 }
 
+// Rewind moves the read position back to the first packet dec, reading from T, hasn't reached yet
+func (T *Buffer) Rewind(dec *fed.Decoder) {
+	T.pos = T.pos - int64(dec.Buffered()) + int64(dec.Length()-dec.Position())
+}
+
+// Reset empties the buffer and removes the temp file
 func (T *Buffer) Reset() {
-	T.buf = T.buf[:0]
+	T.mem = T.mem[:0]
 	T.pos = 0
+
+	if T.file != nil {
+		_ = T.file.Close()
+		_ = os.Remove(T.file.Name())
+		T.file = nil
+		T.fileSize = 0
+	}
 }
 
 func (T *Buffer) ResetRead() {
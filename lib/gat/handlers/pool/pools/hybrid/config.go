@@ -16,6 +16,42 @@ import (
 type Config struct {
 	ClientAcquireTimeout caddy.Duration `json:"client_acquire_timeout,omitempty"`
 
+	// ClientMaxWaiting is the max number of clients that may wait for a server. Clients past this are rejected
+	// immediately instead of waiting for client_acquire_timeout
+	// 0 = unlimited
+	ClientMaxWaiting int `json:"client_max_waiting,omitempty"`
+
+	// ClientMaxWaitEstimate rejects clients immediately if their estimated wait for a server is longer
+	// 0 = disable
+	ClientMaxWaitEstimate caddy.Duration `json:"client_max_wait_estimate,omitempty"`
+
+	// ClientIdleTimeout defines how long a client may be idle outside a transaction before it is disconnected
+	// 0 = disable
+	ClientIdleTimeout caddy.Duration `json:"client_idle_timeout,omitempty"`
+
+	// IdleTransactionTimeout defines how long a client may be idle inside a transaction before it is disconnected and
+	// the transaction is aborted
+	// 0 = disable
+	IdleTransactionTimeout caddy.Duration `json:"idle_transaction_timeout,omitempty"`
+
+	// QueryTimeout defines how long a query may run before it is canceled
+	// 0 = disable
+	QueryTimeout caddy.Duration `json:"query_timeout,omitempty"`
+
+	// MaxPreparedStatements is the max number of named prepared statements kept on each server. Clients preparing the
+	// same query share one statement on the server
+	// 0 = disable, prepared statements are synced by name
+	MaxPreparedStatements int `json:"max_prepared_statements,omitempty"`
+
+	// MultiplexNotifications shares one LISTEN connection to the primary between all clients, so LISTEN keeps working
+	// even though servers are released after each transaction
+	MultiplexNotifications bool `json:"multiplex_notifications,omitempty"`
+
+	// MaxBufferSize is how many bytes of a transaction sent to a replica are buffered in memory, in case it has to be
+	// replayed on the primary. The rest is spilled to a temp file
+	// 0 = unlimited
+	MaxBufferSize int `json:"max_buffer_size,omitempty"`
+
 	ServerResetQuery        string         `json:"server_reset_query,omitempty"`
 	ServerResetQueryTimeout caddy.Duration `json:"server_reset_query_timeout,omitempty"`
 
@@ -29,6 +65,10 @@ type Config struct {
 	RawCritics []json.RawMessage `json:"critics,omitempty" caddy:"namespace=pggat.handlers.pool.critics inline_key=critic"`
 	Critics    []pool.Critic     `json:"-"`
 
+	// RawBalancer picks which recipe new servers are opened with. If unset, the best rated recipe is used
+	RawBalancer     json.RawMessage      `json:"balancer,omitempty" caddy:"namespace=pggat.handlers.pool.balancers inline_key=balancer"`
+	BalancerFactory pool.BalancerFactory `json:"-"`
+
 	Logger *zap.Logger `json:"-"`
 }
 
@@ -40,17 +80,22 @@ func (T Config) Spool() spool.Config {
 	}
 
 	return spool.Config{
-		PoolerFactory:        new(rob.Factory),
-		UsePS:                true,
-		UseEQP:               true,
-		ResetQuery:           T.ServerResetQuery,
-		ResetQueryTimeout:    resetQueryTimeout,
-		AcquireTimeout:       time.Duration(T.ClientAcquireTimeout),
-		IdleTimeout:          time.Duration(T.ServerIdleTimeout),
-		ReconnectInitialTime: time.Duration(T.ServerReconnectInitialTime),
-		ReconnectMaxTime:     time.Duration(T.ServerReconnectMaxTime),
-
-		Critics: T.Critics,
+		PoolerFactory:         new(rob.Factory),
+		UsePS:                 true,
+		UseEQP:                true,
+		MaxPreparedStatements: T.MaxPreparedStatements,
+		ResetQuery:            T.ServerResetQuery,
+		ResetQueryTimeout:     resetQueryTimeout,
+		AcquireTimeout:        time.Duration(T.ClientAcquireTimeout),
+		QueryTimeout:          time.Duration(T.QueryTimeout),
+		MaxWaiters:            T.ClientMaxWaiting,
+		MaxWaitEstimate:       time.Duration(T.ClientMaxWaitEstimate),
+		IdleTimeout:           time.Duration(T.ServerIdleTimeout),
+		ReconnectInitialTime:  time.Duration(T.ServerReconnectInitialTime),
+		ReconnectMaxTime:      time.Duration(T.ServerReconnectMaxTime),
+
+		Critics:         T.Critics,
+		BalancerFactory: T.BalancerFactory,
 
 		Logger: T.Logger,
 	}
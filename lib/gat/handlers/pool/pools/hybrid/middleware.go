@@ -2,43 +2,71 @@ package hybrid
 
 import (
 	"context"
+	"io"
+
 	"gfx.cafe/gfx/pggat/lib/fed"
 	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
 	"gfx.cafe/gfx/pggat/lib/perror"
+	"gfx.cafe/gfx/pggat/lib/util/ring"
 )
 
 type Middleware struct {
 	primary bool
-	buf     Buffer
-	bufEnc  fed.Encoder
-	bufDec  fed.Decoder
+
+	// buf holds the packets read during the transaction, so they can be replayed on the primary
+	buf    Buffer
+	bufEnc fed.Encoder
+	bufDec fed.Decoder
+
+	// next holds packets that were read during a transaction but weren't part of it, like the rest of a pipeline
+	next    Buffer
+	nextDec fed.Decoder
+
+	// messages are the client messages which haven't been answered yet, oldest first
+	messages ring.Ring[fed.Type]
+	// answered is the number of messages which have been answered
+	answered int
+	// skip is the number of messages the replica answered. Their responses are dropped while replaying on the primary.
+	skip int
 }
 
-func NewMiddleware() *Middleware {
+// NewMiddleware creates a Middleware which keeps up to maxBufferSize bytes of each buffer in memory. 0 = unlimited
+func NewMiddleware(maxBufferSize int) *Middleware {
 	m := new(Middleware)
+	m.buf.Max = maxBufferSize
+	m.next.Max = maxBufferSize
 	m.bufEnc.Reset(&m.buf)
 	m.bufDec.Reset(&m.buf)
+	m.nextDec.Reset(&m.next)
 	return m
 }
 
 func (T *Middleware) PreRead(ctx context.Context, typed bool) (fed.Packet, error) {
-	if !T.primary {
-		return nil, nil
+	if T.primary && (T.buf.Buffered() > 0 || T.bufDec.Buffered() > 0) {
+		if err := T.bufDec.Next(typed); err != nil {
+			return nil, err
+		}
+		return fed.PendingPacket{
+			Decoder: &T.bufDec,
+		}, nil
 	}
 
-	if T.buf.Buffered() == 0 && T.bufDec.Buffered() == 0 {
-		return nil, nil
+	if T.next.Buffered() > 0 || T.nextDec.Buffered() > 0 {
+		if err := T.nextDec.Next(typed); err != nil {
+			return nil, err
+		}
+		return fed.PendingPacket{
+			Decoder: &T.nextDec,
+		}, nil
 	}
 
-	if err := T.bufDec.Next(typed); err != nil {
-		return nil, err
-	}
-	return fed.PendingPacket{
-		Decoder: &T.bufDec,
-	}, nil
+	return nil, nil
 }
 
 func (T *Middleware) ReadPacket(ctx context.Context, packet fed.Packet) (fed.Packet, error) {
+	T.messages.PushBack(packet.Type())
+	T.settle()
+
 	if T.primary {
 		return packet, nil
 	}
@@ -61,10 +89,63 @@ func (T *Middleware) ReadPacket(ctx context.Context, packet fed.Packet) (fed.Pac
 	return p, nil
 }
 
-func (T *Middleware) WritePacket(ctx context.Context, packet fed.Packet) (fed.Packet, error) {
-	if T.primary && (T.buf.Buffered() > 0 || T.bufDec.Buffered() > 0) {
-		return nil, nil
+// settle answers the messages at the front which don't get a response
+func (T *Middleware) settle() {
+	for T.messages.Length() > 0 {
+		switch T.messages.Get(0) {
+		case packets.TypeFlush, packets.TypeCopyData, packets.TypeCopyDone, packets.TypeCopyFail:
+			T.messages.PopFront()
+			T.answered++
+		default:
+			return
+		}
+	}
+}
+
+// respond updates the answered messages for a response
+func (T *Middleware) respond(typ fed.Type) {
+	if T.messages.Length() == 0 {
+		return
 	}
+	message := T.messages.Get(0)
+
+	var done bool
+	switch typ {
+	case packets.TypeParseComplete:
+		done = message == packets.TypeParse
+	case packets.TypeBindComplete:
+		done = message == packets.TypeBind
+	case packets.TypeCloseComplete:
+		done = message == packets.TypeClose
+	case packets.TypeRowDescription, packets.TypeNoData:
+		done = message == packets.TypeDescribe
+	case packets.TypeCommandComplete, packets.TypeEmptyQueryResponse, packets.TypePortalSuspended:
+		done = message == packets.TypeExecute
+	case packets.TypeReadyForQuery:
+		done = true
+	case packets.TypeMarkiplierResponse:
+		switch message {
+		case packets.TypeQuery, packets.TypeFunctionCall, packets.TypeSync:
+		default:
+			// the server discards messages until the next Sync
+			for T.messages.Length() > 0 && T.messages.Get(0) != packets.TypeSync {
+				T.messages.PopFront()
+				T.answered++
+			}
+			return
+		}
+	}
+
+	if done {
+		T.messages.PopFront()
+		T.answered++
+		T.settle()
+	}
+}
+
+func (T *Middleware) WritePacket(ctx context.Context, packet fed.Packet) (fed.Packet, error) {
+	// drop responses the replica already sent
+	skip := T.primary && T.answered < T.skip
 
 	if packet.Type() == packets.TypeMarkiplierResponse {
 		var p packets.MarkiplierResponse
@@ -78,7 +159,13 @@ func (T *Middleware) WritePacket(ctx context.Context, packet fed.Packet) (fed.Pa
 				}
 			}
 		}
-		return &p, nil
+		packet = &p
+	}
+
+	T.respond(packet.Type())
+
+	if skip {
+		return nil, nil
 	}
 	return packet, nil
 }
@@ -87,17 +174,51 @@ func (T *Middleware) PostWrite(ctx context.Context) (fed.Packet, error) {
 	return nil, nil
 }
 
+// Reset starts a new transaction. Packets which were read but not replayed are kept for the next one.
 func (T *Middleware) Reset() {
+	leftover := Buffer{
+		Max: T.next.Max,
+	}
+	if T.primary {
+		T.buf.Rewind(&T.bufDec)
+		_, _ = io.Copy(&leftover, &T.buf)
+	}
+	T.next.Rewind(&T.nextDec)
+	_, _ = io.Copy(&leftover, &T.next)
+	T.next.Reset()
+	T.next = leftover
+	T.nextDec.Reset(&T.next)
+
 	T.primary = false
 	T.buf.Reset()
 	T.bufEnc.Reset(&T.buf)
 	T.bufDec.Reset(&T.buf)
+
+	T.messages.Clear()
+	T.answered = 0
+	T.skip = 0
 }
 
+// Spilled returns whether the transaction outgrew memory and was buffered to a temp file
+func (T *Middleware) Spilled() bool {
+	return T.buf.Spilled()
+}
+
+// Close removes the temp files
+func (T *Middleware) Close() {
+	T.buf.Reset()
+	T.next.Reset()
+}
+
+// Primary replays the transaction on the primary
 func (T *Middleware) Primary() {
 	T.primary = true
 	T.buf.ResetRead()
 	T.bufDec.Reset(&T.buf)
+
+	T.messages.Clear()
+	T.skip = T.answered
+	T.answered = 0
 }
 
 var _ fed.Middleware = (*Middleware)(nil)
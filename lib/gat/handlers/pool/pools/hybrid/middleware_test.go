@@ -0,0 +1,130 @@
+package hybrid
+
+import (
+	"context"
+	"testing"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+	"gfx.cafe/gfx/pggat/lib/perror"
+)
+
+func read(t *testing.T, m *Middleware, packet fed.Packet) fed.Type {
+	packet, err := m.ReadPacket(context.Background(), packet)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// consume the packet like a server conn would
+	var b Buffer
+	var enc fed.Encoder
+	enc.Reset(&b)
+	if err = enc.Next(packet.Type(), packet.Length()); err != nil {
+		t.Fatal(err)
+	}
+	if err = packet.WriteTo(&enc); err != nil {
+		t.Fatal(err)
+	}
+	return packet.Type()
+}
+
+func write(t *testing.T, m *Middleware, responses ...fed.Packet) string {
+	var forwarded []byte
+	for _, response := range responses {
+		packet, err := m.WritePacket(context.Background(), response)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if packet != nil {
+			forwarded = append(forwarded, byte(packet.Type()))
+		}
+	}
+	return string(forwarded)
+}
+
+func replay(t *testing.T, m *Middleware) string {
+	var replayed []byte
+	for {
+		packet, err := m.PreRead(context.Background(), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if packet == nil {
+			return string(replayed)
+		}
+		replayed = append(replayed, byte(read(t, m, packet)))
+	}
+}
+
+func TestMiddlewarePipeline(t *testing.T) {
+	t.Run("memory", func(t *testing.T) {
+		m := NewMiddleware(0)
+		testMiddlewarePipeline(t, m)
+		if m.Spilled() {
+			t.Error("expected buffer to stay in memory")
+		}
+	})
+	t.Run("spilled", func(t *testing.T) {
+		m := NewMiddleware(16)
+		defer m.Close()
+		testMiddlewarePipeline(t, m)
+	})
+}
+
+func testMiddlewarePipeline(t *testing.T, m *Middleware) {
+
+	commandComplete := packets.CommandComplete("SELECT 1")
+	readyForQuery := packets.ReadyForQuery('I')
+
+	// three batches are pipelined to the replica, the second fails on Execute
+	for _, packet := range []fed.Packet{
+		&packets.Parse{Query: "select 1"}, &packets.Bind{}, &packets.Execute{}, &packets.Sync{},
+		&packets.Parse{Query: "insert"}, &packets.Bind{}, &packets.Execute{}, &packets.Sync{},
+		&packets.Parse{Query: "select 2"}, &packets.Sync{},
+	} {
+		read(t, m, packet)
+	}
+	if m.buf.Max != 0 && !m.Spilled() {
+		t.Error("expected buffer to spill")
+	}
+
+	if forwarded := write(
+		t,
+		m,
+		&packets.ParseComplete{}, &packets.BindComplete{}, &commandComplete, &readyForQuery,
+		&packets.ParseComplete{}, &packets.BindComplete{},
+	); forwarded != "12CZ12" {
+		t.Errorf("expected replica responses to be forwarded but got %q", forwarded)
+	}
+	_, err := m.WritePacket(
+		context.Background(),
+		perror.ToPacket(perror.New(perror.ERROR, perror.ReadOnlySqlTransaction, "read only")),
+	)
+	if err != (ErrReadOnly{}) {
+		t.Fatalf("expected ErrReadOnly but got %v", err)
+	}
+
+	// the primary replays the first two batches, and only the responses the replica didn't send are forwarded
+	m.Primary()
+	for i := 0; i < 8; i++ {
+		packet, err := m.PreRead(context.Background(), true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		read(t, m, packet)
+	}
+	if forwarded := write(
+		t,
+		m,
+		&packets.ParseComplete{}, &packets.BindComplete{}, &commandComplete, &readyForQuery,
+		&packets.ParseComplete{}, &packets.BindComplete{}, &commandComplete, &readyForQuery,
+	); forwarded != "CZ" {
+		t.Errorf("expected the rest of the failed batch to be forwarded but got %q", forwarded)
+	}
+
+	// the last batch is kept for the next transaction
+	m.Reset()
+	if replayed := replay(t, m); replayed != "PS" {
+		t.Errorf("expected the last batch to be kept but got %q", replayed)
+	}
+}
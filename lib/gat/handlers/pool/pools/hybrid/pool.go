@@ -20,6 +20,7 @@ import (
 	"gfx.cafe/gfx/pggat/lib/fed/middlewares/unterminate"
 	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool/notify"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool/spool"
 	"gfx.cafe/gfx/pggat/lib/gat/metrics"
 	"gfx.cafe/gfx/pggat/lib/instrumentation/prom"
@@ -31,10 +32,17 @@ type Pool struct {
 
 	primary spool.Pool
 	replica spool.Pool
+	notify  *notify.Multiplexer
+
+	// recipes tracks which spool each recipe is in, so they can be moved when servers change role
+	recipes   map[recipeKey]*recipe
+	recipesMu sync.Mutex
 
 	clients map[fed.BackendKey]*Client
 	mu      sync.RWMutex
 
+	closed chan struct{}
+
 	tracer trace.Tracer
 }
 
@@ -46,29 +54,43 @@ func NewPool(ctx context.Context, config Config) *Pool {
 
 		primary: spool.MakePool(c),
 		replica: spool.MakePool(c),
+		closed:  make(chan struct{}),
 		tracer: otel.Tracer("hybrid-pool", trace.WithInstrumentationAttributes(
 			attribute.String("component", "gfx.cafe/gfx/pggat/lib/gat/handlers/pool/pools/hybrid/pool.go"),
 		)),
 	}
+	if config.MultiplexNotifications {
+		p.notify = notify.NewMultiplexer(config.Logger)
+	}
 	go p.primary.ScaleLoop(ctx)
 	go p.replica.ScaleLoop(ctx)
+	for _, critic := range config.Critics {
+		if rc, ok := critic.(pool.RoleCritic); ok {
+			go p.roleLoop(ctx, rc)
+			break
+		}
+	}
 	return p
 }
 
 func (T *Pool) AddReplicaRecipe(ctx context.Context, name string, recipe *pool.Recipe) {
-	T.replica.AddRecipe(ctx, name, recipe)
+	T.addRecipe(ctx, recipeKey{Name: name, Replica: true}, recipe)
 }
 
 func (T *Pool) RemoveReplicaRecipe(ctx context.Context, name string) {
-	T.replica.RemoveRecipe(ctx, name)
+	T.removeRecipe(ctx, recipeKey{Name: name, Replica: true})
 }
 
 func (T *Pool) AddRecipe(ctx context.Context, name string, recipe *pool.Recipe) {
-	T.primary.AddRecipe(ctx, name, recipe)
+	T.addRecipe(ctx, recipeKey{Name: name}, recipe)
 }
 
 func (T *Pool) RemoveRecipe(ctx context.Context, name string) {
-	T.primary.RemoveRecipe(ctx, name)
+	T.removeRecipe(ctx, recipeKey{Name: name})
+}
+
+func (T *Pool) PrimaryRecipe() (*pool.Recipe, bool) {
+	return T.primary.Recipe()
 }
 
 func (T *Pool) Pair(ctx context.Context, client *Client, server *spool.Server) (err, serverErr error) {
@@ -125,6 +147,23 @@ func (T *Pool) PairPrimary(ctx context.Context, client *Client, psc *ps.Client,
 	return nil
 }
 
+func (T *Pool) acquire(sp *spool.Pool, client *Client, labels prom.OperationHybridLabels) (*spool.Server, error) {
+	if !client.admitted {
+		if sp.Saturated() {
+			prom.OperationHybrid.Rejected(labels).Inc()
+			return nil, pool.ErrPoolSaturated
+		}
+		client.admitted = true
+	}
+
+	// every acquiring client counts itself, so the gauge is current even while nobody else acquires
+	waiters := prom.OperationHybrid.Waiters(labels)
+	waiters.Inc()
+	defer waiters.Dec()
+
+	return sp.Acquire(client.ID)
+}
+
 func (T *Pool) addClient(client *Client) {
 	T.mu.Lock()
 	defer T.mu.Unlock()
@@ -143,7 +182,8 @@ func (T *Pool) removeClient(client *Client) {
 }
 
 func (T *Pool) serveRW(ctx context.Context, l prom.PoolHybridLabels, conn *fed.Conn) error {
-	m := NewMiddleware()
+	m := NewMiddleware(T.config.MaxBufferSize)
+	defer m.Close()
 
 	eqpa := eqp.NewClient()
 	eqpi := eqp.NewClient()
@@ -163,11 +203,17 @@ func (T *Pool) serveRW(ctx context.Context, l prom.PoolHybridLabels, conn *fed.C
 	T.addClient(client)
 	defer T.removeClient(client)
 
-	T.primary.AddClient(client.ID)
+	T.primary.AddClient(client.ID, conn)
 	defer T.primary.RemoveClient(client.ID)
-	T.replica.AddClient(client.ID)
+	T.replica.AddClient(client.ID, conn)
 	defer T.replica.RemoveClient(client.ID)
 
+	var subscriber *notify.Subscriber
+	if T.notify != nil {
+		subscriber = notify.NewSubscriber(conn)
+		defer T.notify.UnlistenAll(subscriber)
+	}
+
 	var err, serverErr error
 
 	var primary, replica *spool.Server
@@ -194,9 +240,9 @@ func (T *Pool) serveRW(ctx context.Context, l prom.PoolHybridLabels, conn *fed.C
 		client.SetState(metrics.ConnStateAwaitingServer, nil, false)
 
 		if !T.replica.Empty() {
-			replica = T.replica.Acquire(client.ID)
-			if replica == nil {
-				return pool.ErrFailedToAcquirePeer
+			replica, err = T.acquire(&T.replica, client, l.ToOperation("replica"))
+			if err != nil {
+				return err
 			}
 
 			err, serverErr = T.Pair(ctx, client, replica)
@@ -209,9 +255,9 @@ func (T *Pool) serveRW(ctx context.Context, l prom.PoolHybridLabels, conn *fed.C
 		} else {
 			// pair with primary instead
 
-			primary = T.primary.Acquire(client.ID)
-			if primary == nil {
-				return pool.ErrFailedToAcquirePeer
+			primary, err = T.acquire(&T.primary, client, l.ToOperation("primary"))
+			if err != nil {
+				return err
 			}
 
 			err, serverErr = T.Pair(ctx, client, primary)
@@ -242,20 +288,40 @@ func (T *Pool) serveRW(ctx context.Context, l prom.PoolHybridLabels, conn *fed.C
 		}
 		client.SetState(metrics.ConnStateIdle, nil, false)
 
+		if subscriber != nil {
+			if err = subscriber.Idle(ctx); err != nil {
+				return err
+			}
+		}
+
 		var packet fed.Packet
-		packet, err = conn.ReadPacket(ctx, true)
+		packet, err = pool.ReadIdlePacket(ctx, conn, time.Duration(T.config.ClientIdleTimeout))
+		if subscriber != nil {
+			subscriber.Busy()
+		}
 		if err != nil {
 			return err
 		}
 
+		if subscriber != nil {
+			packet, err = T.notify.HandlePacket(ctx, subscriber, packet)
+			if err != nil {
+				return err
+			}
+			if packet == nil {
+				m.Reset()
+				continue
+			}
+		}
+
 		client.SetState(metrics.ConnStateAwaitingServer, nil, false)
 
 		// try replica first (if it isn't empty)
 		if !T.replica.Empty() {
 			start := time.Now()
-			replica = T.replica.Acquire(client.ID)
-			if replica == nil {
-				return pool.ErrFailedToAcquirePeer
+			replica, err = T.acquire(&T.replica, client, l.ToOperation("replica"))
+			if err != nil {
+				return err
 			}
 
 			err, serverErr = T.Pair(ctx, client, replica)
@@ -267,7 +333,7 @@ func (T *Pool) serveRW(ctx context.Context, l prom.PoolHybridLabels, conn *fed.C
 			if err == nil && serverErr == nil {
 				prom.OperationHybrid.Acquire(l.ToOperation("replica")).Observe(float64(dur) / float64(time.Millisecond))
 				start := time.Now()
-				err, serverErr = bouncers.Bounce(ctx, conn, replica.Conn, packet)
+				err, serverErr = bouncers.BounceIdleTimeout(ctx, conn, replica.Conn, packet, time.Duration(T.config.IdleTransactionTimeout))
 				if serverErr == nil {
 					dur := time.Since(start)
 					prom.OperationHybrid.Execution(l.ToOperation("replica")).Observe(float64(dur) / float64(time.Millisecond))
@@ -279,6 +345,10 @@ func (T *Pool) serveRW(ctx context.Context, l prom.PoolHybridLabels, conn *fed.C
 				replica.TransactionComplete()
 			}
 
+			if m.Spilled() {
+				prom.OperationHybrid.Spills(l.ToOperation("replica")).Inc()
+			}
+
 			// fallback to primary
 			if err == (ErrReadOnly{}) {
 				m.Primary()
@@ -295,9 +365,9 @@ func (T *Pool) serveRW(ctx context.Context, l prom.PoolHybridLabels, conn *fed.C
 
 				// acquire primary
 				start := time.Now()
-				primary = T.primary.Acquire(client.ID)
-				if primary == nil {
-					return pool.ErrFailedToAcquirePeer
+				primary, err = T.acquire(&T.primary, client, l.ToOperation("primary"))
+				if err != nil {
+					return err
 				}
 
 				serverErr = T.PairPrimary(ctx, client, psi, eqpi, primary)
@@ -306,7 +376,7 @@ func (T *Pool) serveRW(ctx context.Context, l prom.PoolHybridLabels, conn *fed.C
 				if serverErr == nil {
 					prom.OperationHybrid.Acquire(l.ToOperation("primary")).Observe(float64(dur) / float64(time.Millisecond))
 					start := time.Now()
-					err, serverErr = bouncers.Bounce(ctx, conn, primary.Conn, packet)
+					err, serverErr = bouncers.BounceIdleTimeout(ctx, conn, primary.Conn, packet, time.Duration(T.config.IdleTransactionTimeout))
 					dur := time.Since(start)
 					prom.OperationHybrid.Execution(l.ToOperation("primary")).Observe(float64(dur) / float64(time.Millisecond))
 				}
@@ -331,9 +401,9 @@ func (T *Pool) serveRW(ctx context.Context, l prom.PoolHybridLabels, conn *fed.C
 
 			start := time.Now()
 			// acquire primary
-			primary = T.primary.Acquire(client.ID)
-			if primary == nil {
-				return pool.ErrFailedToAcquirePeer
+			primary, err = T.acquire(&T.primary, client, l.ToOperation("primary"))
+			if err != nil {
+				return err
 			}
 
 			err, serverErr = T.Pair(ctx, client, primary)
@@ -343,7 +413,7 @@ func (T *Pool) serveRW(ctx context.Context, l prom.PoolHybridLabels, conn *fed.C
 			if err == nil && serverErr == nil {
 				prom.OperationHybrid.Acquire(l.ToOperation("primary")).Observe(float64(dur) / float64(time.Millisecond))
 				start := time.Now()
-				err, serverErr = bouncers.Bounce(ctx, conn, primary.Conn, packet)
+				err, serverErr = bouncers.BounceIdleTimeout(ctx, conn, primary.Conn, packet, time.Duration(T.config.IdleTransactionTimeout))
 				if serverErr == nil {
 					dur := time.Since(start)
 					prom.OperationHybrid.Execution(l.ToOperation("primary")).Observe(float64(dur) / float64(time.Millisecond))
@@ -366,10 +436,13 @@ func (T *Pool) serveRW(ctx context.Context, l prom.PoolHybridLabels, conn *fed.C
 
 func (T *Pool) serveOnly(ctx context.Context, l prom.PoolHybridLabels, conn *fed.Conn, write bool) error {
 	var sp *spool.Pool
+	var opL prom.OperationHybridLabels
 	if write {
 		sp = &T.primary
+		opL = l.ToOperation("primary")
 	} else {
 		sp = &T.replica
+		opL = l.ToOperation("replica")
 	}
 
 	conn.Middleware = append(
@@ -384,9 +457,15 @@ func (T *Pool) serveOnly(ctx context.Context, l prom.PoolHybridLabels, conn *fed
 	T.addClient(client)
 	defer T.removeClient(client)
 
-	sp.AddClient(client.ID)
+	sp.AddClient(client.ID, conn)
 	defer sp.RemoveClient(client.ID)
 
+	var subscriber *notify.Subscriber
+	if T.notify != nil {
+		subscriber = notify.NewSubscriber(conn)
+		defer T.notify.UnlistenAll(subscriber)
+	}
+
 	var err, serverErr error
 
 	var server *spool.Server
@@ -404,9 +483,9 @@ func (T *Pool) serveOnly(ctx context.Context, l prom.PoolHybridLabels, conn *fed
 	if !conn.Ready {
 		client.SetState(metrics.ConnStateAwaitingServer, nil, true)
 
-		server = sp.Acquire(client.ID)
-		if server == nil {
-			return pool.ErrFailedToAcquirePeer
+		server, err = T.acquire(sp, client, opL)
+		if err != nil {
+			return err
 		}
 
 		err, serverErr = T.Pair(ctx, client, server)
@@ -425,13 +504,6 @@ func (T *Pool) serveOnly(ctx context.Context, l prom.PoolHybridLabels, conn *fed
 		conn.Ready = true
 	}
 
-	var opL prom.OperationHybridLabels
-	if write {
-		opL = l.ToOperation("primary")
-	} else {
-		opL = l.ToOperation("replica")
-	}
-
 	for {
 		if server != nil {
 			sp.Release(ctx, server)
@@ -439,25 +511,44 @@ func (T *Pool) serveOnly(ctx context.Context, l prom.PoolHybridLabels, conn *fed
 		}
 		client.SetState(metrics.ConnStateIdle, nil, true)
 
+		if subscriber != nil {
+			if err = subscriber.Idle(ctx); err != nil {
+				return err
+			}
+		}
+
 		var packet fed.Packet
-		packet, err = conn.ReadPacket(ctx, true)
+		packet, err = pool.ReadIdlePacket(ctx, conn, time.Duration(T.config.ClientIdleTimeout))
+		if subscriber != nil {
+			subscriber.Busy()
+		}
 		if err != nil {
 			return err
 		}
 
+		if subscriber != nil {
+			packet, err = T.notify.HandlePacket(ctx, subscriber, packet)
+			if err != nil {
+				return err
+			}
+			if packet == nil {
+				continue
+			}
+		}
+
 		client.SetState(metrics.ConnStateAwaitingServer, nil, true)
 
 		start := time.Now()
-		server = sp.Acquire(client.ID)
-		if server == nil {
-			return pool.ErrFailedToAcquirePeer
+		server, err = T.acquire(sp, client, opL)
+		if err != nil {
+			return err
 		}
 		err, serverErr = T.Pair(ctx, client, server)
 		dur := time.Since(start)
 		if err == nil && serverErr == nil {
 			prom.OperationHybrid.Acquire(opL).Observe(float64(dur) / float64(time.Millisecond))
 			start := time.Now()
-			err, serverErr = bouncers.Bounce(ctx, conn, server.Conn, packet)
+			err, serverErr = bouncers.BounceIdleTimeout(ctx, conn, server.Conn, packet, time.Duration(T.config.IdleTransactionTimeout))
 			if serverErr == nil {
 				dur := time.Since(start)
 				prom.OperationHybrid.Execution(opL).Observe(float64(dur) / float64(time.Millisecond))
@@ -480,6 +571,10 @@ func (T *Pool) Serve(ctx context.Context, conn *fed.Conn) error {
 	ctx, span := T.tracer.Start(ctx, "Serve")
 	defer span.End()
 
+	if conn.Replication != "" {
+		return pool.ServeReplication(ctx, conn, T)
+	}
+
 	// returning 2 errors is questionable
 	err := T.serve(ctx, conn)
 	if err != nil {
@@ -570,8 +665,13 @@ func (T *Pool) Close(ctx context.Context) {
 	ctx, span := T.tracer.Start(ctx, "Close")
 	defer span.End()
 
+	close(T.closed)
+
 	T.primary.Close(ctx)
 	T.replica.Close(ctx)
+	if T.notify != nil {
+		T.notify.Close()
+	}
 }
 
 var (
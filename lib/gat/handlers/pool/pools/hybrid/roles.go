@@ -0,0 +1,216 @@
+package hybrid
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
+)
+
+// roleCheckRetry is how long to wait between role checks when no critic returned a validity
+const roleCheckRetry = 5 * time.Second
+
+// errRecipeFull is returned when a role check can't dial because the recipe is at its max connections
+var errRecipeFull = errors.New("recipe is at max connections")
+
+type recipeKey struct {
+	Name string
+	// Replica is whether the recipe was added as a replica
+	Replica bool
+}
+
+type recipe struct {
+	recipe *pool.Recipe
+	// replica is whether the recipe is currently in the replica spool
+	replica bool
+}
+
+func (T *Pool) place(ctx context.Context, name string, r *pool.Recipe, replica bool) {
+	if replica {
+		T.replica.AddRecipe(ctx, name, r)
+		return
+	}
+
+	T.primary.AddRecipe(ctx, name, r)
+	if T.notify != nil {
+		T.notify.AddRecipe(name, r)
+	}
+}
+
+func (T *Pool) unplace(ctx context.Context, name string, replica bool) {
+	if replica {
+		T.replica.RemoveRecipe(ctx, name)
+		return
+	}
+
+	T.primary.RemoveRecipe(ctx, name)
+	if T.notify != nil {
+		T.notify.RemoveRecipe(name)
+	}
+}
+
+// occupant returns the recipe which is currently in the spool under name
+func (T *Pool) occupant(name string, replica bool) (recipeKey, bool) {
+	for key, r := range T.recipes {
+		if key.Name == name && r.replica == replica {
+			return key, true
+		}
+	}
+	return recipeKey{}, false
+}
+
+func (T *Pool) addRecipe(ctx context.Context, key recipeKey, r *pool.Recipe) {
+	T.recipesMu.Lock()
+	defer T.recipesMu.Unlock()
+
+	if T.recipes == nil {
+		T.recipes = make(map[recipeKey]*recipe)
+	}
+
+	// the recipe was moved by a role change, remove it from the other spool
+	if prev, ok := T.recipes[key]; ok && prev.replica != key.Replica {
+		T.unplace(ctx, key.Name, prev.replica)
+	}
+
+	// a recipe from the other spool was moved into our place, send it back
+	if other, ok := T.occupant(key.Name, key.Replica); ok && other != key {
+		o := T.recipes[other]
+		o.replica = other.Replica
+		T.place(ctx, other.Name, o.recipe, o.replica)
+	}
+
+	T.recipes[key] = &recipe{
+		recipe:  r,
+		replica: key.Replica,
+	}
+	T.place(ctx, key.Name, r, key.Replica)
+}
+
+func (T *Pool) removeRecipe(ctx context.Context, key recipeKey) {
+	T.recipesMu.Lock()
+	defer T.recipesMu.Unlock()
+
+	r, ok := T.recipes[key]
+	if !ok {
+		return
+	}
+	delete(T.recipes, key)
+
+	T.unplace(ctx, key.Name, r.replica)
+}
+
+// roleLoop moves recipes between the primary and replica spools when the servers they dial change role, so writes
+// follow a failover before discovery notices it
+func (T *Pool) roleLoop(ctx context.Context, critic pool.RoleCritic) {
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	interval := roleCheckRetry
+	for {
+		select {
+		case <-T.closed:
+			return
+		case <-timer.C:
+			interval = T.checkRoles(ctx, critic, interval)
+			timer.Reset(interval)
+		}
+	}
+}
+
+func (T *Pool) checkRole(ctx context.Context, critic pool.RoleCritic, r *pool.Recipe, timeout time.Duration) (bool, time.Duration, error) {
+	// the check connection counts towards the recipe's max connections like any other
+	if !r.Allocate() {
+		return false, 0, errRecipeFull
+	}
+	defer r.Free()
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	conn, err := r.DialContext(ctx)
+	if err != nil {
+		return false, 0, err
+	}
+	defer func() {
+		_ = conn.Close(ctx)
+	}()
+
+	return critic.Primary(ctx, conn)
+}
+
+// checkRoles checks the role of every recipe at once and returns how long until they should be checked again. Each
+// check has until timeout, so a slow server can't hold up the rest or the next round
+func (T *Pool) checkRoles(ctx context.Context, critic pool.RoleCritic, timeout time.Duration) time.Duration {
+	recipes := func() map[recipeKey]*pool.Recipe {
+		T.recipesMu.Lock()
+		defer T.recipesMu.Unlock()
+
+		recipes := make(map[recipeKey]*pool.Recipe, len(T.recipes))
+		for key, r := range T.recipes {
+			recipes[key] = r.recipe
+		}
+		return recipes
+	}()
+
+	var next time.Duration
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for key, r := range recipes {
+		wg.Add(1)
+		go func(key recipeKey, r *pool.Recipe) {
+			defer wg.Done()
+
+			primary, validity, err := T.checkRole(ctx, critic, r, timeout)
+
+			mu.Lock()
+			if validity > 0 && (next == 0 || validity < next) {
+				next = validity
+			}
+			mu.Unlock()
+
+			if err != nil {
+				// leave unreachable servers where they are, the spool will deal with them
+				T.config.Logger.Debug("failed to check server role", zap.String("recipe", key.Name), zap.Error(err))
+				return
+			}
+
+			T.setRole(ctx, key, r, !primary)
+		}(key, r)
+	}
+	wg.Wait()
+
+	if next == 0 {
+		next = roleCheckRetry
+	}
+	return next
+}
+
+func (T *Pool) setRole(ctx context.Context, key recipeKey, r *pool.Recipe, replica bool) {
+	T.recipesMu.Lock()
+	defer T.recipesMu.Unlock()
+
+	current, ok := T.recipes[key]
+	if !ok || current.recipe != r || current.replica == replica {
+		// removed, replaced, or already in the right place
+		return
+	}
+
+	if _, ok = T.occupant(key.Name, replica); ok {
+		T.config.Logger.Warn("server changed role but a recipe with the same name is already in its place", zap.String("recipe", key.Name))
+		return
+	}
+
+	T.unplace(ctx, key.Name, current.replica)
+	current.replica = replica
+	T.place(ctx, key.Name, r, replica)
+
+	if replica {
+		T.config.Logger.Info("server is in recovery, moving it to replicas", zap.String("recipe", key.Name))
+	} else {
+		T.config.Logger.Info("server was promoted, moving it to primaries", zap.String("recipe", key.Name))
+	}
+}
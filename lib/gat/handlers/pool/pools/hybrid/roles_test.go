@@ -0,0 +1,159 @@
+package hybrid
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool/critics/role"
+)
+
+func expectPlacement(t *testing.T, p *Pool, key recipeKey, replica bool) {
+	t.Helper()
+
+	r, ok := p.recipes[key]
+	if !ok {
+		t.Fatalf("expected recipe %v to exist", key)
+	}
+	if r.replica != replica {
+		t.Fatalf("expected recipe %v to have replica=%v", key, replica)
+	}
+}
+
+func TestPoolRoles(t *testing.T) {
+	ctx := context.Background()
+
+	p := NewPool(ctx, Config{
+		Logger: zap.NewNop(),
+	})
+	defer p.Close(ctx)
+
+	primary := new(pool.Recipe)
+	replica := new(pool.Recipe)
+
+	p.AddRecipe(ctx, "primary", primary)
+	p.AddReplicaRecipe(ctx, "a", replica)
+
+	primaryKey := recipeKey{Name: "primary"}
+	replicaKey := recipeKey{Name: "a", Replica: true}
+
+	// failover
+	p.setRole(ctx, primaryKey, primary, true)
+	p.setRole(ctx, replicaKey, replica, false)
+	expectPlacement(t, p, primaryKey, true)
+	expectPlacement(t, p, replicaKey, false)
+	if p.primary.Empty() || p.replica.Empty() {
+		t.Fatal("expected both spools to have a recipe")
+	}
+
+	// stale results are ignored
+	p.setRole(ctx, replicaKey, new(pool.Recipe), true)
+	expectPlacement(t, p, replicaKey, false)
+
+	// discovery removes the old primary from wherever it is now
+	p.RemoveRecipe(ctx, "primary")
+	if !p.replica.Empty() {
+		t.Fatal("expected the replica spool to be empty")
+	}
+
+	// a replica with the same name as the promoted one takes its place back
+	p.AddRecipe(ctx, "a", primary)
+	expectPlacement(t, p, recipeKey{Name: "a"}, false)
+	expectPlacement(t, p, replicaKey, true)
+
+	// moving is skipped if the name is taken
+	p.setRole(ctx, replicaKey, replica, false)
+	expectPlacement(t, p, replicaKey, true)
+
+	p.RemoveReplicaRecipe(ctx, "a")
+	p.RemoveRecipe(ctx, "a")
+	if !p.primary.Empty() || !p.replica.Empty() {
+		t.Fatal("expected both spools to be empty")
+	}
+}
+
+func TestPoolCheckRolesTimeout(t *testing.T) {
+	ctx := context.Background()
+
+	// a server which accepts connections but never responds
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = l.Close()
+	}()
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			defer func() {
+				_ = c.Close()
+			}()
+		}
+	}()
+
+	p := NewPool(ctx, Config{
+		Logger: zap.NewNop(),
+	})
+	defer p.Close(ctx)
+
+	for i := 0; i < 5; i++ {
+		r := &pool.Recipe{
+			Dialer: pool.Dialer{
+				Address: l.Addr().String(),
+			},
+		}
+		p.AddReplicaRecipe(ctx, strconv.Itoa(i), r)
+	}
+
+	// the checks run at once, so they take about one timeout instead of one each
+	timeout := 200 * time.Millisecond
+	start := time.Now()
+	if next := p.checkRoles(ctx, role.NewCritic(), timeout); next != roleCheckRetry {
+		t.Fatalf("expected to retry after %v but got %v", roleCheckRetry, next)
+	}
+	if elapsed := time.Since(start); elapsed >= 5*timeout {
+		t.Fatalf("expected the checks to time out together but they took %v", elapsed)
+	}
+}
+
+func TestPoolRolesMaxConnections(t *testing.T) {
+	ctx := context.Background()
+
+	p := NewPool(ctx, Config{
+		Logger: zap.NewNop(),
+	})
+	defer p.Close(ctx)
+
+	r := &pool.Recipe{
+		Dialer: pool.Dialer{
+			// nothing listens here, the check must not get as far as dialing
+			Address: "127.0.0.1:1",
+		},
+		MaxConnections: 1,
+	}
+	if !r.Allocate() {
+		t.Fatal("expected to allocate the only connection")
+	}
+
+	if _, _, err := p.checkRole(ctx, role.NewCritic(), r, time.Second); err != errRecipeFull {
+		t.Fatalf("expected %v but got %v", errRecipeFull, err)
+	}
+
+	// the check freed its own allocation but not ours
+	if r.Allocate() {
+		t.Fatal("expected the recipe to still be full")
+	}
+	r.Free()
+	if !r.Allocate() {
+		t.Fatal("expected the check to free what it allocated")
+	}
+}
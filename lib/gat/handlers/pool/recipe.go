@@ -7,7 +7,11 @@ import (
 type Recipe struct {
 	Dialer
 
+	// Priority orders recipes, lower is preferred
 	Priority int `json:"priority,omitempty"`
+	// Weight is the share of new servers compared to recipes of the same priority, for balancers that support it.
+	// Defaults to 1
+	Weight int `json:"weight,omitempty"`
 
 	MinConnections int `json:"min_connections,omitempty"`
 
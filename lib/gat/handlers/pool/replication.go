@@ -0,0 +1,36 @@
+package pool
+
+import (
+	"context"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+)
+
+// ReplicationServer serves replication connections, which can't be pooled
+type ReplicationServer interface {
+	// ServeReplication proxies conn to its own server dialed with recipe for the whole session
+	ServeReplication(ctx context.Context, conn *fed.Conn, recipe *Recipe) error
+}
+
+type replicationServerKey struct{}
+
+// WithReplicationServer returns a ctx which lets the pool a replication connection is routed to hand it to server
+func WithReplicationServer(ctx context.Context, server ReplicationServer) context.Context {
+	return context.WithValue(ctx, replicationServerKey{}, server)
+}
+
+// ServeReplication hands a replication connection to the ReplicationServer of ctx along with the primary recipe of p.
+// Pools call it from Serve, so replication follows the same routes and credentials as every other connection.
+func ServeReplication(ctx context.Context, conn *fed.Conn, p Pool) error {
+	server, ok := ctx.Value(replicationServerKey{}).(ReplicationServer)
+	if !ok {
+		return ErrReplicationNotPooled
+	}
+
+	recipe, ok := p.PrimaryRecipe()
+	if !ok {
+		return ErrNoPrimary
+	}
+
+	return server.ServeReplication(ctx, conn, recipe)
+}
@@ -0,0 +1,52 @@
+package pool
+
+import (
+	"context"
+	"testing"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+)
+
+type testPrimaryPool struct {
+	Pool
+
+	recipe *Recipe
+}
+
+func (T testPrimaryPool) PrimaryRecipe() (*Recipe, bool) {
+	return T.recipe, T.recipe != nil
+}
+
+type testReplicationServer struct {
+	recipe *Recipe
+}
+
+func (T *testReplicationServer) ServeReplication(_ context.Context, _ *fed.Conn, recipe *Recipe) error {
+	T.recipe = recipe
+	return nil
+}
+
+func TestServeReplication(t *testing.T) {
+	ctx := context.Background()
+	conn := fed.NewConn(nil)
+	primary := new(Recipe)
+
+	// without a replication handler in front, the pool can't serve it
+	if err := ServeReplication(ctx, conn, testPrimaryPool{recipe: primary}); err == nil || err.Error() != ErrReplicationNotPooled.Error() {
+		t.Fatalf("expected %v but got %v", ErrReplicationNotPooled, err)
+	}
+
+	var server testReplicationServer
+	ctx = WithReplicationServer(ctx, &server)
+
+	if err := ServeReplication(ctx, conn, testPrimaryPool{}); err == nil || err.Error() != ErrNoPrimary.Error() {
+		t.Fatalf("expected %v but got %v", ErrNoPrimary, err)
+	}
+
+	if err := ServeReplication(ctx, conn, testPrimaryPool{recipe: primary}); err != nil {
+		t.Fatal(err)
+	}
+	if server.recipe != primary {
+		t.Fatal("expected the replication server to get the primary recipe")
+	}
+}
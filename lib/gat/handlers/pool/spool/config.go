@@ -15,6 +15,9 @@ type Config struct {
 	UsePS bool
 	// UseEQP controls whether to add the eqp middleware to servers
 	UseEQP bool
+	// MaxPreparedStatements is the max number of shared prepared statements per server. 0 = don't share prepared
+	// statements between clients. Only used with UseEQP
+	MaxPreparedStatements int
 
 	UseOtelTracing   bool
 	UsePacketTracing bool
@@ -24,12 +27,24 @@ type Config struct {
 
 	AcquireTimeout time.Duration
 
+	// MaxWaiters is the max number of clients that may wait for a server. Clients past this are rejected immediately.
+	// 0 = unlimited
+	MaxWaiters int
+	// MaxWaitEstimate rejects clients immediately if their estimated wait for a server is longer.
+	// 0 = disabled
+	MaxWaitEstimate time.Duration
+
+	// QueryTimeout is how long a query may run on a server before it is canceled. 0 = no timeout
+	QueryTimeout time.Duration
+
 	IdleTimeout time.Duration
 
 	ReconnectInitialTime time.Duration
 	ReconnectMaxTime     time.Duration
 
 	Critics []pool.Critic
+	// BalancerFactory creates the balancer which picks which recipe to use when scaling up
+	BalancerFactory pool.BalancerFactory
 
 	Logger *zap.Logger
 }
@@ -40,7 +40,9 @@ func NewChef(config Config) *Chef {
 func (T *Chef) Learn(ctx context.Context, name string, recipe *pool.Recipe) (removed []*fed.Conn, added []*fed.Conn) {
 	n := recipe.AllocateInitial()
 	added = make([]*fed.Conn, 0, n)
+	var latency time.Duration
 	for i := 0; i < n; i++ {
+		start := time.Now()
 		conn, err := recipe.Dial()
 		if err != nil {
 			// free remaining, failed to dial initial :(
@@ -50,6 +52,7 @@ func (T *Chef) Learn(ctx context.Context, name string, recipe *pool.Recipe) (rem
 			}
 			break
 		}
+		latency = time.Since(start)
 
 		added = append(added, conn)
 	}
@@ -59,7 +62,10 @@ func (T *Chef) Learn(ctx context.Context, name string, recipe *pool.Recipe) (rem
 
 	removed = T.forget(ctx, name)
 
-	r := NewRecipe(recipe, added)
+	r := NewRecipe(name, recipe, added)
+	if latency != 0 {
+		r.observe(latency)
+	}
 
 	if T.byName == nil {
 		T.byName = make(map[string]*Recipe)
@@ -94,6 +100,7 @@ func (T *Chef) forget(ctx context.Context, name string) []*fed.Conn {
 
 		delete(T.byConn, conn)
 		delete(r.conns, conn)
+		delete(r.active, conn)
 	}
 
 	T.order = slices.Remove(T.order, r)
@@ -117,11 +124,36 @@ func (T *Chef) Empty() bool {
 	return len(T.byName) == 0
 }
 
+// Recipe returns the recipe with the lowest priority, ties are broken by name
+func (T *Chef) Recipe() (*pool.Recipe, bool) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	var best *Recipe
+	for _, r := range T.order {
+		if best == nil || r.recipe.Priority < best.recipe.Priority ||
+			(r.recipe.Priority == best.recipe.Priority && r.name < best.name) {
+			best = r
+		}
+	}
+	if best == nil {
+		return nil, false
+	}
+	return best.recipe, true
+}
+
 func (T *Chef) cook(r *Recipe) (*fed.Conn, error) {
-	T.mu.Unlock()
-	defer T.mu.Lock()
+	start := time.Now()
+	conn, err := func() (*fed.Conn, error) {
+		T.mu.Unlock()
+		defer T.mu.Lock()
 
-	return r.recipe.Dial()
+		return r.recipe.Dial()
+	}()
+	if err == nil {
+		r.observe(time.Since(start))
+	}
+	return conn, err
 }
 
 func (T *Chef) score(ctx context.Context, r *Recipe) error {
@@ -146,6 +178,7 @@ func (T *Chef) score(ctx context.Context, r *Recipe) error {
 	ratings := make([]Rating, len(T.config.Critics))
 
 	total := 0
+	var latency time.Duration
 
 	for i, critic := range T.config.Critics {
 		if now.Before(r.ratings[i].Expiration) {
@@ -160,10 +193,12 @@ func (T *Chef) score(ctx context.Context, r *Recipe) error {
 		T.mu.Unlock()
 		defer T.mu.Lock()
 
+		start := time.Now()
 		conn, err := r.recipe.Dial()
 		if err != nil {
 			return err
 		}
+		latency = time.Since(start)
 		defer func() {
 			_ = conn.Close(ctx)
 		}()
@@ -196,6 +231,9 @@ func (T *Chef) score(ctx context.Context, r *Recipe) error {
 
 	// update total score
 	r.score = total
+	if latency != 0 {
+		r.observe(latency)
+	}
 
 	// update ratings
 	r.ratings = slices.Resize(r.ratings, len(T.config.Critics))
@@ -211,6 +249,21 @@ func (T *Chef) score(ctx context.Context, r *Recipe) error {
 	return nil
 }
 
+// balance orders recipes with the balancer. The balancer sees the critic penalties and has the final say
+func (T *Chef) balance() {
+	candidates := make([]pool.Candidate, 0, len(T.order))
+	for _, r := range T.order {
+		candidates = append(candidates, r.Candidate())
+	}
+
+	T.config.Balancer.Balance(candidates)
+
+	T.order = T.order[:0]
+	for _, candidate := range candidates {
+		T.order = append(T.order, T.byName[candidate.Name])
+	}
+}
+
 // Cook will cook the best recipe
 func (T *Chef) Cook(ctx context.Context) (*fed.Conn, error) {
 	T.mu.Lock()
@@ -224,19 +277,23 @@ func (T *Chef) Cook(ctx context.Context) (*fed.Conn, error) {
 		}
 	}
 
-	sort.Slice(T.order, func(i, j int) bool {
-		a := T.order[i]
-		b := T.order[j]
-		// sort by priority first
-		if a.Rating() < b.Rating() {
-			return true
-		}
-		if a.Rating() > b.Rating() {
-			return false
-		}
-		// then sort by number of conns
-		return len(a.conns) < len(b.conns)
-	})
+	if T.config.Balancer != nil {
+		T.balance()
+	} else {
+		sort.Slice(T.order, func(i, j int) bool {
+			a := T.order[i]
+			b := T.order[j]
+			// sort by priority first
+			if a.Rating() < b.Rating() {
+				return true
+			}
+			if a.Rating() > b.Rating() {
+				return false
+			}
+			// then sort by number of conns
+			return len(a.conns) < len(b.conns)
+		})
+	}
 
 	for i, r := range T.order {
 		if !r.recipe.Allocate() {
@@ -255,6 +312,10 @@ func (T *Chef) Cook(ctx context.Context) (*fed.Conn, error) {
 			}
 			T.byConn[conn] = r
 
+			if T.config.Balancer != nil {
+				T.config.Balancer.Chosen(r.name)
+			}
+
 			return conn, nil
 		}
 
@@ -291,6 +352,7 @@ func (T *Chef) Burn(ctx context.Context, conn *fed.Conn) {
 
 	delete(T.byConn, conn)
 	delete(r.conns, conn)
+	delete(r.active, conn)
 }
 
 // Ignite tries to Burn conn. If successful, conn is closed and returns true
@@ -309,9 +371,39 @@ func (T *Chef) Ignite(ctx context.Context, conn *fed.Conn) bool {
 
 	delete(T.byConn, conn)
 	delete(r.conns, conn)
+	delete(r.active, conn)
 	return true
 }
 
+// Acquire marks conn as in use by a client
+func (T *Chef) Acquire(conn *fed.Conn) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	r, ok := T.byConn[conn]
+	if !ok {
+		return
+	}
+
+	if r.active == nil {
+		r.active = make(map[*fed.Conn]struct{})
+	}
+	r.active[conn] = struct{}{}
+}
+
+// Release marks conn as no longer in use
+func (T *Chef) Release(conn *fed.Conn) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	r, ok := T.byConn[conn]
+	if !ok {
+		return
+	}
+
+	delete(r.active, conn)
+}
+
 func (T *Chef) Cancel(ctx context.Context, conn *fed.Conn) {
 	T.mu.Lock()
 	defer T.mu.Unlock()
@@ -321,7 +413,7 @@ func (T *Chef) Cancel(ctx context.Context, conn *fed.Conn) {
 		return
 	}
 
-	r.recipe.Cancel(ctx, conn.BackendKey)
+	r.recipe.Cancel(ctx, conn)
 }
 
 func (T *Chef) Close(ctx context.Context) {
@@ -336,5 +428,6 @@ func (T *Chef) Close(ctx context.Context) {
 
 		delete(T.byConn, conn)
 		delete(r.conns, conn)
+		delete(r.active, conn)
 	}
 }
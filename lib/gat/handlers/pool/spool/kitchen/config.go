@@ -8,5 +8,7 @@ import (
 
 type Config struct {
 	Critics []pool.Critic
-	Logger  *zap.Logger
+	// Balancer orders recipes when scaling up. If nil, the recipe with the best rating is used
+	Balancer pool.Balancer
+	Logger   *zap.Logger
 }
@@ -2,25 +2,32 @@ package kitchen
 
 import (
 	"math"
+	"time"
 
 	"gfx.cafe/gfx/pggat/lib/fed"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
 )
 
 type Recipe struct {
+	name    string
 	recipe  *pool.Recipe
 	ratings []Rating
 	score   int
 	conns   map[*fed.Conn]struct{}
+	active  map[*fed.Conn]struct{}
+
+	// latency is a moving average of how long it takes to dial the recipe
+	latency time.Duration
 }
 
-func NewRecipe(recipe *pool.Recipe, initial []*fed.Conn) *Recipe {
+func NewRecipe(name string, recipe *pool.Recipe, initial []*fed.Conn) *Recipe {
 	conns := make(map[*fed.Conn]struct{}, len(initial))
 	for _, conn := range initial {
 		conns[conn] = struct{}{}
 	}
 
 	return &Recipe{
+		name:   name,
 		recipe: recipe,
 		conns:  conns,
 	}
@@ -32,3 +39,24 @@ func (T *Recipe) Rating() int {
 	}
 	return T.score + T.recipe.Priority
 }
+
+func (T *Recipe) observe(latency time.Duration) {
+	// exponential moving average, a = 1/8
+	if T.latency == 0 {
+		T.latency = latency
+	} else {
+		T.latency += (latency - T.latency) / 8
+	}
+}
+
+func (T *Recipe) Candidate() pool.Candidate {
+	return pool.Candidate{
+		Name:     T.name,
+		Priority: T.recipe.Priority,
+		Weight:   T.recipe.Weight,
+		Penalty:  T.score,
+		Conns:    len(T.conns),
+		Active:   len(T.active),
+		Latency:  T.latency,
+	}
+}
@@ -7,6 +7,7 @@ import (
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/trace"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/google/uuid"
@@ -16,9 +17,11 @@ import (
 	"gfx.cafe/gfx/pggat/lib/fed"
 	"gfx.cafe/gfx/pggat/lib/fed/middlewares/eqp"
 	"gfx.cafe/gfx/pggat/lib/fed/middlewares/ps"
+	"gfx.cafe/gfx/pggat/lib/fed/middlewares/querytimeout"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
 	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool/spool/kitchen"
 	"gfx.cafe/gfx/pggat/lib/gat/metrics"
+	"gfx.cafe/gfx/pggat/lib/instrumentation/prom"
 	"gfx.cafe/gfx/pggat/lib/util/maps"
 )
 
@@ -34,12 +37,21 @@ type Pool struct {
 	serversByConn map[*fed.Conn]*Server
 	mu            sync.RWMutex
 
+	// holdTime is a moving average of how long clients hold a server
+	holdTime atomic.Int64
+
 	tracer trace.Tracer
 }
 
 // MakePool will create a new pool with config. ScaleLoop must be called if this is used instead of NewPool
 func MakePool(config Config) Pool {
 	pooler := config.PoolerFactory.NewPooler()
+
+	var balancer pool.Balancer
+	if config.BalancerFactory != nil {
+		balancer = config.BalancerFactory.NewBalancer()
+	}
+
 	return Pool{
 		config: config,
 		pooler: pooler,
@@ -47,8 +59,9 @@ func MakePool(config Config) Pool {
 		closed: make(chan struct{}),
 
 		chef: kitchen.MakeChef(kitchen.Config{
-			Critics: config.Critics,
-			Logger:  config.Logger,
+			Critics:  config.Critics,
+			Balancer: balancer,
+			Logger:   config.Logger,
 		}),
 		tracer: otel.Tracer("spool", trace.WithInstrumentationAttributes(
 			attribute.String("component", "gfx.cafe/gfx/pggat/lib/gat/handlers/pool/spool/pool.go"),
@@ -63,6 +76,15 @@ func NewPool(ctx context.Context, config Config) *Pool {
 }
 
 func (T *Pool) addServer(conn *fed.Conn) {
+	if T.config.QueryTimeout != 0 {
+		conn.Middleware = append(
+			conn.Middleware,
+			querytimeout.NewServer(T.config.QueryTimeout, func() {
+				T.chef.Cancel(context.Background(), conn)
+			}),
+		)
+	}
+
 	if T.config.UsePacketTracing {
 		conn.Middleware = append(
 			conn.Middleware,
@@ -83,10 +105,29 @@ func (T *Pool) addServer(conn *fed.Conn) {
 	}
 
 	if T.config.UseEQP {
-		conn.Middleware = append(
-			conn.Middleware,
-			eqp.NewServer(),
-		)
+		if T.config.MaxPreparedStatements != 0 {
+			labels := prom.PreparedStatementLabels{
+				Database: conn.Database,
+				User:     conn.User,
+			}
+			conn.Middleware = append(
+				conn.Middleware,
+				eqp.NewCachingServer(eqp.CacheConfig{
+					MaxPreparedStatements: T.config.MaxPreparedStatements,
+					Hit: func() {
+						prom.PreparedStatements.Hits(labels).Inc()
+					},
+					Miss: func() {
+						prom.PreparedStatements.Misses(labels).Inc()
+					},
+				}),
+			)
+		} else {
+			conn.Middleware = append(
+				conn.Middleware,
+				eqp.NewServer(),
+			)
+		}
 	}
 
 	server := NewServer(conn)
@@ -146,6 +187,11 @@ func (T *Pool) RemoveRecipe(ctx context.Context, name string) {
 	}
 }
 
+// Recipe returns the preferred recipe of the pool
+func (T *Pool) Recipe() (*pool.Recipe, bool) {
+	return T.chef.Recipe()
+}
+
 func (T *Pool) Empty() bool {
 	return T.chef.Empty()
 }
@@ -266,25 +312,57 @@ func (T *Pool) ScaleLoop(ctx context.Context) {
 	}
 }
 
-func (T *Pool) AddClient(client uuid.UUID) {
-	T.pooler.AddClient(client)
+func (T *Pool) AddClient(client uuid.UUID, conn *fed.Conn) {
+	T.pooler.AddClient(client, conn)
 }
 
 func (T *Pool) RemoveClient(client uuid.UUID) {
 	T.pooler.DeleteClient(client)
 }
 
-func (T *Pool) Acquire(client uuid.UUID) *Server {
+// Saturated returns whether a new client should be rejected instead of waiting for a server
+func (T *Pool) Saturated() bool {
+	if T.config.MaxWaiters == 0 && T.config.MaxWaitEstimate == 0 {
+		return false
+	}
+
+	waiters := T.pooler.Waiters()
+	if T.config.MaxWaiters != 0 && waiters >= T.config.MaxWaiters {
+		return true
+	}
+
+	if T.config.MaxWaitEstimate != 0 && waiters > 0 {
+		T.mu.RLock()
+		servers := len(T.serversByID)
+		T.mu.RUnlock()
+
+		if servers == 0 {
+			return false
+		}
+
+		// everyone ahead of us (and us) needs to hold a server once
+		estimate := time.Duration(T.holdTime.Load()) * time.Duration(waiters+1) / time.Duration(servers)
+		if estimate > T.config.MaxWaitEstimate {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Acquire a server for client. Returns pool.ErrFailedToAcquirePeer if a server couldn't be acquired in time.
+func (T *Pool) Acquire(client uuid.UUID) (*Server, error) {
 	for {
 		serverID := T.pooler.Acquire(client, T.config.AcquireTimeout)
 		if serverID == uuid.Nil {
-			return nil
+			return nil, pool.ErrFailedToAcquirePeer
 		}
 
 		T.mu.RLock()
 		c, ok := T.serversByID[serverID]
 		if ok {
 			c.SetState(metrics.ConnStatePairing, client)
+			c.acquired = time.Now()
 		}
 		T.mu.RUnlock()
 
@@ -293,11 +371,35 @@ func (T *Pool) Acquire(client uuid.UUID) *Server {
 			continue
 		}
 
-		return c
+		T.chef.Acquire(c.Conn)
+
+		return c, nil
 	}
 }
 
+func (T *Pool) updateHoldTime(server *Server) {
+	if server.acquired.IsZero() {
+		return
+	}
+
+	// exponential moving average, a = 1/8
+	held := int64(time.Since(server.acquired))
+	for {
+		prev := T.holdTime.Load()
+		next := held
+		if prev != 0 {
+			next = prev + (held-prev)/8
+		}
+		if T.holdTime.CompareAndSwap(prev, next) {
+			break
+		}
+	}
+	server.acquired = time.Time{}
+}
+
 func (T *Pool) Release(ctx context.Context, server *Server) {
+	T.updateHoldTime(server)
+
 	if T.config.ResetQuery != "" {
 		server.SetState(metrics.ConnStateRunningResetQuery, uuid.Nil)
 
@@ -316,6 +418,7 @@ func (T *Pool) Release(ctx context.Context, server *Server) {
 		}
 	}
 
+	T.chef.Release(server.Conn)
 	T.pooler.Release(server.ID)
 
 	server.SetState(metrics.ConnStateIdle, uuid.Nil)
@@ -18,6 +18,9 @@ type Server struct {
 
 	txnCount atomic.Int64
 
+	// acquired is when the server was last acquired by a client
+	acquired time.Time
+
 	lastMetricsRead time.Time
 	state           metrics.ConnState
 	peer            uuid.UUID
@@ -0,0 +1,176 @@
+package replication
+
+import (
+	"context"
+	"sync"
+
+	"github.com/caddyserver/caddy/v2"
+	"go.uber.org/zap"
+
+	"gfx.cafe/gfx/pggat/lib/bouncer/bouncers/v2"
+	"gfx.cafe/gfx/pggat/lib/fed"
+	"gfx.cafe/gfx/pggat/lib/fed/middlewares/unterminate"
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+	"gfx.cafe/gfx/pggat/lib/gat"
+	"gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
+	"gfx.cafe/gfx/pggat/lib/perror"
+	"gfx.cafe/gfx/pggat/lib/util/strutil"
+)
+
+func init() {
+	caddy.RegisterModule((*Module)(nil))
+}
+
+var ErrTooManyConnections = perror.New(
+	perror.FATAL,
+	perror.TooManyConnections,
+	"too many replication connections",
+)
+
+// Module proxies replication connections 1:1 to the primary of the pool they are routed to. Other connections are
+// passed to the next handler. Replication connections can't be pooled, so each client gets its own server for the whole
+// session, dialed with the primary recipe and counted towards its max connections.
+//
+// The pool handler after this one authenticates the client and picks the primary, so replication follows the same
+// routes, credentials, and failovers as every other connection.
+type Module struct {
+	log *zap.Logger
+
+	sessions map[fed.BackendKey]session
+	mu       sync.Mutex
+}
+
+// session is the server of a replication client and the recipe it was dialed with
+type session struct {
+	server *fed.Conn
+	recipe *pool.Recipe
+}
+
+func (T *Module) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.handlers.replication",
+		New: func() caddy.Module {
+			return new(Module)
+		},
+	}
+}
+
+func (T *Module) Provision(ctx caddy.Context) error {
+	T.log = ctx.Logger()
+	return nil
+}
+
+func (T *Module) dial(client *fed.Conn, recipe *pool.Recipe) (*fed.Conn, error) {
+	dialer := recipe.Dialer
+
+	dialer.Parameters = make(map[strutil.CIString]string, len(client.InitialParameters)+len(recipe.Parameters)+1)
+	for key, value := range client.InitialParameters {
+		dialer.Parameters[key] = value
+	}
+	for key, value := range recipe.Parameters {
+		dialer.Parameters[key] = value
+	}
+	dialer.Parameters[strutil.MakeCIString("replication")] = client.Replication
+
+	return dialer.Dial()
+}
+
+func (T *Module) addSession(client *fed.Conn, s session) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	if T.sessions == nil {
+		T.sessions = make(map[fed.BackendKey]session)
+	}
+	T.sessions[client.BackendKey] = s
+}
+
+func (T *Module) removeSession(client *fed.Conn) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	delete(T.sessions, client.BackendKey)
+}
+
+func (T *Module) ServeReplication(ctx context.Context, client *fed.Conn, recipe *pool.Recipe) error {
+	if !recipe.Allocate() {
+		return ErrTooManyConnections
+	}
+	defer recipe.Free()
+
+	server, err := T.dial(client, recipe)
+	if err != nil {
+		T.log.Error("failed to dial replication server", zap.Error(err))
+		return err
+	}
+	defer func() {
+		_ = server.Close(ctx)
+	}()
+
+	T.addSession(client, session{
+		server: server,
+		recipe: recipe,
+	})
+	defer T.removeSession(client)
+
+	client.Middleware = append(client.Middleware, unterminate.Unterminate)
+
+	for key, value := range server.InitialParameters {
+		p := packets.ParameterStatus{
+			Key:   key.String(),
+			Value: value,
+		}
+		if err = client.WritePacket(ctx, &p); err != nil {
+			return err
+		}
+	}
+
+	rfq := packets.ReadyForQuery('I')
+	if err = client.WritePacket(ctx, &rfq); err != nil {
+		return err
+	}
+	client.Ready = true
+
+	for {
+		var packet fed.Packet
+		packet, err = client.ReadPacket(ctx, true)
+		if err != nil {
+			return err
+		}
+
+		clientErr, serverErr := bouncers.Bounce(ctx, client, server, packet)
+		if serverErr != nil {
+			return serverErr
+		}
+		if clientErr != nil {
+			return clientErr
+		}
+	}
+}
+
+func (T *Module) Handle(next gat.Router) gat.Router {
+	return gat.RouterFunc(func(ctx context.Context, conn *fed.Conn) error {
+		if conn.Replication != "" {
+			ctx = pool.WithReplicationServer(ctx, T)
+		}
+		return next.Route(ctx, conn)
+	})
+}
+
+func (T *Module) Cancel(ctx context.Context, key fed.BackendKey) {
+	T.mu.Lock()
+	s, ok := T.sessions[key]
+	T.mu.Unlock()
+
+	if !ok {
+		return
+	}
+
+	s.recipe.Cancel(ctx, s.server)
+}
+
+var _ gat.Handler = (*Module)(nil)
+var _ gat.CancellableHandler = (*Module)(nil)
+var _ pool.ReplicationServer = (*Module)(nil)
+var _ caddy.Module = (*Module)(nil)
+var _ caddy.Provisioner = (*Module)(nil)
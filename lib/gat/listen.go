@@ -3,6 +3,7 @@ package gat
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -14,14 +15,20 @@ import (
 	"github.com/caddyserver/caddy/v2"
 	"go.uber.org/zap"
 
+	"gfx.cafe/gfx/pggat/lib/auth/gss"
 	"gfx.cafe/gfx/pggat/lib/fed"
 	"gfx.cafe/gfx/pggat/lib/fed/listeners/netconnlistener"
+	"gfx.cafe/gfx/pggat/lib/util/netutil"
+	"gfx.cafe/gfx/pggat/lib/util/proxyproto"
 )
 
 type ListenerConfig struct {
 	Address        string          `json:"address"`
 	SSL            json.RawMessage `json:"ssl,omitempty" caddy:"namespace=pggat.ssl.servers inline_key=provider"`
+	GSS            *GSSConfig      `json:"gss,omitempty"`
 	MaxConnections int             `json:"max_connections,omitempty"`
+
+	ProxyProtocol *ProxyProtocolConfig `json:"proxy_protocol,omitempty"`
 }
 
 type Listener struct {
@@ -29,6 +36,8 @@ type Listener struct {
 
 	networkAddress caddy.NetworkAddress
 	ssl            SSLServer
+	gss            *gss.Acceptor
+	proxyTrusted   []*net.IPNet
 
 	listener fed.Listener
 	open     atomic.Int64
@@ -74,6 +83,27 @@ func (T *Listener) Provision(ctx caddy.Context) error {
 		T.ssl = val.(SSLServer)
 	}
 
+	if T.GSS != nil {
+		var err error
+		T.gss, err = gss.LoadAcceptor(T.GSS.Keytab, T.GSS.Principal)
+		if err != nil {
+			return err
+		}
+	}
+
+	if T.ProxyProtocol != nil {
+		if len(T.ProxyProtocol.Trusted) == 0 {
+			return errors.New("proxy_protocol requires at least one trusted proxy")
+		}
+		for _, trusted := range T.ProxyProtocol.Trusted {
+			network, err := netutil.ParseIPNet(trusted)
+			if err != nil {
+				return fmt.Errorf("parsing trusted proxy: %v", err)
+			}
+			T.proxyTrusted = append(T.proxyTrusted, network)
+		}
+	}
+
 	return nil
 }
 
@@ -89,6 +119,12 @@ func (T *Listener) Start() error {
 		return err
 	}
 	if netListener, ok := listener.(net.Listener); ok {
+		if T.ProxyProtocol != nil {
+			netListener = &proxyproto.Listener{
+				Listener: netListener,
+				Trusted:  T.proxyTrusted,
+			}
+		}
 		T.listener = &netconnlistener.Listener{Listener: netListener}
 	} else if fedListener, ok := listener.(fed.Listener); ok {
 		T.listener = fedListener
@@ -0,0 +1,61 @@
+package matchers
+
+import (
+	"net"
+
+	"github.com/caddyserver/caddy/v2"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	"gfx.cafe/gfx/pggat/lib/gat"
+	"gfx.cafe/gfx/pggat/lib/util/netutil"
+)
+
+func init() {
+	caddy.RegisterModule((*RemoteAddress)(nil))
+}
+
+// RemoteAddress matches clients connecting from any of the given IPs or CIDRs. Clients on unix sockets never match.
+type RemoteAddress struct {
+	Ranges []string `json:"ranges"`
+
+	networks []*net.IPNet
+}
+
+func (T *RemoteAddress) CaddyModule() caddy.ModuleInfo {
+	return caddy.ModuleInfo{
+		ID: "pggat.matchers.remote_address",
+		New: func() caddy.Module {
+			return new(RemoteAddress)
+		},
+	}
+}
+
+func (T *RemoteAddress) Provision(ctx caddy.Context) error {
+	T.networks = T.networks[:0]
+	for _, r := range T.Ranges {
+		network, err := netutil.ParseIPNet(r)
+		if err != nil {
+			return err
+		}
+		T.networks = append(T.networks, network)
+	}
+	return nil
+}
+
+func (T *RemoteAddress) Matches(conn *fed.Conn) bool {
+	ip := netutil.IP(conn.RemoteAddr())
+	if ip == nil {
+		return false
+	}
+
+	for _, network := range T.networks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+var _ gat.Matcher = (*RemoteAddress)(nil)
+var _ caddy.Module = (*RemoteAddress)(nil)
+var _ caddy.Provisioner = (*RemoteAddress)(nil)
@@ -0,0 +1,107 @@
+package matchers
+
+import (
+	"net"
+	"testing"
+
+	"github.com/caddyserver/caddy/v2"
+
+	"gfx.cafe/gfx/pggat/lib/fed"
+	"gfx.cafe/gfx/pggat/lib/fed/codecs/netconncodec"
+	"gfx.cafe/gfx/pggat/lib/util/netutil"
+	"gfx.cafe/gfx/pggat/lib/util/proxyproto"
+)
+
+type addrConn struct {
+	net.Conn
+	remote net.Addr
+}
+
+func (T addrConn) RemoteAddr() net.Addr {
+	return T.remote
+}
+
+func testConn(remote net.Addr) *fed.Conn {
+	conn, _ := net.Pipe()
+	return fed.NewConn(netconncodec.NewCodec(addrConn{Conn: conn, remote: remote}))
+}
+
+func TestRemoteAddress(t *testing.T) {
+	matcher := RemoteAddress{
+		Ranges: []string{"10.0.0.0/8", "192.168.1.5", "2001:db8::/32"},
+	}
+	if err := matcher.Provision(caddy.Context{}); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		addr     net.Addr
+		expected bool
+	}{
+		{"ipv4 in cidr", &net.TCPAddr{IP: net.ParseIP("10.1.2.3"), Port: 5432}, true},
+		{"ipv4 outside cidr", &net.TCPAddr{IP: net.ParseIP("11.1.2.3"), Port: 5432}, false},
+		{"bare ip", &net.TCPAddr{IP: net.ParseIP("192.168.1.5"), Port: 5432}, true},
+		{"bare ip neighbor", &net.TCPAddr{IP: net.ParseIP("192.168.1.6"), Port: 5432}, false},
+		{"ipv6 in cidr", &net.TCPAddr{IP: net.ParseIP("2001:db8::1"), Port: 5432}, true},
+		{"ipv6 outside cidr", &net.TCPAddr{IP: net.ParseIP("2001:db9::1"), Port: 5432}, false},
+		{"unix socket", &net.UnixAddr{Name: "/run/pggat/.s.PGSQL.5432", Net: "unix"}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if actual := matcher.Matches(testConn(test.addr)); actual != test.expected {
+				t.Errorf("expected %v but got %v", test.expected, actual)
+			}
+		})
+	}
+}
+
+func TestRemoteAddressProxyProtocol(t *testing.T) {
+	matcher := RemoteAddress{
+		Ranges: []string{"192.168.1.5"},
+	}
+	if err := matcher.Provision(caddy.Context{}); err != nil {
+		t.Fatal(err)
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	trusted, err := netutil.ParseIPNet("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	listener := &proxyproto.Listener{
+		Listener: l,
+		Trusted:  []*net.IPNet{trusted},
+	}
+	defer func() {
+		_ = listener.Close()
+	}()
+
+	go func() {
+		client, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			return
+		}
+		defer func() {
+			_ = client.Close()
+		}()
+		_, _ = client.Write([]byte("PROXY TCP4 192.168.1.5 10.0.0.1 50000 5432\r\n"))
+		_, _ = client.Read(make([]byte, 1))
+	}()
+
+	conn, err := listener.Accept()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	// the proxy itself is not in the ranges, only the client behind it
+	if !matcher.Matches(fed.NewConn(netconncodec.NewCodec(conn))) {
+		t.Error("expected the address from the PROXY header to match")
+	}
+}
@@ -0,0 +1,8 @@
+package gat
+
+// ProxyProtocolConfig accepts PROXY protocol v1 and v2 headers from load balancers, so clients are seen with their real
+// address instead of the load balancer's
+type ProxyProtocolConfig struct {
+	// Trusted are the IPs or CIDRs allowed to send headers. At least one is required
+	Trusted []string `json:"trusted,omitempty"`
+}
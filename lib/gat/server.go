@@ -209,10 +209,10 @@ func (T *Server) accept(ctx context.Context, listener *Listener, conn *fed.Conn)
 	var cancelKey fed.BackendKey
 	var isCanceling bool
 	var err error
-	cancelKey, isCanceling, err = frontends.Accept(conn, tlsConfig)
+	cancelKey, isCanceling, err = frontends.Accept(conn, tlsConfig, listener.gss)
 	if err != nil {
 		if !errors.Is(err, io.EOF) {
-			T.log.Warn("error accepting client", zap.Error(err))
+			T.log.Warn("error accepting client", zap.Stringer("remote", conn.RemoteAddr()), zap.Error(err))
 		}
 		return
 	}
@@ -18,6 +18,7 @@ import (
 	// middlewares
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/allowed_startup_parameters"
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/error"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/gssapi"
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/require_ssl"
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/rewrite_database"
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/rewrite_parameter"
@@ -29,13 +30,27 @@ import (
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/pgbouncer"
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/pgbouncer_spilo"
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/pool"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/replication"
 
 	// discovery
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/cloudnative_pg"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/consul"
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/digitalocean"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/dns"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/etcd"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/file"
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/google_cloud_sql"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/k8s_labels"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/patroni"
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/zalando_operator"
 
+	// discovery filters
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/filters/databases"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/filters/id"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/filters/min_replicas"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/filters/rename_database"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/filters/users"
+
 	// digitalocean filters
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/discovery/discoverers/digitalocean/filters/tag"
 
@@ -45,6 +60,12 @@ import (
 
 	// critics
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/pool/critics/latency"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/pool/critics/role"
+
+	// balancers
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/pool/balancers/least_active"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/pool/balancers/power_of_two"
+	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/pool/balancers/round_robin"
 
 	// pools
 	_ "gfx.cafe/gfx/pggat/lib/gat/handlers/pool/pools/basic"
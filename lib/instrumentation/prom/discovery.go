@@ -0,0 +1,22 @@
+package prom
+
+import (
+	"gfx.cafe/open/gotoprom"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	gotoprom.MustInit(&Discovery, "pggat_discovery", make(prometheus.Labels))
+}
+
+type DiscoveryLabels struct {
+	Discoverer string `label:"discoverer"`
+}
+
+var Discovery struct {
+	Added    func(DiscoveryLabels) prometheus.Counter `name:"added" help:"clusters added"`
+	Removed  func(DiscoveryLabels) prometheus.Counter `name:"removed" help:"clusters removed"`
+	Changed  func(DiscoveryLabels) prometheus.Counter `name:"changed" help:"clusters changed"`
+	Clusters func(DiscoveryLabels) prometheus.Gauge   `name:"clusters" help:"current clusters"`
+	Draining func(DiscoveryLabels) prometheus.Gauge   `name:"draining" help:"removed pools waiting for their clients to leave"`
+}
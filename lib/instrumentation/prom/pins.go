@@ -0,0 +1,20 @@
+package prom
+
+import (
+	"gfx.cafe/open/gotoprom"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	gotoprom.MustInit(&Pins, "pggat_pins", make(prometheus.Labels))
+}
+
+type PinLabels struct {
+	Database string `label:"database"`
+	User     string `label:"user"`
+	Reason   string `label:"reason"`
+}
+
+var Pins struct {
+	Pinned func(PinLabels) prometheus.Counter `name:"pinned" help:"clients pinned to their server because they used a session-level feature"`
+}
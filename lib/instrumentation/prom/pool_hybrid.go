@@ -46,4 +46,7 @@ var OperationHybrid struct {
 	Execution func(OperationHybridLabels) prometheus.Histogram `name:"execution_ms"  buckets:"1,5,10,30,75,150,300,500,1000,2000,5000,7500,10000,15000,30000" help:"ms that the txn took to execute on remote"`
 	Miss      func(OperationHybridLabels) prometheus.Counter   `name:"write_misses" help:"queries which failed replica"`
 	Hit       func(OperationHybridLabels) prometheus.Counter   `name:"write_hits" help:"queries which failed replica"`
+	Waiters   func(OperationHybridLabels) prometheus.Gauge     `name:"waiters" help:"clients waiting to acquire from pool"`
+	Rejected  func(OperationHybridLabels) prometheus.Counter   `name:"rejected" help:"clients rejected because the pool was saturated"`
+	Spills    func(OperationHybridLabels) prometheus.Counter   `name:"buffer_spills" help:"transactions which outgrew the replay buffer and spilled to disk"`
 }
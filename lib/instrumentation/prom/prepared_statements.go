@@ -0,0 +1,20 @@
+package prom
+
+import (
+	"gfx.cafe/open/gotoprom"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func init() {
+	gotoprom.MustInit(&PreparedStatements, "pggat_prepared_statements", make(prometheus.Labels))
+}
+
+type PreparedStatementLabels struct {
+	Database string `label:"database"`
+	User     string `label:"user"`
+}
+
+var PreparedStatements struct {
+	Hits   func(PreparedStatementLabels) prometheus.Counter `name:"hits" help:"prepared statements that were already on the server"`
+	Misses func(PreparedStatementLabels) prometheus.Counter `name:"misses" help:"prepared statements that had to be parsed on the server"`
+}
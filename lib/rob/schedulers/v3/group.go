@@ -0,0 +1,106 @@
+package schedulers
+
+import (
+	"time"
+
+	"gfx.cafe/gfx/pggat/lib/util/rbtree"
+)
+
+// Group is a set of users that share scheduling options. Groups are scheduled fairly against each other by weight, then
+// users are scheduled fairly within their group. Users that aren't in a named group are each in their own group with
+// weight 1.
+type Group struct {
+	// Weight is the share of worker time the group as a whole receives relative to other groups. A group with weight 2
+	// will be scheduled twice as often as a group with weight 1, no matter how many users are in either. 0 = 1
+	Weight float64
+	// MaxWorkers is the max number of workers that may be held by users in the group at once. 0 = unlimited
+	MaxWorkers int
+
+	// Stride is the weighted runtime of the group. It is the group's key in the scheduler's schedule
+	Stride time.Duration
+	// Scheduled is whether the group is in the scheduler's schedule
+	Scheduled bool
+
+	active   int
+	floor    time.Duration
+	schedule rbtree.RBTree[time.Duration, Job]
+}
+
+// Charge returns how much stride the group should be charged for one of its users holding a worker for dur.
+func (T *Group) Charge(dur time.Duration) time.Duration {
+	if T.Weight <= 0 {
+		return dur
+	}
+	return time.Duration(float64(dur) / T.Weight)
+}
+
+// Available returns whether a user in the group may acquire another worker.
+func (T *Group) Available() bool {
+	if T.MaxWorkers == 0 {
+		return true
+	}
+	return T.active < T.MaxWorkers
+}
+
+func (T *Group) acquire() {
+	T.active++
+}
+
+func (T *Group) release() {
+	T.active--
+}
+
+// nextStride returns the stride a new user of the group should start with
+func (T *Group) nextStride() time.Duration {
+	if s, _, ok := T.schedule.Max(); ok {
+		return s + 1
+	}
+	return T.floor
+}
+
+func (T *Group) pending() bool {
+	_, _, ok := T.schedule.Min()
+	return ok
+}
+
+// enqueue adds the job to the group's schedule
+func (T *Group) enqueue(job Job) {
+	u := job.User
+
+	// find empty slot
+	if u.Stride < T.floor {
+		u.Stride = T.floor
+	}
+	for _, ok := T.schedule.Get(u.Stride); ok; _, ok = T.schedule.Get(u.Stride) {
+		u.Stride++
+	}
+	T.schedule.Set(u.Stride, job)
+	u.Scheduled = true
+}
+
+// dequeue removes the user's job from the group's schedule. Returns false if it was already dispatched
+func (T *Group) dequeue(u *User) (Job, bool) {
+	if !u.Scheduled {
+		return Job{}, false
+	}
+	u.Scheduled = false
+
+	job, ok := T.schedule.Get(u.Stride)
+	if ok {
+		T.schedule.Delete(u.Stride)
+	}
+	return job, ok
+}
+
+// next removes and returns the job of the user with the lowest stride
+func (T *Group) next() (Job, bool) {
+	stride, job, ok := T.schedule.Min()
+	if !ok {
+		return Job{}, false
+	}
+	T.floor = stride
+
+	T.schedule.Delete(stride)
+	job.User.Scheduled = false
+	return job, true
+}
@@ -23,7 +23,8 @@ type Scheduler struct {
 
 	floor    time.Duration
 	users    map[uuid.UUID]*User
-	schedule rbtree.RBTree[time.Duration, Job]
+	groups   map[string]*Group
+	schedule rbtree.RBTree[time.Duration, *Group]
 
 	mu sync.Mutex
 }
@@ -69,7 +70,36 @@ func (T *Scheduler) DeleteWorker(worker uuid.UUID) {
 	T.queue = slices.Delete(T.queue, w)
 }
 
+// SetGroup creates or updates the group called name. Users added to the group with AddGroupUser will use its weight and
+// worker limit.
+func (T *Scheduler) SetGroup(name string, weight float64, maxWorkers int) {
+	T.mu.Lock()
+	defer T.mu.Unlock()
+
+	if T.closed {
+		return
+	}
+
+	if T.groups == nil {
+		T.groups = make(map[string]*Group)
+	}
+
+	g, ok := T.groups[name]
+	if !ok {
+		g = new(Group)
+		T.groups[name] = g
+	}
+	g.Weight = weight
+	g.MaxWorkers = maxWorkers
+}
+
 func (T *Scheduler) AddUser(user uuid.UUID) {
+	T.AddGroupUser(user, "")
+}
+
+// AddGroupUser is like AddUser, but the user will be part of group if it exists. Otherwise, the user is put in a group of
+// its own.
+func (T *Scheduler) AddGroupUser(user uuid.UUID, group string) {
 	T.mu.Lock()
 	defer T.mu.Unlock()
 
@@ -81,15 +111,57 @@ func (T *Scheduler) AddUser(user uuid.UUID) {
 		T.users = make(map[uuid.UUID]*User)
 	}
 
-	stride := T.floor
-	if s, _, ok := T.schedule.Max(); ok {
-		stride = s + 1
+	g, ok := T.groups[group]
+	if !ok {
+		g = &Group{
+			Stride: T.floor,
+		}
+		if s, _, ok := T.schedule.Max(); ok {
+			g.Stride = s + 1
+		}
 	}
 
 	T.users[user] = &User{
 		ID:     user,
-		Stride: stride,
+		Stride: g.nextStride(),
+		Group:  g,
+	}
+}
+
+// scheduleGroup adds the group to the schedule
+func (T *Scheduler) scheduleGroup(g *Group) {
+	if g.Scheduled {
+		return
 	}
+
+	// find empty slot
+	if g.Stride < T.floor {
+		g.Stride = T.floor
+	}
+	for _, ok := T.schedule.Get(g.Stride); ok; _, ok = T.schedule.Get(g.Stride) {
+		g.Stride++
+	}
+	T.schedule.Set(g.Stride, g)
+	g.Scheduled = true
+}
+
+// unscheduleGroup removes the group from the schedule
+func (T *Scheduler) unscheduleGroup(g *Group) {
+	if !g.Scheduled {
+		return
+	}
+
+	T.schedule.Delete(g.Stride)
+	g.Scheduled = false
+}
+
+// dequeue removes the user's job from the schedule. Returns false if it was already dispatched
+func (T *Scheduler) dequeue(u *User) (Job, bool) {
+	job, ok := u.Group.dequeue(u)
+	if !u.Group.pending() {
+		T.unscheduleGroup(u.Group)
+	}
+	return job, ok
 }
 
 func (T *Scheduler) DeleteUser(user uuid.UUID) {
@@ -106,13 +178,8 @@ func (T *Scheduler) DeleteUser(user uuid.UUID) {
 	}
 	delete(T.users, user)
 
-	if u.Scheduled {
-		var j Job
-		j, ok = T.schedule.Get(u.Stride)
-		if ok {
-			close(j.Ready)
-			T.schedule.Delete(u.Stride)
-		}
+	if j, ok := T.dequeue(u); ok {
+		close(j.Ready)
 	}
 }
 
@@ -130,11 +197,14 @@ func (T *Scheduler) Acquire(user uuid.UUID, timeout time.Duration) uuid.UUID {
 			return uuid.Nil, nil
 		}
 
-		if len(T.queue) > 0 {
+		available := u.Group.Available()
+
+		if len(T.queue) > 0 && available {
 			worker := T.queue[len(T.queue)-1]
 			T.queue = T.queue[:len(T.queue)-1]
 
 			u.Worker = worker
+			u.Group.acquire()
 			worker.User = u
 			worker.Since = time.Now()
 
@@ -150,18 +220,14 @@ func (T *Scheduler) Acquire(user uuid.UUID, timeout time.Duration) uuid.UUID {
 			Ready: ready,
 		}
 
-		// find empty slot
-		if u.Stride < T.floor {
-			u.Stride = T.floor
-		}
-		for _, ok = T.schedule.Get(u.Stride); ok; _, ok = T.schedule.Get(u.Stride) {
-			u.Stride++
-		}
-		T.schedule.Set(u.Stride, job)
-		u.Scheduled = true
-		select {
-		case T.waiting <- struct{}{}:
-		default:
+		u.Group.enqueue(job)
+		T.scheduleGroup(u.Group)
+		if available {
+			// don't ask for more workers if we are just waiting for our group to free one
+			select {
+			case T.waiting <- struct{}{}:
+			default:
+			}
 		}
 
 		return uuid.Nil, ready
@@ -211,10 +277,8 @@ func (T *Scheduler) Acquire(user uuid.UUID, timeout time.Duration) uuid.UUID {
 				}
 			}
 
-			_, ok = T.schedule.Get(u.Stride)
+			_, ok = T.dequeue(u)
 			if ok {
-				u.Scheduled = false
-				T.schedule.Delete(u.Stride)
 				T.cc.Put(c)
 			} else {
 				// we lost the race, but we got a worker
@@ -234,31 +298,65 @@ func (T *Scheduler) releaseWorker(worker *Worker) {
 
 	// update prev user and state
 	if worker.User != nil {
-		worker.User.Stride += now.Sub(worker.Since)
+		dur := now.Sub(worker.Since)
+
+		// users are scheduled fairly within their group, the group's weight only applies between groups
+		worker.User.Stride += dur
+
+		g := worker.User.Group
+		scheduled := g.Scheduled
+		T.unscheduleGroup(g)
+		g.Stride += g.Charge(dur)
+		if scheduled {
+			T.scheduleGroup(g)
+		}
+
 		worker.User.Worker = nil
+		g.release()
 
 		worker.Since = now
 		worker.User = nil
 	}
 
-	// try to give it to the next pending
-	stride, job, ok := T.schedule.Min()
-	if !ok {
-		// no work available, append to queue
-		T.queue = append(T.queue, worker)
-		return
-	}
+	T.queue = append(T.queue, worker)
+	T.dispatch(now)
+}
+
+// dispatch gives idle workers to the pending user with the lowest stride in the group with the lowest stride. Groups at
+// their limit are skipped.
+func (T *Scheduler) dispatch(now time.Time) {
+	for len(T.queue) > 0 {
+		stride, g, ok := T.schedule.Min()
+		if !ok {
+			// no work available
+			return
+		}
+		T.floor = stride
+
+		for ok && !g.Available() {
+			stride, g, ok = T.schedule.Next(stride)
+		}
+		if !ok {
+			// all pending users are waiting on their group
+			return
+		}
 
-	T.floor = stride
-	T.schedule.Delete(stride)
+		worker := T.queue[len(T.queue)-1]
+		T.queue = T.queue[:len(T.queue)-1]
 
-	job.User.Worker = worker
-	job.User.Scheduled = false
+		job, _ := g.next()
+		if !g.pending() {
+			T.unscheduleGroup(g)
+		}
 
-	worker.Since = now
-	worker.User = job.User
+		job.User.Worker = worker
+		g.acquire()
 
-	job.Ready <- worker.ID
+		worker.Since = now
+		worker.User = job.User
+
+		job.Ready <- worker.ID
+	}
 }
 
 func (T *Scheduler) release(worker uuid.UUID) {
@@ -292,7 +390,8 @@ func (T *Scheduler) Waiters() int {
 	num := 0
 
 	for _, user := range T.users {
-		if user.Scheduled {
+		// users waiting on their group's limit won't be helped by more workers
+		if user.Scheduled && user.Group.Available() {
 			num++
 		}
 	}
@@ -311,10 +410,13 @@ func (T *Scheduler) Close() {
 	T.closed = true
 
 	T.users = nil
+	T.groups = nil
 	T.workers = nil
-	for stride, job, ok := T.schedule.Min(); ok; stride, job, ok = T.schedule.Min() {
+	for stride, g, ok := T.schedule.Min(); ok; stride, g, ok = T.schedule.Min() {
 		T.schedule.Delete(stride)
-		close(job.Ready)
+		for job, ok := g.next(); ok; job, ok = g.next() {
+			close(job.Ready)
+		}
 	}
 	T.queue = nil
 }
@@ -51,6 +51,20 @@ func testSource(sched *Scheduler, tab *ShareTable, id int, dur time.Duration) {
 	}
 }
 
+func testGroupSource(sched *Scheduler, tab *ShareTable, id int, group string, dur time.Duration) {
+	source := uuid.New()
+	sched.AddGroupUser(source, group)
+	for {
+		sink := sched.Acquire(source, 0)
+		start := time.Now()
+		for time.Since(start) < dur {
+			runtime.Gosched()
+		}
+		tab.Inc(id)
+		sched.Release(sink)
+	}
+}
+
 func testStarver(sched *Scheduler, tab *ShareTable, id int, dur time.Duration) {
 	for {
 		func() {
@@ -383,3 +397,112 @@ func TestScheduler_RemoveSinkOuter(t *testing.T) {
 		t.Errorf("%s", allStacks())
 	}
 }
+
+func TestScheduler_Weighted(t *testing.T) {
+	var table ShareTable
+	sched := new(Scheduler)
+	sched.SetGroup("batch", 0.25, 0)
+	testSink(sched)
+
+	go testGroupSource(sched, &table, 0, "batch", 10*time.Millisecond)
+	go testSource(sched, &table, 1, 10*time.Millisecond)
+	go testSource(sched, &table, 2, 10*time.Millisecond)
+
+	time.Sleep(10 * time.Second)
+	t0 := table.Get(0)
+	t1 := table.Get(1)
+	t2 := table.Get(2)
+
+	/*
+		Expectations:
+		- 1 and 2 should be similar
+		- 1 and 2 should have roughly 4x the executions of 0
+	*/
+
+	t.Log("share of 0:", t0)
+	t.Log("share of 1:", t1)
+	t.Log("share of 2:", t2)
+
+	if !similar(t1, t2) {
+		t.Error("expected s1 and s2 to be similar")
+	}
+
+	if !similar(t0*4, t1) {
+		t.Error("expected s0*4 and s1 to be similar")
+	}
+}
+
+func TestScheduler_GroupLimit(t *testing.T) {
+	var table ShareTable
+	sched := new(Scheduler)
+	sched.SetGroup("batch", 0, 1)
+	testSink(sched)
+	testSink(sched)
+
+	go testGroupSource(sched, &table, 0, "batch", 10*time.Millisecond)
+	go testGroupSource(sched, &table, 1, "batch", 10*time.Millisecond)
+	go testSource(sched, &table, 2, 10*time.Millisecond)
+
+	time.Sleep(10 * time.Second)
+	t0 := table.Get(0)
+	t1 := table.Get(1)
+	t2 := table.Get(2)
+
+	/*
+		Expectations:
+		- 0 and 1 share a single worker, so together they should have about as many executions as 2
+		- there should be no waiters asking for more workers, the group is just at its limit
+	*/
+
+	t.Log("share of 0:", t0)
+	t.Log("share of 1:", t1)
+	t.Log("share of 2:", t2)
+
+	if !similar(t0, t1) {
+		t.Error("expected s0 and s1 to be similar")
+	}
+
+	if !similar(t0+t1, t2) {
+		t.Error("expected s0+s1 and s2 to be similar")
+	}
+}
+
+func TestScheduler_WeightedGroup(t *testing.T) {
+	var table ShareTable
+	sched := new(Scheduler)
+	sched.SetGroup("batch", 1, 0)
+	testSink(sched)
+
+	go testGroupSource(sched, &table, 0, "batch", 10*time.Millisecond)
+	go testGroupSource(sched, &table, 1, "batch", 10*time.Millisecond)
+	go testGroupSource(sched, &table, 2, "batch", 10*time.Millisecond)
+	go testGroupSource(sched, &table, 3, "batch", 10*time.Millisecond)
+	go testSource(sched, &table, 4, 10*time.Millisecond)
+
+	time.Sleep(10 * time.Second)
+	t0 := table.Get(0)
+	t1 := table.Get(1)
+	t2 := table.Get(2)
+	t3 := table.Get(3)
+	t4 := table.Get(4)
+
+	/*
+		Expectations:
+		- 0, 1, 2, and 3 should be similar
+		- the batch group as a whole should have about as many executions as 4
+	*/
+
+	t.Log("share of 0:", t0)
+	t.Log("share of 1:", t1)
+	t.Log("share of 2:", t2)
+	t.Log("share of 3:", t3)
+	t.Log("share of 4:", t4)
+
+	if !similar(t0, t1, t2, t3) {
+		t.Error("expected s0, s1, s2, and s3 to be similar")
+	}
+
+	if !similar(t0+t1+t2+t3, t4) {
+		t.Error("expected s0+s1+s2+s3 and s4 to be similar")
+	}
+}
@@ -10,6 +10,9 @@ type User struct {
 	ID     uuid.UUID
 	Stride time.Duration
 
+	// Group is the group this user belongs to. Users that aren't in a named group have a group of their own
+	Group *Group
+
 	Scheduled bool
 
 	Worker *Worker
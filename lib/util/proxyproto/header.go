@@ -0,0 +1,152 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var (
+	ErrInvalidHeader = errors.New("invalid PROXY protocol header")
+
+	signatureV1 = []byte("PROXY ")
+	signatureV2 = []byte{0x0D, 0x0A, 0x0D, 0x0A, 0x00, 0x0D, 0x0A, 0x51, 0x55, 0x49, 0x54, 0x0A}
+)
+
+// maxLengthV1 is the max length of a v1 header, including the CRLF
+const maxLengthV1 = 107
+
+// ReadHeader reads a PROXY protocol v1 or v2 header if r starts with one, and returns the source address it carries.
+// The source is nil if there is no header, or if the header doesn't carry an address, like for health checks.
+func ReadHeader(r *bufio.Reader) (net.Addr, error) {
+	// only peek one byte at a time, the client might be waiting for a response to something shorter than a header.
+	// postgres messages never start with either signature.
+	b, err := r.Peek(1)
+	if err != nil {
+		return nil, err
+	}
+	switch b[0] {
+	case signatureV1[0]:
+		return readV1(r)
+	case signatureV2[0]:
+		return readV2(r)
+	default:
+		return nil, nil
+	}
+}
+
+func readV1(r *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for {
+		c, err := r.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		line = append(line, c)
+		if c == '\n' {
+			break
+		}
+		if len(line) >= maxLengthV1 {
+			return nil, ErrInvalidHeader
+		}
+	}
+
+	if !bytes.HasPrefix(line, signatureV1) || !bytes.HasSuffix(line, []byte("\r\n")) {
+		return nil, ErrInvalidHeader
+	}
+
+	fields := strings.Split(string(line[len(signatureV1):len(line)-2]), " ")
+	switch fields[0] {
+	case "UNKNOWN":
+		return nil, nil
+	case "TCP4", "TCP6":
+		if len(fields) != 5 {
+			return nil, ErrInvalidHeader
+		}
+		ip := net.ParseIP(fields[1])
+		if ip == nil || (fields[0] == "TCP4") != (ip.To4() != nil) {
+			return nil, ErrInvalidHeader
+		}
+		port, err := strconv.ParseUint(fields[3], 10, 16)
+		if err != nil {
+			return nil, ErrInvalidHeader
+		}
+		return &net.TCPAddr{
+			IP:   ip,
+			Port: int(port),
+		}, nil
+	default:
+		return nil, ErrInvalidHeader
+	}
+}
+
+func readV2(r *bufio.Reader) (net.Addr, error) {
+	var header [16]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	if !bytes.Equal(header[:12], signatureV2) {
+		return nil, ErrInvalidHeader
+	}
+
+	versionCommand := header[12]
+	if versionCommand>>4 != 2 {
+		return nil, ErrInvalidHeader
+	}
+	family := header[13]
+	length := binary.BigEndian.Uint16(header[14:16])
+
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+
+	switch versionCommand & 0xF {
+	case 0x0:
+		// LOCAL, the proxy connected on its own behalf
+		return nil, nil
+	case 0x1:
+		// PROXY
+	default:
+		return nil, ErrInvalidHeader
+	}
+
+	switch family {
+	case 0x11:
+		// TCP over IPv4
+		if len(payload) < 12 {
+			return nil, ErrInvalidHeader
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:4]),
+			Port: int(binary.BigEndian.Uint16(payload[8:10])),
+		}, nil
+	case 0x21:
+		// TCP over IPv6
+		if len(payload) < 36 {
+			return nil, ErrInvalidHeader
+		}
+		return &net.TCPAddr{
+			IP:   net.IP(payload[0:16]),
+			Port: int(binary.BigEndian.Uint16(payload[32:34])),
+		}, nil
+	case 0x31:
+		// unix stream
+		if len(payload) < 216 {
+			return nil, ErrInvalidHeader
+		}
+		name, _, _ := bytes.Cut(payload[0:108], []byte{0})
+		return &net.UnixAddr{
+			Name: string(name),
+			Net:  "unix",
+		}, nil
+	default:
+		// unspecified or datagram, ignore the addresses
+		return nil, nil
+	}
+}
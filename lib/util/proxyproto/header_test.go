@@ -0,0 +1,154 @@
+package proxyproto
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"testing"
+)
+
+func v2(command, family byte, payload []byte) []byte {
+	var b []byte
+	b = append(b, signatureV2...)
+	b = append(b, 0x20|command, family)
+	b = binary.BigEndian.AppendUint16(b, uint16(len(payload)))
+	return append(b, payload...)
+}
+
+func TestReadHeader(t *testing.T) {
+	v4 := []byte{10, 0, 0, 1, 10, 0, 0, 2, 0x30, 0x39, 0x15, 0x38}
+	v6 := make([]byte, 36)
+	v6[15] = 1
+	v6[31] = 2
+	binary.BigEndian.PutUint16(v6[32:], 12345)
+	binary.BigEndian.PutUint16(v6[34:], 5432)
+
+	tests := []struct {
+		name   string
+		input  []byte
+		source string
+		err    bool
+	}{
+		{name: "none", input: []byte{0, 0, 0, 8, 4, 210, 22, 47}},
+		{name: "v1 tcp4", input: []byte("PROXY TCP4 10.0.0.1 10.0.0.2 12345 5432\r\n"), source: "10.0.0.1:12345"},
+		{name: "v1 tcp6", input: []byte("PROXY TCP6 ::1 ::2 12345 5432\r\n"), source: "[::1]:12345"},
+		{name: "v1 unknown", input: []byte("PROXY UNKNOWN\r\n")},
+		{name: "v1 mismatched family", input: []byte("PROXY TCP4 ::1 ::2 12345 5432\r\n"), err: true},
+		{name: "v1 bad port", input: []byte("PROXY TCP4 10.0.0.1 10.0.0.2 123456 5432\r\n"), err: true},
+		{name: "v1 too long", input: append(append([]byte("PROXY "), bytes.Repeat([]byte("A"), 200)...), '\r', '\n'), err: true},
+		{name: "v2 tcp4", input: v2(0x1, 0x11, v4), source: "10.0.0.1:12345"},
+		{name: "v2 tcp4 with tlvs", input: v2(0x1, 0x11, append(v4, 0x04, 0x00, 0x01, 0xFF)), source: "10.0.0.1:12345"},
+		{name: "v2 tcp6", input: v2(0x1, 0x21, v6), source: "[::1]:12345"},
+		{name: "v2 local", input: v2(0x0, 0x11, v4)},
+		{name: "v2 short", input: v2(0x1, 0x11, v4[:4]), err: true},
+		{name: "v2 bad command", input: v2(0x2, 0x11, v4), err: true},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			r := bufio.NewReader(bytes.NewReader(append(test.input, "rest"...)))
+			source, err := ReadHeader(r)
+			if test.err {
+				if err == nil {
+					t.Fatal("expected error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var got string
+			if source != nil {
+				got = source.String()
+			}
+			if got != test.source {
+				t.Fatalf("expected source %q but got %q", test.source, got)
+			}
+
+			rest, err := io.ReadAll(r)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if test.name == "none" {
+				rest = rest[len(test.input):]
+			}
+			if string(rest) != "rest" {
+				t.Fatalf("header was not fully consumed, got %q", rest)
+			}
+		})
+	}
+}
+
+func TestListener(t *testing.T) {
+	tests := []struct {
+		name    string
+		trusted string
+		source  string
+	}{
+		{name: "trusted", trusted: "127.0.0.0/8", source: "10.0.0.1:12345"},
+		{name: "untrusted", trusted: "10.0.0.0/8"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, trusted, err := net.ParseCIDR(test.trusted)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			inner, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Skip("unable to listen:", err)
+			}
+			l := &Listener{
+				Listener: inner,
+				Trusted:  []*net.IPNet{trusted},
+			}
+			defer func() {
+				_ = l.Close()
+			}()
+
+			client, err := net.Dial("tcp", l.Addr().String())
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = client.Close()
+			}()
+			if _, err = client.Write([]byte("PROXY TCP4 10.0.0.1 10.0.0.2 12345 5432\r\nhello")); err != nil {
+				t.Fatal(err)
+			}
+
+			conn, err := l.Accept()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer func() {
+				_ = conn.Close()
+			}()
+
+			source := test.source
+			if source == "" {
+				source = client.LocalAddr().String()
+			}
+			if conn.RemoteAddr().String() != source {
+				t.Fatalf("expected remote address %s but got %s", source, conn.RemoteAddr())
+			}
+
+			if test.source == "" {
+				// untrusted conns see the header as data
+				return
+			}
+			b := make([]byte, 5)
+			if _, err = io.ReadFull(conn, b); err != nil {
+				t.Fatal(err)
+			}
+			if string(b) != "hello" {
+				t.Fatalf("expected hello but got %q", b)
+			}
+		})
+	}
+}
@@ -0,0 +1,95 @@
+package proxyproto
+
+import (
+	"bufio"
+	"net"
+	"sync"
+	"time"
+
+	"gfx.cafe/gfx/pggat/lib/util/netutil"
+)
+
+// HeaderTimeout is how long a proxy has to send the header
+const HeaderTimeout = 10 * time.Second
+
+// Listener accepts connections which may start with a PROXY protocol header. Headers are only read from trusted
+// sources, connections from anywhere else are returned as is.
+type Listener struct {
+	net.Listener
+
+	// Trusted are the sources allowed to send headers. If empty, no source is trusted
+	Trusted []*net.IPNet
+}
+
+func (T *Listener) trusted(addr net.Addr) bool {
+	ip := netutil.IP(addr)
+	if ip == nil {
+		return false
+	}
+
+	for _, trusted := range T.Trusted {
+		if trusted.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+func (T *Listener) Accept() (net.Conn, error) {
+	conn, err := T.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+
+	if !T.trusted(conn.RemoteAddr()) {
+		return conn, nil
+	}
+
+	return &Conn{
+		Conn:   conn,
+		reader: bufio.NewReader(conn),
+	}, nil
+}
+
+// Conn reads the PROXY protocol header the first time it is used, so a slow proxy doesn't hold up Accept
+type Conn struct {
+	net.Conn
+
+	reader *bufio.Reader
+	source net.Addr
+	err    error
+	once   sync.Once
+}
+
+func (T *Conn) readHeader() {
+	T.once.Do(func() {
+		if T.err = T.Conn.SetReadDeadline(time.Now().Add(HeaderTimeout)); T.err != nil {
+			return
+		}
+		T.source, T.err = ReadHeader(T.reader)
+		if T.err != nil {
+			return
+		}
+		T.err = T.Conn.SetReadDeadline(time.Time{})
+	})
+}
+
+func (T *Conn) Read(b []byte) (int, error) {
+	T.readHeader()
+	if T.err != nil {
+		return 0, T.err
+	}
+	return T.reader.Read(b)
+}
+
+// RemoteAddr returns the source address from the header, or the address of the peer if the header didn't have one
+func (T *Conn) RemoteAddr() net.Addr {
+	T.readHeader()
+	if T.source != nil {
+		return T.source
+	}
+	return T.Conn.RemoteAddr()
+}
+
+var _ net.Listener = (*Listener)(nil)
+var _ net.Conn = (*Conn)(nil)
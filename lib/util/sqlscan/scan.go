@@ -0,0 +1,233 @@
+package sqlscan
+
+import (
+	"strings"
+)
+
+type Kind int
+
+const (
+	// Word is a keyword or unquoted identifier
+	Word Kind = iota
+	// QuotedIdentifier is a double-quoted identifier, Value is unescaped
+	QuotedIdentifier
+	// String is a string constant, Value is unescaped
+	String
+	Number
+	// Param is a positional parameter like $1
+	Param
+	// Symbol is any other single character, like an operator or punctuation
+	Symbol
+)
+
+type Token struct {
+	Kind  Kind
+	Value string
+}
+
+// Is returns whether the token is the keyword
+func (T Token) Is(keyword string) bool {
+	return T.Kind == Word && strings.EqualFold(T.Value, keyword)
+}
+
+// Statement is the tokens of a single statement. Whitespace and comments are dropped.
+type Statement []Token
+
+// Is returns whether the statement starts with keywords
+func (T Statement) Is(keywords ...string) bool {
+	if len(T) < len(keywords) {
+		return false
+	}
+	for i, keyword := range keywords {
+		if !T[i].Is(keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f' || c == '\v'
+}
+
+func isWordStart(c byte) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c >= 0x80
+}
+
+func isWordChar(c byte) bool {
+	return isWordStart(c) || c == '$' || isDigit(c)
+}
+
+func isDigit(c byte) bool {
+	return c >= '0' && c <= '9'
+}
+
+type scanner struct {
+	query string
+	pos   int
+}
+
+// skip skips whitespace and comments
+func (T *scanner) skip() {
+	for T.pos < len(T.query) {
+		c := T.query[T.pos]
+		switch {
+		case isSpace(c):
+			T.pos++
+		case strings.HasPrefix(T.query[T.pos:], "--"):
+			end := strings.IndexByte(T.query[T.pos:], '\n')
+			if end == -1 {
+				T.pos = len(T.query)
+			} else {
+				T.pos += end + 1
+			}
+		case strings.HasPrefix(T.query[T.pos:], "/*"):
+			// block comments nest
+			depth := 0
+			for T.pos < len(T.query) {
+				if strings.HasPrefix(T.query[T.pos:], "/*") {
+					depth++
+					T.pos += 2
+				} else if strings.HasPrefix(T.query[T.pos:], "*/") {
+					depth--
+					T.pos += 2
+					if depth == 0 {
+						break
+					}
+				} else {
+					T.pos++
+				}
+			}
+		default:
+			return
+		}
+	}
+}
+
+// quoted reads a quoted string or identifier. The opening quote must be at T.pos
+func (T *scanner) quoted(quote byte, backslash bool) string {
+	var b strings.Builder
+	T.pos++
+	for T.pos < len(T.query) {
+		c := T.query[T.pos]
+		switch {
+		case backslash && c == '\\' && T.pos+1 < len(T.query):
+			b.WriteByte(T.query[T.pos+1])
+			T.pos += 2
+		case c == quote:
+			if T.pos+1 < len(T.query) && T.query[T.pos+1] == quote {
+				b.WriteByte(quote)
+				T.pos += 2
+				continue
+			}
+			T.pos++
+			return b.String()
+		default:
+			b.WriteByte(c)
+			T.pos++
+		}
+	}
+	return b.String()
+}
+
+// dollarQuoted reads a dollar quoted string if there is one at T.pos
+func (T *scanner) dollarQuoted() (string, bool) {
+	end := T.pos + 1
+	for end < len(T.query) && T.query[end] != '$' {
+		if !isWordChar(T.query[end]) {
+			return "", false
+		}
+		end++
+	}
+	if end >= len(T.query) {
+		return "", false
+	}
+	tag := T.query[T.pos : end+1]
+	if len(tag) > 2 && isDigit(tag[1]) {
+		return "", false
+	}
+
+	body := T.query[end+1:]
+	n := strings.Index(body, tag)
+	if n == -1 {
+		T.pos = len(T.query)
+		return body, true
+	}
+	T.pos = end + 1 + n + len(tag)
+	return body[:n], true
+}
+
+// next returns the next token. ok is false at the end of a statement
+func (T *scanner) next() (token Token, ok bool) {
+	T.skip()
+	if T.pos >= len(T.query) {
+		return
+	}
+
+	c := T.query[T.pos]
+	switch {
+	case c == ';':
+		T.pos++
+		return
+	case c == '\'':
+		return Token{Kind: String, Value: T.quoted('\'', false)}, true
+	case (c == 'E' || c == 'e') && T.pos+1 < len(T.query) && T.query[T.pos+1] == '\'':
+		T.pos++
+		return Token{Kind: String, Value: T.quoted('\'', true)}, true
+	case c == '"':
+		return Token{Kind: QuotedIdentifier, Value: T.quoted('"', false)}, true
+	case c == '$':
+		if value, ok := T.dollarQuoted(); ok {
+			return Token{Kind: String, Value: value}, true
+		}
+		start := T.pos
+		T.pos++
+		for T.pos < len(T.query) && isDigit(T.query[T.pos]) {
+			T.pos++
+		}
+		if T.pos-start == 1 {
+			return Token{Kind: Symbol, Value: "$"}, true
+		}
+		return Token{Kind: Param, Value: T.query[start:T.pos]}, true
+	case isWordStart(c):
+		start := T.pos
+		for T.pos < len(T.query) && isWordChar(T.query[T.pos]) {
+			T.pos++
+		}
+		return Token{Kind: Word, Value: T.query[start:T.pos]}, true
+	case isDigit(c) || (c == '.' && T.pos+1 < len(T.query) && isDigit(T.query[T.pos+1])):
+		start := T.pos
+		for T.pos < len(T.query) && (isDigit(T.query[T.pos]) || T.query[T.pos] == '.' || T.query[T.pos] == 'e' || T.query[T.pos] == 'E') {
+			T.pos++
+		}
+		return Token{Kind: Number, Value: T.query[start:T.pos]}, true
+	default:
+		T.pos++
+		return Token{Kind: Symbol, Value: string(c)}, true
+	}
+}
+
+// Split splits query into its statements. Empty statements are dropped. It is not a full parser, but it understands
+// comments and every kind of quoting, so semicolons and keywords inside them are never mistaken for real ones.
+func Split(query string) []Statement {
+	var statements []Statement
+
+	s := scanner{
+		query: query,
+	}
+	for s.pos < len(s.query) {
+		var statement Statement
+		for {
+			token, ok := s.next()
+			if !ok {
+				break
+			}
+			statement = append(statement, token)
+		}
+		if len(statement) > 0 {
+			statements = append(statements, statement)
+		}
+	}
+
+	return statements
+}
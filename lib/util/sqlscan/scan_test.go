@@ -0,0 +1,49 @@
+package sqlscan
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	cases := []struct {
+		query      string
+		statements []Statement
+	}{
+		{
+			query: "SELECT 1; select 'a;b'",
+			statements: []Statement{
+				{{Word, "SELECT"}, {Number, "1"}},
+				{{Word, "select"}, {String, "a;b"}},
+			},
+		},
+		{
+			query: `SET search_path = "My ""Schema""", public -- trailing; comment`,
+			statements: []Statement{
+				{{Word, "SET"}, {Word, "search_path"}, {Symbol, "="}, {QuotedIdentifier, `My "Schema"`}, {Symbol, ","}, {Word, "public"}},
+			},
+		},
+		{
+			query: "/* a /* nested; */ comment */ DO $body$ BEGIN; END $body$;;",
+			statements: []Statement{
+				{{Word, "DO"}, {String, " BEGIN; END "}},
+			},
+		},
+		{
+			query: `SELECT E'it\'s', $$;$$, $1`,
+			statements: []Statement{
+				{{Word, "SELECT"}, {String, "it's"}, {Symbol, ","}, {String, ";"}, {Symbol, ","}, {Param, "$1"}},
+			},
+		},
+		{
+			query: " ; -- nothing",
+		},
+	}
+
+	for _, c := range cases {
+		statements := Split(c.query)
+		if !reflect.DeepEqual(statements, c.statements) {
+			t.Errorf("%q: expected %v but got %v", c.query, c.statements, statements)
+		}
+	}
+}
@@ -266,6 +266,10 @@ func TestTester(t *testing.T) {
 		tests.EQP6,
 		tests.EQP7,
 		tests.EQP8,
+		tests.Pipeline0,
+		tests.Pipeline1,
+		tests.Pipeline2,
+		tests.Pipeline3,
 		tests.CopyOut0,
 		tests.CopyOut1,
 		tests.CopyIn0,
@@ -0,0 +1,96 @@
+package tests
+
+import (
+	"gfx.cafe/gfx/pggat/lib/fed"
+	packets "gfx.cafe/gfx/pggat/lib/fed/packets/v3.0"
+	"gfx.cafe/gfx/pggat/test"
+)
+
+// pipelineBatch is one query of a pipeline, like libpq pipeline mode and pgx batches send
+func pipelineBatch(query string) []fed.Packet {
+	return []fed.Packet{
+		&packets.Parse{
+			Query: query,
+		},
+		&packets.Bind{},
+		&packets.Describe{Which: 'P'},
+		&packets.Execute{},
+		&packets.Sync{},
+	}
+}
+
+func pipeline(batches ...[]fed.Packet) []fed.Packet {
+	var p []fed.Packet
+	for _, batch := range batches {
+		p = append(p, batch...)
+	}
+	return p
+}
+
+var Pipeline0 = test.Test{
+	Name: "Pipeline0",
+	Packets: pipeline(
+		pipelineBatch("select 0"),
+		pipelineBatch("select 1"),
+		pipelineBatch("select generate_series(1, 100)"),
+		pipelineBatch("select 2"),
+	),
+}
+
+// Pipeline1 fails in the middle. The server discards until the next Sync, and the rest of the pipeline runs.
+var Pipeline1 = test.Test{
+	Name: "Pipeline1",
+	Packets: pipeline(
+		pipelineBatch("select 0"),
+		[]fed.Packet{
+			&packets.Parse{
+				Destination: "a",
+				Query:       "select 1",
+			},
+			&packets.Parse{
+				Query: "this is a bad query",
+			},
+			&packets.Bind{},
+			&packets.Execute{},
+			&packets.Parse{
+				Destination: "b",
+				Query:       "select 2",
+			},
+			&packets.Sync{},
+		},
+		pipelineBatch("select 3"),
+		[]fed.Packet{
+			&packets.Describe{Which: 'S', Name: "a"},
+			&packets.Describe{Which: 'S', Name: "b"},
+			&packets.Sync{},
+		},
+	),
+}
+
+var Pipeline2 = test.Test{
+	Name: "Pipeline2",
+	Packets: pipeline(
+		[]fed.Packet{
+			MakeQuery("BEGIN"),
+		},
+		pipelineBatch("select 0"),
+		pipelineBatch("select 1/0"),
+		pipelineBatch("select 1"),
+		[]fed.Packet{
+			MakeQuery("END"),
+		},
+	),
+}
+
+// Pipeline3 has a pipeline deeper than the number of Syncs pggat forwards at once
+var Pipeline3 = test.Test{
+	Name: "Pipeline3",
+	Packets: func() []fed.Packet {
+		var batches [][]fed.Packet
+		for i := 0; i < 200; i++ {
+			batches = append(batches, pipelineBatch("select 0"))
+		}
+		batches = append(batches, []fed.Packet{MakeQuery("select 1")})
+		return pipeline(batches...)
+	}(),
+}